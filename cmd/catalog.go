@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/models"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// catalogKey is the catalog context key.
+const catalogKey xo.ContextKey = "catalog"
+
+// catalog is the bulk loaded catalog for a schema, keyed by table name.
+//
+// A nil map indicates the loader is not able to bulk load the objects, and
+// that the objects should be loaded per table.
+type catalog struct {
+	sequences    map[string][]*models.Sequence
+	columns      map[string][]*models.Column
+	foreignKeys  map[string][]*models.ForeignKey
+	indexes      map[string][]*models.Index
+	indexColumns map[string][]*models.IndexColumn
+}
+
+// withCatalog bulk loads the sequences, columns, indexes, and index columns
+// for the schema in the context, returning a context containing the catalog.
+func withCatalog(ctx context.Context) (context.Context, error) {
+	c := new(catalog)
+	var err error
+	if c.sequences, err = loader.AllSequences(ctx); err != nil {
+		return nil, err
+	}
+	if c.columns, err = loader.AllColumns(ctx); err != nil {
+		return nil, err
+	}
+	if c.indexes, err = loader.AllIndexes(ctx); err != nil {
+		return nil, err
+	}
+	if c.indexColumns, err = loader.AllIndexColumns(ctx); err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, catalogKey, c), nil
+}
+
+// withForeignKeyCatalog bulk loads the foreign keys for the schema in the
+// context, returning a context containing the catalog.
+func withForeignKeyCatalog(ctx context.Context) (context.Context, error) {
+	foreignKeys, err := loader.AllForeignKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, catalogKey, &catalog{
+		foreignKeys: foreignKeys,
+	}), nil
+}
+
+// getCatalog returns the catalog from the context.
+func getCatalog(ctx context.Context) *catalog {
+	if c, ok := ctx.Value(catalogKey).(*catalog); ok {
+		return c
+	}
+	return new(catalog)
+}
+
+// tableSequences returns the table sequences, using the catalog when
+// available.
+func tableSequences(ctx context.Context, table string) ([]*models.Sequence, error) {
+	if c := getCatalog(ctx); c.sequences != nil {
+		return c.sequences[table], nil
+	}
+	return loader.TableSequences(ctx, table)
+}
+
+// tableColumns returns the table columns, using the catalog when available.
+func tableColumns(ctx context.Context, table string) ([]*models.Column, error) {
+	if c := getCatalog(ctx); c.columns != nil {
+		return c.columns[table], nil
+	}
+	return loader.TableColumns(ctx, table)
+}
+
+// tableForeignKeys returns the table foreign keys, using the catalog when
+// available.
+func tableForeignKeys(ctx context.Context, table string) ([]*models.ForeignKey, error) {
+	if c := getCatalog(ctx); c.foreignKeys != nil {
+		return c.foreignKeys[table], nil
+	}
+	return loader.TableForeignKeys(ctx, table)
+}
+
+// tableIndexes returns the table indexes, using the catalog when available.
+func tableIndexes(ctx context.Context, table string) ([]*models.Index, error) {
+	if c := getCatalog(ctx); c.indexes != nil {
+		return c.indexes[table], nil
+	}
+	return loader.TableIndexes(ctx, table)
+}
+
+// indexColumns returns the index columns, using the catalog when available.
+func indexColumns(ctx context.Context, table, index string) ([]*models.IndexColumn, error) {
+	c := getCatalog(ctx)
+	if c.indexColumns == nil {
+		return loader.IndexColumns(ctx, table, index)
+	}
+	var cols []*models.IndexColumn
+	for _, col := range c.indexColumns[table] {
+		if col.IndexName == index {
+			cols = append(cols, col)
+		}
+	}
+	return cols, nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/models"
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestLoadSchemaCatalog(t *testing.T) {
+	sequences := []*models.Sequence{
+		{TableName: "authors", ColumnName: "id"},
+		{TableName: "books", ColumnName: "id"},
+	}
+	columns := []*models.Column{
+		{TableName: "authors", FieldOrdinal: 1, ColumnName: "id", DataType: "integer", NotNull: true, IsPrimaryKey: true},
+		{TableName: "authors", FieldOrdinal: 2, ColumnName: "name", DataType: "text", NotNull: true},
+		{TableName: "books", FieldOrdinal: 1, ColumnName: "id", DataType: "integer", NotNull: true, IsPrimaryKey: true},
+		{TableName: "books", FieldOrdinal: 2, ColumnName: "author_id", DataType: "integer", NotNull: true},
+		{TableName: "books", FieldOrdinal: 3, ColumnName: "title", DataType: "text", DefaultValue: sql.NullString{String: "''", Valid: true}},
+	}
+	foreignKeys := []*models.ForeignKey{
+		{TableName: "books", ForeignKeyName: "books_author_id_fkey", ColumnName: "author_id", RefSchemaName: "public", RefTableName: "authors", RefColumnName: "id"},
+	}
+	indexes := []*models.Index{
+		{TableName: "authors", IndexName: "authors_pkey", IsUnique: true, IsPrimary: true},
+		{TableName: "books", IndexName: "books_pkey", IsUnique: true, IsPrimary: true},
+		{TableName: "books", IndexName: "books_title_author_id_idx"},
+	}
+	indexColumns := []*models.IndexColumn{
+		{TableName: "authors", IndexName: "authors_pkey", SeqNo: 1, ColumnName: "id"},
+		{TableName: "books", IndexName: "books_pkey", SeqNo: 1, ColumnName: "id"},
+		{TableName: "books", IndexName: "books_title_author_id_idx", SeqNo: 1, ColumnName: "title"},
+		{TableName: "books", IndexName: "books_title_author_id_idx", SeqNo: 2, ColumnName: "author_id"},
+	}
+	var calls atomic.Int32
+	l := loader.Loader{
+		Tables: func(_ context.Context, _ models.DB, _, typ string) ([]*models.Table, error) {
+			if typ != "table" {
+				return nil, nil
+			}
+			return []*models.Table{{Type: "table", TableName: "books"}, {Type: "table", TableName: "authors"}}, nil
+		},
+		TableSequences: func(_ context.Context, _ models.DB, _, table string) ([]*models.Sequence, error) {
+			calls.Add(1)
+			return filterTable(sequences, table, func(s *models.Sequence) string { return s.TableName }), nil
+		},
+		TableColumns: func(_ context.Context, _ models.DB, _, table string) ([]*models.Column, error) {
+			calls.Add(1)
+			return filterTable(columns, table, func(c *models.Column) string { return c.TableName }), nil
+		},
+		TableForeignKeys: func(_ context.Context, _ models.DB, _, table string) ([]*models.ForeignKey, error) {
+			calls.Add(1)
+			return filterTable(foreignKeys, table, func(fk *models.ForeignKey) string { return fk.TableName }), nil
+		},
+		TableIndexes: func(_ context.Context, _ models.DB, _, table string) ([]*models.Index, error) {
+			calls.Add(1)
+			return filterTable(indexes, table, func(i *models.Index) string { return i.TableName }), nil
+		},
+		IndexColumns: func(_ context.Context, _ models.DB, _, table, index string) ([]*models.IndexColumn, error) {
+			calls.Add(1)
+			return filterTable(indexColumns, table+"."+index, func(ic *models.IndexColumn) string { return ic.TableName + "." + ic.IndexName }), nil
+		},
+	}
+	load := func(typ string, concurrency int) *xo.Set {
+		t.Helper()
+		ctx := context.WithValue(context.Background(), xo.DriverKey, typ)
+		ctx = context.WithValue(ctx, xo.SchemaKey, "public")
+		set := new(xo.Set)
+		if err := loadSchema(ctx, set, &Args{SchemaParams: SchemaParams{FkMode: "smart", Concurrency: concurrency}}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return set
+	}
+	// per table
+	loader.Register("catalogtest", l)
+	exp := load("catalogtest", 1)
+	if calls.Load() == 0 {
+		t.Fatalf("expected per table calls")
+	}
+	// bulk
+	l.AllSequences = func(context.Context, models.DB, string) ([]*models.Sequence, error) { return sequences, nil }
+	l.AllColumns = func(context.Context, models.DB, string) ([]*models.Column, error) { return columns, nil }
+	l.AllForeignKeys = func(context.Context, models.DB, string) ([]*models.ForeignKey, error) { return foreignKeys, nil }
+	l.AllIndexes = func(context.Context, models.DB, string) ([]*models.Index, error) { return indexes, nil }
+	l.AllIndexColumns = func(context.Context, models.DB, string) ([]*models.IndexColumn, error) { return indexColumns, nil }
+	loader.Register("catalogtest_bulk", l)
+	calls.Store(0)
+	set := load("catalogtest_bulk", 1)
+	if n := calls.Load(); n != 0 {
+		t.Errorf("expected no per table calls, got: %d", n)
+	}
+	if len(exp.Schemas) != 1 || len(exp.Schemas[0].Tables) != 2 || len(exp.Schemas[0].Tables[1].ForeignKeys) != 1 {
+		t.Fatalf("expected 2 tables with a foreign key, got: %+v", exp)
+	}
+	for i := range set.Schemas {
+		set.Schemas[i].Driver = exp.Schemas[i].Driver
+	}
+	if !reflect.DeepEqual(set, exp) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", exp, set)
+	}
+	// concurrently
+	for _, typ := range []string{"catalogtest", "catalogtest_bulk"} {
+		if set := load(typ, 4); !reflect.DeepEqual(set.Schemas[0].Tables, exp.Schemas[0].Tables) {
+			t.Errorf("%s expected:\n%+v\ngot:\n%+v", typ, exp.Schemas[0].Tables, set.Schemas[0].Tables)
+		}
+	}
+}
+
+// filterTable returns the rows for a table.
+func filterTable[T any](rows []T, table string, name func(T) string) []T {
+	var res []T
+	for _, row := range rows {
+		if name(row) == table {
+			res = append(res, row)
+		}
+	}
+	return res
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// check compares the generated files against the files in the out path,
+// writing a unified diff to w for each file that differs.
+func check(w io.Writer, out string, files map[string][]byte) error {
+	var stale int
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		name := displayPath(filepath.Join(out, file))
+		buf, err := os.ReadFile(filepath.Join(out, file))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if _, err := io.WriteString(w, unifiedDiff("/dev/null", name, nil, files[file])); err != nil {
+				return err
+			}
+			stale++
+			continue
+		case err != nil:
+			return err
+		case bytes.Equal(buf, files[file]):
+			continue
+		}
+		if _, err := io.WriteString(w, unifiedDiff(name, name, buf, files[file])); err != nil {
+			return err
+		}
+		stale++
+	}
+	if stale != 0 {
+		return fmt.Errorf("%d generated files are out of date", stale)
+	}
+	return nil
+}
+
+// displayPath returns the path relative to the working directory, when the
+// path is in the working directory.
+func displayPath(name string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+	if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return name
+}
+
+// diffContext is the number of context lines in a unified diff.
+const diffContext = 3
+
+// diffMax is the maximum number of line comparisons made when diffing. When
+// exceeded, the differing lines are reported as entirely replaced.
+const diffMax = 1 << 22
+
+// diffOp is a diff operation on a line.
+type diffOp struct {
+	op   byte
+	line string
+}
+
+// unifiedDiff returns the unified diff of a and b, or an empty string when a
+// and b are the same.
+func unifiedDiff(aname, bname string, a, b []byte) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	var sb strings.Builder
+	for i := 0; i < len(ops); {
+		// find next change
+		for i < len(ops) && ops[i].op == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		// extend hunk until the gap between changes exceeds the context
+		start, end := max(0, i-diffContext), i
+		for j := i; j < len(ops) && j-end <= 2*diffContext; j++ {
+			if ops[j].op != ' ' {
+				end = j
+			}
+		}
+		end = min(len(ops), end+diffContext+1)
+		// determine line numbers
+		var astart, bstart, acount, bcount int
+		for _, op := range ops[:start] {
+			if op.op != '+' {
+				astart++
+			}
+			if op.op != '-' {
+				bstart++
+			}
+		}
+		for _, op := range ops[start:end] {
+			if op.op != '+' {
+				acount++
+			}
+			if op.op != '-' {
+				bcount++
+			}
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aname, bname)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(astart, acount), hunkRange(bstart, bcount))
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.op)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats a unified diff hunk range.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits buf into lines, retaining line endings.
+func splitLines(buf []byte) []string {
+	if len(buf) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(buf), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the operations transforming a into b, using the longest
+// common subsequence of the lines between their common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	// common prefix and suffix
+	var p, s int
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+	var ops []diffOp
+	for _, line := range a[:p] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	x, y := a[p:len(a)-s], b[p:len(b)-s]
+	if len(x)*len(y) > diffMax {
+		for _, line := range x {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range y {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of x[i:]
+		// and y[j:]
+		lcs := make([][]int, len(x)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(y)+1)
+		}
+		for i := len(x) - 1; i >= 0; i-- {
+			for j := len(y) - 1; j >= 0; j-- {
+				if x[i] == y[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(x) && j < len(y) {
+			switch {
+			case x[i] == y[j]:
+				ops = append(ops, diffOp{' ', x[i]})
+				i, j = i+1, j+1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				ops = append(ops, diffOp{'-', x[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', y[j]})
+				j++
+			}
+		}
+		for ; i < len(x); i++ {
+			ops = append(ops, diffOp{'-', x[i]})
+		}
+		for ; j < len(y); j++ {
+			ops = append(ops, diffOp{'+', y[j]})
+		}
+	}
+	for _, line := range a[len(a)-s:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// plan writes the files that would be created, overwritten, appended to, or
+// left unchanged in the out path to w, along with their sizes and how their
+// content changed, followed by the stale files that would be removed.
+func plan(w io.Writer, out string, doAppend bool, files map[string][]byte, stale []string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		name := displayPath(filepath.Join(out, file))
+		action, status := "create", "new"
+		buf, err := os.ReadFile(filepath.Join(out, file))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return err
+		case bytes.Equal(buf, files[file]):
+			action, status = "unchanged", ""
+		case doAppend:
+			action, status = "append", fmt.Sprintf("changed (was %d bytes)", len(buf))
+		default:
+			action, status = "overwrite", fmt.Sprintf("changed (was %d bytes)", len(buf))
+		}
+		line := fmt.Sprintf("%s\t%s\t%d bytes", action, name, len(files[file]))
+		if status != "" {
+			line += "\t" + status
+		}
+		if _, err := fmt.Fprintln(tw, line); err != nil {
+			return err
+		}
+	}
+	for _, file := range stale {
+		if _, err := fmt.Fprintf(tw, "remove\t%s\n", displayPath(filepath.Join(out, file))); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		exp  string
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", ""},
+		{"", "a\nb\n", "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"a\nb\nc\n", "a\nx\nc\n", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"a\n", "a", "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-a\n+a\n\\ No newline at end of file\n"},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\nx\n3\n4\n5\n6\n7\n8\n9\n10\ny\n12\n",
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+x\n 3\n 4\n 5\n@@ -8,5 +8,5 @@\n 8\n 9\n 10\n-11\n+y\n 12\n",
+		},
+	}
+	for i, test := range tests {
+		if s := unifiedDiff("a", "b", []byte(test.a), []byte(test.b)); s != test.exp {
+			t.Errorf("test %d expected:\n%q\ngot:\n%q", i, test.exp, s)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	for name, buf := range map[string]string{"changed.go": "a\n", "same.go": "b\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(buf), 0o644); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	files := map[string][]byte{
+		"changed.go": []byte("a\nc\n"),
+		"new.go":     []byte("d\n"),
+		"same.go":    []byte("b\n"),
+	}
+	tests := []struct {
+		doAppend bool
+		exp      []string
+	}{
+		{false, []string{
+			"overwrite " + filepath.Join(dir, "changed.go") + " 4 bytes changed (was 2 bytes)",
+			"create " + filepath.Join(dir, "new.go") + " 2 bytes new",
+			"unchanged " + filepath.Join(dir, "same.go") + " 2 bytes",
+			"remove " + filepath.Join(dir, "old.go"),
+		}},
+		{true, []string{
+			"append " + filepath.Join(dir, "changed.go") + " 4 bytes changed (was 2 bytes)",
+			"create " + filepath.Join(dir, "new.go") + " 2 bytes new",
+			"unchanged " + filepath.Join(dir, "same.go") + " 2 bytes",
+			"remove " + filepath.Join(dir, "old.go"),
+		}},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		if err := plan(&buf, dir, test.doAppend, files, []string{"old.go"}); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		var lines []string
+		for line := range strings.Lines(buf.String()) {
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+		if !reflect.DeepEqual(lines, test.exp) {
+			t.Errorf("test %d expected:\n%q\ngot:\n%q", i, test.exp, lines)
+		}
+	}
+}
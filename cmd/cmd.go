@@ -68,12 +68,15 @@ type Args struct {
 	SchemaParams SchemaParams
 	// OutParams are out parameters.
 	OutParams OutParams
+	// DiffParams are diff parameters.
+	DiffParams DiffParams
 }
 
 // LoaderParams are loader parameters.
 type LoaderParams struct {
-	// Schema is the name of the database schema.
-	Schema string
+	// Schemas are the names of, or globs matching, the database schemas. The
+	// first name that is not a glob is the active schema.
+	Schemas []string
 }
 
 // TemplateParams are template parameters.
@@ -92,6 +95,9 @@ type TemplateParams struct {
 type QueryParams struct {
 	// Query is the query to introspect.
 	Query string
+	// File is an annotated SQL file or directory of files containing
+	// queries to introspect.
+	File string
 	// Type is the type name.
 	Type string
 	// TypeComment is the type comment.
@@ -110,6 +116,12 @@ type QueryParams struct {
 	Flat bool
 	// Exec toggles the generated code to do a db exec.
 	Exec bool
+	// ExecRows toggles the generated code to do a db exec, returning the
+	// rows affected.
+	ExecRows bool
+	// ExpectRows is the number of rows expected to be affected by an exec
+	// rows query.
+	ExpectRows int
 	// Interpolate enables interpolation.
 	Interpolate bool
 	// Delimiter is the delimiter for parameterized values.
@@ -118,6 +130,19 @@ type QueryParams struct {
 	Fields string
 	// AllowNulls enables results to have null types.
 	AllowNulls bool
+	// Introspect is the query introspection strategy (auto, view, or
+	// describe).
+	Introspect string
+	// ParamStyle is the query param style (delim, colon, at, or sqlc).
+	ParamStyle string
+	// NullMode is the query result nullability mode (view or infer).
+	NullMode string
+	// ReuseTypes is the schema snapshot with the table types to reuse for
+	// query results.
+	ReuseTypes string
+	// Results are the result sets returned by the query, as
+	// <name>[=<fields>].
+	Results []string
 }
 
 // SchemaParams are schema parameters.
@@ -144,6 +169,11 @@ type SchemaParams struct {
 	// to indexes (for example, 'authors__b124214__u_idx' instead of the more
 	// descriptive 'authors_title_idx').
 	UseIndexNames bool
+	// Concurrency is the number of tables introspected concurrently.
+	Concurrency int
+	// ProcResults are the result sets returned by stored procedures, as
+	// <proc>.<name>=<fields>.
+	ProcResults []string
 }
 
 // OutParams are out parameters.
@@ -156,6 +186,21 @@ type OutParams struct {
 	Single string
 	// Debug toggles direct writing of files to disk, skipping post processing.
 	Debug bool
+	// Check toggles checking that the generated files on disk are up to date,
+	// instead of writing the files.
+	Check bool
+	// DryRun toggles printing the files that would be written, instead of
+	// writing the files.
+	DryRun bool
+	// Prune toggles removing previously generated files that were not
+	// generated.
+	Prune bool
+}
+
+// DiffParams are diff parameters.
+type DiffParams struct {
+	// Format is the output format (text or json).
+	Format string
 }
 
 // newTemplateSet creates a new templates set.
@@ -176,11 +221,8 @@ func newTemplateSet(ctx context.Context, dir, template string) (*templates.Templ
 		ts.Use(template)
 	default:
 		// load specified template
-		s := snaker.SnakeToCamel(filepath.Base(dir))
-		s = strings.ReplaceAll(strings.ToLower(s), "_", "-")
-		// add template
-		var err error
-		if s, err = ts.Add(ctx, s, os.DirFS(dir), false); err != nil {
+		s, err := ts.Add(ctx, srcTarget(dir), os.DirFS(dir), false)
+		if err != nil {
 			return nil, err
 		}
 		// use
@@ -189,6 +231,12 @@ func newTemplateSet(ctx context.Context, dir, template string) (*templates.Templ
 	return ts, nil
 }
 
+// srcTarget returns the template target name for a template source directory.
+func srcTarget(dir string) string {
+	s := snaker.SnakeToCamel(filepath.Base(dir))
+	return strings.ReplaceAll(strings.ToLower(s), "_", "-")
+}
+
 // rootCommand creates the root command.
 func rootCommand(name string, ts *templates.Templates, args *Args) ([]ox.Option, error) {
 	// root
@@ -206,6 +254,8 @@ func rootCommand(name string, ts *templates.Templates, args *Args) ([]ox.Option,
 	for _, f := range []func(*templates.Templates, *Args) ([]ox.Option, error){
 		queryCommand,
 		schemaCommand,
+		generateCommand,
+		diffCommand,
 		dumpCommand,
 	} {
 		subopts, err := f(ts, args)
@@ -229,6 +279,11 @@ func queryCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 			ox.Bind(&args.QueryParams.Query),
 			ox.Short("Q"),
 		).
+		String(
+			"file", "annotated SQL file or directory of queries",
+			ox.Bind(&args.QueryParams.File),
+			ox.Short("f"),
+		).
 		String(
 			"type", "type name",
 			ox.Bind(&args.QueryParams.Type),
@@ -271,6 +326,14 @@ func queryCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 			ox.Bind(&args.QueryParams.Exec),
 			ox.Short("X"),
 		).
+		Bool(
+			"exec-rows", "enable exec returning rows affected (disables query introspection)",
+			ox.Bind(&args.QueryParams.ExecRows),
+		).
+		Int(
+			"expect-rows", "expected rows affected by exec rows queries",
+			ox.Bind(&args.QueryParams.ExpectRows),
+		).
 		Bool(
 			"interpolate", "enable interpolation of embedded params",
 			ox.Bind(&args.QueryParams.Interpolate),
@@ -287,10 +350,37 @@ func queryCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 			ox.Bind(&args.QueryParams.Fields),
 			ox.Short("Z"),
 		).
+		Slice(
+			"result", "result set name and optional fields (<name>[=<fields>]) for multiple result sets",
+			ox.Bind(&args.QueryParams.Results),
+		).
 		Bool(
 			"allow-nulls", "allow result fields with NULL values",
 			ox.Bind(&args.QueryParams.AllowNulls),
 			ox.Short("U"),
+		).
+		String(
+			"introspect", "query introspection strategy",
+			ox.Bind(&args.QueryParams.Introspect),
+			ox.Default("auto"),
+			ox.Valid(introspectModes...),
+		).
+		String(
+			"param-style", "query param style",
+			ox.Bind(&args.QueryParams.ParamStyle),
+			ox.Default("delim"),
+			ox.Valid(paramStyles...),
+		).
+		String(
+			"null-mode", "query result nullability mode",
+			ox.Bind(&args.QueryParams.NullMode),
+			ox.Default("view"),
+			ox.Valid(nullModes...),
+		).
+		String(
+			"reuse-types", "reuse table types from a schema snapshot for matching results",
+			ox.Bind(&args.QueryParams.ReuseTypes),
+			ox.Spec("<snapshot>"),
 		)
 	var err error
 	if fs, err = addFlags(fs, ts, args, true, false); err != nil {
@@ -336,6 +426,15 @@ func schemaCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 			"use-index-names", "use index names as defined in schema for generated code",
 			ox.Bind(&args.SchemaParams.UseIndexNames),
 			ox.Short("j"),
+		).
+		Int(
+			"concurrency", "number of tables to introspect concurrently",
+			ox.Bind(&args.SchemaParams.Concurrency),
+			ox.Default("1"),
+		).
+		Slice(
+			"proc-result", "result set name and fields returned by a stored procedure (<proc>.<name>=<fields>)",
+			ox.Bind(&args.SchemaParams.ProcResults),
 		)
 	var err error
 	if fs, err = addFlags(fs, ts, args, true, true); err != nil {
@@ -382,6 +481,9 @@ func dumpCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 					return err
 				case d.IsDir():
 					return os.MkdirAll(filepath.Join(v[0], n), 0o755)
+				case strings.HasSuffix(n, "_test.go"):
+					// template tests are not part of the template
+					return nil
 				}
 				buf, err := iofs.ReadFile(src, n)
 				if err != nil {
@@ -406,17 +508,11 @@ func exec(mode string, ts *templates.Templates, args *Args) func(context.Context
 		ctx = buildContext(ctx, mode, ts, args)
 		// enable verbose output for sql queries
 		if args.Verbose {
-			models.SetLogger(func(str string, v ...any) {
-				s, z := "SQL: %s\n", []any{str}
-				if len(v) != 0 {
-					s, z = s+"PARAMS: %v\n", append(z, v)
-				}
-				fmt.Printf(s+"\n", z...)
-			})
+			enableVerbose()
 		}
 		// open database
 		var err error
-		if ctx, err = open(ctx, cmdargs[0], args.LoaderParams.Schema); err != nil {
+		if ctx, err = open(ctx, cmdargs[0], activeSchema(args.LoaderParams.Schemas)); err != nil {
 			return err
 		}
 		// load
@@ -428,6 +524,17 @@ func exec(mode string, ts *templates.Templates, args *Args) func(context.Context
 	}
 }
 
+// enableVerbose enables verbose output for sql queries.
+func enableVerbose() {
+	models.SetLogger(func(str string, v ...any) {
+		s, z := "SQL: %s\n", []any{str}
+		if len(v) != 0 {
+			s, z = s+"PARAMS: %v\n", append(z, v)
+		}
+		fmt.Printf(s+"\n", z...)
+	})
+}
+
 // generate generates the dbtpl files with the provided templates, data, and
 // arguments.
 func generate(ctx context.Context, mode string, ts *templates.Templates, set *xo.Set, args *Args) error {
@@ -453,20 +560,63 @@ func generate(ctx context.Context, mode string, ts *templates.Templates, set *xo
 			return err
 		}
 	}
+	// check
+	if args.OutParams.Check {
+		return check(os.Stdout, args.OutParams.Out, ts.Files())
+	}
+	key := manifestKey(mode, args)
+	// dry run
+	if args.OutParams.DryRun {
+		var stale []string
+		if args.OutParams.Prune {
+			var err error
+			if stale, err = ts.Stale(key, args.OutParams.Out); err != nil {
+				return err
+			}
+		}
+		return plan(os.Stdout, args.OutParams.Out, args.OutParams.Append, ts.Files(), stale)
+	}
+	// prune
+	if args.OutParams.Prune {
+		ts.Prune(key, args.OutParams.Out)
+		if err := displayErrors(ts); err != nil {
+			return err
+		}
+	}
 	// dump
 	ts.Dump(args.OutParams.Out)
+	// record files for later pruning
+	if args.OutParams.Prune {
+		ts.Record(key, args.OutParams.Out)
+	}
 	if err := displayErrors(ts); err != nil {
 		return err
 	}
 	return nil
 }
 
+// manifestKey returns the key for the run's generated files in the out path's
+// manifest, so that runs generating different files into the same out path
+// only prune their own files. The key is the mode, and the single file, query
+// file, or query type.
+func manifestKey(mode string, args *Args) string {
+	switch {
+	case args.OutParams.Single != "":
+		return mode + ":" + args.OutParams.Single
+	case mode == "query" && args.QueryParams.File != "":
+		return mode + ":" + filepath.Base(args.QueryParams.File)
+	case mode == "query":
+		return mode + ":" + args.QueryParams.Type
+	}
+	return mode
+}
+
 // databaseFlags adds database flags to the flag set.
 func databaseFlags(fs *ox.FlagSet, args *Args) *ox.FlagSet {
 	return fs.
-		String(
-			"schema", "database schema name",
-			ox.Bind(&args.LoaderParams.Schema),
+		Slice(
+			"schema", "database schema names or globs",
+			ox.Bind(&args.LoaderParams.Schemas),
 			ox.Short("s"),
 		)
 }
@@ -494,6 +644,18 @@ func outFlags(fs *ox.FlagSet, args *Args) *ox.FlagSet {
 			"debug", "debug generated code (writes generated code to disk without post processing)",
 			ox.Bind(&args.OutParams.Debug),
 			ox.Short("D"),
+		).
+		Bool(
+			"check", "check generated files are up to date (does not write files)",
+			ox.Bind(&args.OutParams.Check),
+		).
+		Bool(
+			"dry-run", "print the files that would be written (does not write files)",
+			ox.Bind(&args.OutParams.DryRun),
+		).
+		Bool(
+			"prune", "remove previously generated files that were not generated",
+			ox.Bind(&args.OutParams.Prune),
 		)
 }
 
@@ -610,8 +772,29 @@ func checkArgs(mode string, ts *templates.Templates, args *Args) error {
 	if args.TemplateParams.SrcChanged && args.TemplateParams.TypeChanged {
 		return errors.New("--src and --template cannot be used together")
 	}
-	// read query string from stdin if not provided via --query
-	if mode == "query" && args.QueryParams.Query == "" {
+	// check --query and --file are exclusive
+	if args.QueryParams.Query != "" && args.QueryParams.File != "" {
+		return errors.New("--query and --file cannot be used together")
+	}
+	// check --type is not used with --file, as each query in the file has its
+	// own type
+	if args.QueryParams.Type != "" && args.QueryParams.File != "" {
+		return errors.New("--type cannot be used with --file")
+	}
+	// check --check and --dry-run are exclusive
+	if args.OutParams.Check && args.OutParams.DryRun {
+		return errors.New("--check and --dry-run cannot be used together")
+	}
+	// check concurrency
+	if mode == "schema" && args.SchemaParams.Concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+	// check query mode uses a single schema
+	if s := args.LoaderParams.Schemas; mode == "query" && (len(s) > 1 || len(s) == 1 && isGlob(s[0])) {
+		return errors.New("query mode does not support multiple schemas")
+	}
+	// read query string from stdin if not provided via --query or --file
+	if mode == "query" && args.QueryParams.Query == "" && args.QueryParams.File == "" {
 		buf, err := io.ReadAll(os.Stdin)
 		if err != nil {
 			return err
@@ -633,16 +816,9 @@ func checkArgs(mode string, ts *templates.Templates, args *Args) error {
 
 // buildContext builds a context for the mode and template.
 func buildContext(ctx context.Context, mode string, ts *templates.Templates, args *Args) context.Context {
-	// out params
-	ctx = context.WithValue(ctx, xo.OutKey, args.OutParams.Out)
-	ctx = context.WithValue(ctx, xo.AppendKey, args.OutParams.Append)
-	ctx = context.WithValue(ctx, xo.SingleKey, args.OutParams.Single)
+	ctx = outContext(ctx, args)
 	// add flags
-	flags := ts.Flags(args.TemplateParams.Type)
-	if mode == "schema" {
-		flags = append(flags, loader.Flags()...)
-	}
-	for _, g := range flags {
+	for _, g := range contextFlags(mode, ts, args) {
 		v, ok := otx.Any(ctx, g.Key())
 		if !ok {
 			panic(fmt.Sprintf("param %q was not defined in context vars", g.Key()))
@@ -668,9 +844,33 @@ func buildContext(ctx context.Context, mode string, ts *templates.Templates, arg
 	return ctx
 }
 
+// outContext adds the out params to the context.
+func outContext(ctx context.Context, args *Args) context.Context {
+	ctx = context.WithValue(ctx, xo.OutKey, args.OutParams.Out)
+	ctx = context.WithValue(ctx, xo.AppendKey, args.OutParams.Append)
+	ctx = context.WithValue(ctx, xo.SingleKey, args.OutParams.Single)
+	return ctx
+}
+
+// contextFlags returns the template and loader flags that are added to the
+// context for the mode.
+func contextFlags(mode string, ts *templates.Templates, args *Args) []xo.FlagSet {
+	flags := ts.Flags(args.TemplateParams.Type)
+	if mode == "schema" {
+		flags = append(flags, loader.Flags()...)
+	}
+	return flags
+}
+
 // open opens a connection to the database, returning a context for use in
 // template generation.
 func open(ctx context.Context, urlstr, schema string) (context.Context, error) {
+	switch {
+	case isSnapshot(urlstr):
+		return openSnapshot(ctx, urlstr, schema)
+	case isDDL(urlstr):
+		return openDDL(ctx, urlstr, schema)
+	}
 	v, err := user.Current()
 	if err != nil {
 		return nil, err
@@ -698,16 +898,43 @@ func open(ctx context.Context, urlstr, schema string) (context.Context, error) {
 	return ctx, nil
 }
 
+// activeSchema returns the first schema name that is not a glob.
+func activeSchema(schemas []string) string {
+	for _, schema := range schemas {
+		if !isGlob(schema) {
+			return schema
+		}
+	}
+	return ""
+}
+
+// isGlob determines if s is a glob pattern.
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[{")
+}
+
 // load loads a set of queries or schemas.
 func load(ctx context.Context, mode string, _ *templates.Templates, args *Args) (*xo.Set, error) {
+	_, db, _ := xo.DriverDbSchema(ctx)
+	_, snapshot := ctx.Value(snapshotKey).(*xo.Set)
 	f := loadSchema
-	if mode == "query" {
+	switch {
+	case mode == "query" && db == nil:
+		return nil, errors.New("query mode requires a database connection")
+	case mode == "query":
 		f = loadQuery
+	case snapshot:
+		f = loadSnapshot
 	}
 	set := new(xo.Set)
 	if err := f(ctx, set, args); err != nil {
 		return nil, err
 	}
+	if mode == "schema" {
+		if err := loadProcResults(set.Schemas, args.SchemaParams.ProcResults); err != nil {
+			return nil, err
+		}
+	}
 	return set, nil
 }
 
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xo/dbtpl/loader"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// ddlPrefix is the prefix for ddl urls.
+const ddlPrefix = "ddl:"
+
+// isDDL determines if urlstr refers to SQL DDL (ie,
+// "ddl:path/to/schema.sql?dialect=postgres").
+func isDDL(urlstr string) bool {
+	return strings.HasPrefix(urlstr, ddlPrefix)
+}
+
+// openDDL parses the SQL DDL file, or directory of *.sql files, returning a
+// context for use in template generation.
+//
+// The driver is set from the dialect query parameter, and no database
+// connection is made. When not provided, the schema name defaults to "public"
+// for postgres, and to the file name (without extension) otherwise.
+func openDDL(ctx context.Context, urlstr, schema string) (context.Context, error) {
+	name, query, _ := strings.Cut(strings.TrimPrefix(urlstr, ddlPrefix), "?")
+	v, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	dialect := v.Get("dialect")
+	if dialect == "" {
+		return nil, errors.New("ddl dialect not provided (ie, ddl:schema.sql?dialect=postgres)")
+	}
+	d, err := loader.NewDDL(dialect)
+	if err != nil {
+		return nil, err
+	}
+	// parse files
+	files := []string{name}
+	if isDir(name) {
+		if files, err = filepath.Glob(filepath.Join(name, "*.sql")); err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: no *.sql files found", name)
+		}
+		sort.Strings(files)
+	}
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Parse(string(buf)); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	// determine schema
+	if schema == "" {
+		schema = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		if d.Driver() == "postgres" {
+			schema = "public"
+		}
+	}
+	d.SetSchema(schema)
+	// add driver, schema, ddl to context
+	ctx = context.WithValue(ctx, xo.DriverKey, d.Driver())
+	ctx = context.WithValue(ctx, xo.SchemaKey, schema)
+	ctx = context.WithValue(ctx, loader.DDLKey, d)
+	return ctx, nil
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dbtpl/templates"
+	xo "github.com/xo/dbtpl/types"
+	"github.com/xo/ox"
+)
+
+// Diff is a difference between two schemas.
+type Diff struct {
+	// Kind is the kind of difference (added, removed, or changed).
+	Kind string `json:"kind"`
+	// Type is the type of the object (table, view, column, index, foreign key,
+	// enum, or proc).
+	Type string `json:"type"`
+	// Name is the name of the object. Columns, indexes and foreign keys are
+	// prefixed with their table name (ie, "books.title").
+	Name string `json:"name"`
+	// Changes are the changed attributes of a changed object.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Change is a changed attribute of an object.
+type Change struct {
+	// Attr is the attribute name.
+	Attr string `json:"attr"`
+	// Before is the attribute's value in the first schema.
+	Before string `json:"before"`
+	// After is the attribute's value in the second schema.
+	After string `json:"after"`
+}
+
+// diffCommand builds the diff command options.
+func diffCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
+	fs := ox.Flags()
+	fs = databaseFlags(fs, args)
+	fs = fs.
+		String(
+			"format", "output format",
+			ox.Bind(&args.DiffParams.Format),
+			ox.Short("f"),
+			ox.Default("text"),
+			ox.Valid("text", "json"),
+		).
+		Slice(
+			"include", "include types",
+			ox.Bind(&args.SchemaParams.Include),
+			ox.Elem(ox.GlobT),
+			ox.Short("i"),
+		).
+		Slice(
+			"exclude", "exclude types",
+			ox.Bind(&args.SchemaParams.Exclude),
+			ox.Short("e"),
+			ox.Elem(ox.GlobT),
+		)
+	return []ox.Option{
+		ox.Usage("diff", "compare two database schemas"),
+		ox.Banner("Compare two database schemas, exiting with a non-zero exit code when the schemas differ.\n\nEither database url can be a schema snapshot (snapshot:<path>) or SQL DDL (ddl:<path>?dialect=<dialect>)."),
+		ox.Spec("[flags] <database url> <database url>"),
+		ox.ValidArgs(2, 2),
+		fs,
+		ox.Exec(func(ctx context.Context, v []string) error {
+			// enable verbose output for sql queries
+			if args.Verbose {
+				enableVerbose()
+			}
+			if args.SchemaParams.FkMode == "" {
+				args.SchemaParams.FkMode = "smart"
+			}
+			// load schemas
+			var schemas []xo.Schema
+			for _, urlstr := range v {
+				ctx, err := open(ctx, urlstr, activeSchema(args.LoaderParams.Schemas))
+				if err != nil {
+					return err
+				}
+				set, err := load(ctx, "schema", ts, args)
+				if err != nil {
+					return err
+				}
+				if len(set.Schemas) != 1 {
+					return errors.New("diff does not support multiple schemas")
+				}
+				schemas = append(schemas, set.Schemas[0])
+			}
+			// diff
+			diffs := diffSchemas(schemas[0], schemas[1])
+			if err := writeDiffs(os.Stdout, args.DiffParams.Format, diffs); err != nil {
+				return err
+			}
+			if len(diffs) != 0 {
+				return errors.New("schemas differ")
+			}
+			return nil
+		}),
+	}, nil
+}
+
+// writeDiffs writes the diffs to w in the format.
+func writeDiffs(w io.Writer, format string, diffs []Diff) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Diffs []Diff `json:"diffs"`
+		}{
+			Diffs: diffs,
+		})
+	}
+	for _, d := range diffs {
+		c := "~"
+		switch d.Kind {
+		case "added":
+			c = "+"
+		case "removed":
+			c = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", c, d.Type, d.Name); err != nil {
+			return err
+		}
+		for _, change := range d.Changes {
+			if _, err := fmt.Fprintf(w, "    %s: %s -> %s\n", change.Attr, change.Before, change.After); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diffSchemas returns the differences between schemas a and b.
+func diffSchemas(a, b xo.Schema) []Diff {
+	var diffs []Diff
+	// enums
+	diffs = append(diffs, diffObjects("enum", a.Enums, b.Enums, func(e xo.Enum) string {
+		return e.Name
+	}, func(x, y xo.Enum) []Change {
+		return changes(nil, "values", enumValues(x), enumValues(y))
+	})...)
+	// procs
+	diffs = append(diffs, diffObjects("proc", a.Procs, b.Procs, procSignature, func(x, y xo.Proc) []Change {
+		var c []Change
+		c = changes(c, "returns", fieldTypes(x.Returns), fieldTypes(y.Returns))
+		c = changes(c, "definition", x.Definition, y.Definition)
+		return c
+	})...)
+	// tables, views
+	diffs = append(diffs, diffTables("table", a.Tables, b.Tables)...)
+	diffs = append(diffs, diffTables("view", a.Views, b.Views)...)
+	return diffs
+}
+
+// diffTables returns the differences between tables (or views) a and b,
+// including the differences of their columns, indexes and foreign keys.
+func diffTables(typ string, a, b []xo.Table) []Diff {
+	var diffs []Diff
+	for _, d := range diffObjects(typ, a, b, tableName, func(x, y xo.Table) []Change {
+		if typ != "view" {
+			return nil
+		}
+		return changes(nil, "definition", x.Definition, y.Definition)
+	}) {
+		if d.Kind != "changed" || len(d.Changes) != 0 {
+			diffs = append(diffs, d)
+		}
+		if d.Kind != "changed" {
+			continue
+		}
+		x, y := findTable(a, d.Name), findTable(b, d.Name)
+		// columns
+		diffs = append(diffs, prefix(d.Name, diffObjects("column", x.Columns, y.Columns, fieldName, func(x, y xo.Field) []Change {
+			var c []Change
+			c = changes(c, "type", typeString(x.Type), typeString(y.Type))
+			c = changes(c, "nullable", strconv.FormatBool(x.Type.Nullable), strconv.FormatBool(y.Type.Nullable))
+			c = changes(c, "default", x.Default, y.Default)
+			c = changes(c, "primary key", strconv.FormatBool(x.IsPrimary), strconv.FormatBool(y.IsPrimary))
+			return c
+		}))...)
+		// indexes
+		diffs = append(diffs, prefix(d.Name, diffObjects("index", x.Indexes, y.Indexes, func(i xo.Index) string {
+			return i.Name
+		}, func(x, y xo.Index) []Change {
+			var c []Change
+			c = changes(c, "columns", fieldNames(x.Fields), fieldNames(y.Fields))
+			c = changes(c, "unique", strconv.FormatBool(x.IsUnique), strconv.FormatBool(y.IsUnique))
+			c = changes(c, "primary", strconv.FormatBool(x.IsPrimary), strconv.FormatBool(y.IsPrimary))
+			return c
+		}))...)
+		// foreign keys
+		diffs = append(diffs, prefix(d.Name, diffObjects("foreign key", x.ForeignKeys, y.ForeignKeys, func(fkey xo.ForeignKey) string {
+			return fkey.Name
+		}, func(x, y xo.ForeignKey) []Change {
+			var c []Change
+			c = changes(c, "columns", fieldNames(x.Fields), fieldNames(y.Fields))
+			c = changes(c, "references", x.RefTable+"("+fieldNames(x.RefFields)+")", y.RefTable+"("+fieldNames(y.RefFields)+")")
+			return c
+		}))...)
+	}
+	return diffs
+}
+
+// diffObjects returns the added, removed, and changed objects between a and b,
+// keyed by name. Changed objects are returned for all objects in both a and
+// b, with the changes determined by f.
+func diffObjects[T any](typ string, a, b []T, name func(T) string, f func(T, T) []Change) []Diff {
+	var diffs []Diff
+	for _, x := range a {
+		i := slices.IndexFunc(b, func(y T) bool { return name(y) == name(x) })
+		if i == -1 {
+			diffs = append(diffs, Diff{Kind: "removed", Type: typ, Name: name(x)})
+			continue
+		}
+		c := f(x, b[i])
+		if typ == "table" || typ == "view" || len(c) != 0 {
+			diffs = append(diffs, Diff{Kind: "changed", Type: typ, Name: name(x), Changes: c})
+		}
+	}
+	for _, y := range b {
+		if !slices.ContainsFunc(a, func(x T) bool { return name(x) == name(y) }) {
+			diffs = append(diffs, Diff{Kind: "added", Type: typ, Name: name(y)})
+		}
+	}
+	slices.SortStableFunc(diffs, func(x, y Diff) int {
+		return strings.Compare(x.Name, y.Name)
+	})
+	return diffs
+}
+
+// changes appends a change to c when before and after differ.
+func changes(c []Change, attr, before, after string) []Change {
+	if before == after {
+		return c
+	}
+	return append(c, Change{Attr: attr, Before: before, After: after})
+}
+
+// prefix prefixes the diff names with the table name.
+func prefix(table string, diffs []Diff) []Diff {
+	for i := range diffs {
+		diffs[i].Name = table + "." + diffs[i].Name
+	}
+	return diffs
+}
+
+// findTable finds the named table.
+func findTable(tables []xo.Table, name string) xo.Table {
+	for _, t := range tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return xo.Table{}
+}
+
+// tableName returns the table's name.
+func tableName(t xo.Table) string {
+	return t.Name
+}
+
+// fieldName returns the field's name.
+func fieldName(f xo.Field) string {
+	return f.Name
+}
+
+// fieldNames returns the comma separated field names.
+func fieldNames(fields []xo.Field) string {
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// fieldTypes returns the comma separated field types.
+func fieldTypes(fields []xo.Field) string {
+	var types []string
+	for _, f := range fields {
+		types = append(types, typeString(f.Type))
+	}
+	return strings.Join(types, ", ")
+}
+
+// enumValues returns the comma separated enum values.
+func enumValues(e xo.Enum) string {
+	return fieldNames(e.Values)
+}
+
+// procSignature returns the proc's name and param types, distinguishing
+// overloaded procs.
+func procSignature(p xo.Proc) string {
+	return p.Name + "(" + fieldTypes(p.Params) + ")"
+}
+
+// typeString returns the database type definition, without its nullability.
+func typeString(typ xo.Type) string {
+	s := typ.Type
+	switch {
+	case typ.Prec != 0 && typ.Scale != 0:
+		s += "(" + strconv.Itoa(typ.Prec) + "," + strconv.Itoa(typ.Scale) + ")"
+	case typ.Prec != 0:
+		s += "(" + strconv.Itoa(typ.Prec) + ")"
+	}
+	if typ.Unsigned {
+		s += " unsigned"
+	}
+	if typ.IsArray {
+		s += "[]"
+	}
+	return s
+}
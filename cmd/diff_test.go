@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	authorID := xo.Field{Name: "author_id", Type: xo.Type{Type: "integer"}, IsPrimary: true}
+	name := xo.Field{Name: "name", Type: xo.Type{Type: "text"}}
+	a := xo.Schema{
+		Enums: []xo.Enum{
+			{Name: "book_type", Values: []xo.Field{{Name: "FICTION"}, {Name: "NONFICTION"}}},
+		},
+		Tables: []xo.Table{
+			{
+				Type:        "table",
+				Name:        "authors",
+				Columns:     []xo.Field{authorID, name},
+				PrimaryKeys: []xo.Field{authorID},
+				Indexes: []xo.Index{
+					{Name: "authors_pkey", Fields: []xo.Field{authorID}, IsUnique: true, IsPrimary: true},
+				},
+			},
+			{Type: "table", Name: "removed"},
+		},
+	}
+	b := xo.Schema{
+		Enums: []xo.Enum{
+			{Name: "book_type", Values: []xo.Field{{Name: "FICTION"}}},
+		},
+		Tables: []xo.Table{
+			{
+				Type: "table",
+				Name: "authors",
+				Columns: []xo.Field{
+					authorID,
+					{Name: "name", Type: xo.Type{Type: "varchar", Prec: 255, Nullable: true}},
+					{Name: "bio", Type: xo.Type{Type: "text"}},
+				},
+				PrimaryKeys: []xo.Field{authorID},
+				Indexes: []xo.Index{
+					{Name: "authors_pkey", Fields: []xo.Field{authorID}, IsUnique: true, IsPrimary: true},
+					{Name: "authors_name_idx", Fields: []xo.Field{name}},
+				},
+			},
+		},
+		Views: []xo.Table{
+			{Type: "view", Name: "added"},
+		},
+	}
+	diffs := diffSchemas(a, b)
+	exp := []Diff{
+		{Kind: "changed", Type: "enum", Name: "book_type", Changes: []Change{
+			{Attr: "values", Before: "FICTION, NONFICTION", After: "FICTION"},
+		}},
+		{Kind: "added", Type: "column", Name: "authors.bio"},
+		{Kind: "changed", Type: "column", Name: "authors.name", Changes: []Change{
+			{Attr: "type", Before: "text", After: "varchar(255)"},
+			{Attr: "nullable", Before: "false", After: "true"},
+		}},
+		{Kind: "added", Type: "index", Name: "authors.authors_name_idx"},
+		{Kind: "removed", Type: "table", Name: "removed"},
+		{Kind: "added", Type: "view", Name: "added"},
+	}
+	if !reflect.DeepEqual(diffs, exp) {
+		t.Errorf("expected:\n%#v\ngot:\n%#v", exp, diffs)
+	}
+	if diffs := diffSchemas(a, a); len(diffs) != 0 {
+		t.Errorf("expected no diffs, got: %#v", diffs)
+	}
+}
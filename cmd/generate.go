@@ -0,0 +1,516 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/kenshaw/glob"
+	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/templates"
+	xo "github.com/xo/dbtpl/types"
+	"github.com/xo/ox"
+)
+
+// Manifest is a generation manifest, describing a set of generation jobs run
+// against a single database connection.
+//
+// Manifests can be written as YAML or JSON.
+type Manifest struct {
+	// DSN is the database url.
+	DSN string `json:"dsn"`
+	// Schema is the database schema name.
+	Schema string `json:"schema,omitempty"`
+	// Schemas are the names of, or globs matching, additional database
+	// schemas to load in schema mode.
+	Schemas []string `json:"schemas,omitempty"`
+	// Template is the default template type.
+	Template string `json:"template,omitempty"`
+	// Src is the default template source directory.
+	Src string `json:"src,omitempty"`
+	// Out is the default out path.
+	Out string `json:"out,omitempty"`
+	// Concurrency is the default number of tables introspected concurrently
+	// in schema mode.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Flags are the default template and loader flags, keyed by the flag name
+	// (for example, "go-pkg").
+	Flags map[string]any `json:"flags,omitempty"`
+	// Jobs are the generation jobs.
+	Jobs []Job `json:"jobs,omitempty"`
+}
+
+// Job is a single generation job in a manifest.
+//
+// Unset values are inherited from the manifest.
+type Job struct {
+	// Mode is the generation mode (schema or query). When not specified, the
+	// mode is query when Query or File is set, and schema otherwise.
+	Mode string `json:"mode,omitempty"`
+	// Schema is the database schema name.
+	Schema string `json:"schema,omitempty"`
+	// Schemas are the names of, or globs matching, additional database
+	// schemas to load in schema mode.
+	Schemas []string `json:"schemas,omitempty"`
+	// Template is the template type.
+	Template string `json:"template,omitempty"`
+	// Src is the template source directory.
+	Src string `json:"src,omitempty"`
+	// Out is the out path.
+	Out string `json:"out,omitempty"`
+	// Append toggles appending to existing files.
+	Append bool `json:"append,omitempty"`
+	// Single is the file to output all contents to.
+	Single string `json:"single,omitempty"`
+	// Debug toggles writing generated code to disk without post processing.
+	Debug bool `json:"debug,omitempty"`
+	// Check toggles checking that the generated files on disk are up to date.
+	Check bool `json:"check,omitempty"`
+	// DryRun toggles printing the files that would be written.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Prune toggles removing previously generated files that were not
+	// generated.
+	Prune bool `json:"prune,omitempty"`
+	// Flags are the template and loader flags.
+	Flags map[string]any `json:"flags,omitempty"`
+	// FkMode is the foreign key resolution mode.
+	FkMode string `json:"fk_mode,omitempty"`
+	// Include are the include type globs.
+	Include []string `json:"include,omitempty"`
+	// Exclude are the exclude type globs.
+	Exclude []string `json:"exclude,omitempty"`
+	// UseIndexNames toggles using index names.
+	UseIndexNames bool `json:"use_index_names,omitempty"`
+	// Concurrency is the number of tables introspected concurrently.
+	Concurrency int `json:"concurrency,omitempty"`
+	// ProcResults are the result sets returned by stored procedures.
+	ProcResults []string `json:"proc_results,omitempty"`
+	// Query is the custom query.
+	Query string `json:"query,omitempty"`
+	// File is an annotated SQL file or directory of queries.
+	File string `json:"file,omitempty"`
+	// Type is the type name.
+	Type string `json:"type,omitempty"`
+	// TypeComment is the type comment.
+	TypeComment string `json:"type_comment,omitempty"`
+	// Func is the func name.
+	Func string `json:"func,omitempty"`
+	// FuncComment is the func comment.
+	FuncComment string `json:"func_comment,omitempty"`
+	// Trim enables trimming whitespace.
+	Trim bool `json:"trim,omitempty"`
+	// Strip enables stripping type casts.
+	Strip bool `json:"strip,omitempty"`
+	// One toggles returning a single result.
+	One bool `json:"one,omitempty"`
+	// Flat toggles returning unstructured values.
+	Flat bool `json:"flat,omitempty"`
+	// Exec toggles exec.
+	Exec bool `json:"exec,omitempty"`
+	// ExecRows toggles exec returning the rows affected.
+	ExecRows bool `json:"exec_rows,omitempty"`
+	// ExpectRows is the expected rows affected by exec rows queries.
+	ExpectRows int `json:"expect_rows,omitempty"`
+	// Interpolate enables interpolation of embedded params.
+	Interpolate bool `json:"interpolate,omitempty"`
+	// Delimiter is the delimiter for embedded params.
+	Delimiter string `json:"delimiter,omitempty"`
+	// Fields overrides the field names for results.
+	Fields string `json:"fields,omitempty"`
+	// AllowNulls enables results to have null types.
+	AllowNulls bool `json:"allow_nulls,omitempty"`
+	// Introspect is the query introspection strategy.
+	Introspect string `json:"introspect,omitempty"`
+	// ParamStyle is the query param style.
+	ParamStyle string `json:"param_style,omitempty"`
+	// NullMode is the query result nullability mode.
+	NullMode string `json:"null_mode,omitempty"`
+	// ReuseTypes is the schema snapshot with the table types to reuse for
+	// query results.
+	ReuseTypes string `json:"reuse_types,omitempty"`
+	// Results are the result sets returned by the query.
+	Results []string `json:"results,omitempty"`
+}
+
+// generateCommand builds the generate command options.
+func generateCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
+	return []ox.Option{
+		ox.Usage("generate", "generate code for the jobs in a manifest"),
+		ox.Banner("Generate code for the schema and query jobs defined in a YAML or JSON manifest."),
+		ox.Spec("[flags] <manifest>"),
+		ox.ValidArgs(1, 1),
+		ox.Exec(func(ctx context.Context, v []string) error {
+			// load manifest
+			m, err := readManifest(v[0])
+			if err != nil {
+				return err
+			}
+			// enable verbose output for sql queries
+			if args.Verbose {
+				enableVerbose()
+			}
+			// open database
+			if ctx, err = open(ctx, m.DSN, m.Schema); err != nil {
+				return err
+			}
+			// run jobs
+			for i, job := range m.Jobs {
+				if err := runJob(ctx, m, job); err != nil {
+					return fmt.Errorf("job %d: %w", i, err)
+				}
+			}
+			return nil
+		}),
+	}, nil
+}
+
+// readManifest reads a manifest from the named file. Relative paths in the
+// manifest are resolved relative to the manifest's directory.
+func readManifest(name string) (*Manifest, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	if err := yaml.Unmarshal(buf, m); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	switch {
+	case m.DSN == "":
+		return nil, fmt.Errorf("%s: dsn must be specified", name)
+	case len(m.Jobs) == 0:
+		return nil, fmt.Errorf("%s: no jobs defined", name)
+	}
+	if m.Out == "" {
+		m.Out = "models"
+	}
+	// resolve paths
+	dir := filepath.Dir(name)
+	m.Src, m.Out = resolvePath(dir, m.Src), resolvePath(dir, m.Out)
+	for i := range m.Jobs {
+		m.Jobs[i].Src, m.Jobs[i].Out = resolvePath(dir, m.Jobs[i].Src), resolvePath(dir, m.Jobs[i].Out)
+		m.Jobs[i].File = resolvePath(dir, m.Jobs[i].File)
+		m.Jobs[i].ReuseTypes = resolvePath(dir, m.Jobs[i].ReuseTypes)
+	}
+	return m, nil
+}
+
+// resolvePath resolves path relative to dir.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// runJob runs a manifest generation job.
+func runJob(ctx context.Context, m *Manifest, job Job) error {
+	mode, args, err := job.Args(m)
+	if err != nil {
+		return err
+	}
+	// build template set
+	ts, err := newTemplateSet(ctx, args.TemplateParams.Src, args.TemplateParams.Type)
+	if err != nil {
+		return err
+	}
+	args.TemplateTypes = ts.Targets()
+	if args.TemplateParams.Type == "" {
+		args.TemplateParams.Type = ts.Target()
+	}
+	// ensure out dir exists
+	if err := os.MkdirAll(args.OutParams.Out, 0o755); err != nil {
+		return err
+	}
+	// check args
+	if err := checkArgs(mode, ts, args); err != nil {
+		return err
+	}
+	// set template
+	ts.Use(args.TemplateParams.Type)
+	// build context
+	if ctx, err = manifestContext(ctx, mode, ts, args, m, job); err != nil {
+		return err
+	}
+	if job.Schema != "" {
+		ctx = context.WithValue(ctx, xo.SchemaKey, job.Schema)
+	}
+	// load
+	set, err := load(ctx, mode, ts, args)
+	if err != nil {
+		return err
+	}
+	return generate(ctx, mode, ts, set, args)
+}
+
+// Args builds the command-line arguments for the job, returning the job's
+// mode.
+func (job Job) Args(m *Manifest) (string, *Args, error) {
+	// determine mode
+	mode := job.Mode
+	switch {
+	case mode == "" && (job.Query != "" || job.File != ""):
+		mode = "query"
+	case mode == "":
+		mode = "schema"
+	case mode != "query" && mode != "schema":
+		return "", nil, fmt.Errorf("invalid mode %q", mode)
+	}
+	if mode == "query" && job.Query == "" && job.File == "" {
+		return "", nil, errors.New("query mode requires a query or file")
+	}
+	// inherit
+	typ, src, out := cmp.Or(job.Template, m.Template), cmp.Or(job.Src, m.Src), cmp.Or(job.Out, m.Out)
+	if typ != "" && src != "" {
+		return "", nil, errors.New("template and src cannot be used together")
+	}
+	// compile globs
+	include, err := compileGlobs(job.Include)
+	if err != nil {
+		return "", nil, err
+	}
+	exclude, err := compileGlobs(job.Exclude)
+	if err != nil {
+		return "", nil, err
+	}
+	// schemas
+	var schemas []string
+	if schema := cmp.Or(job.Schema, m.Schema); schema != "" {
+		schemas = append(schemas, schema)
+	}
+	switch {
+	case mode != "schema":
+	case len(job.Schemas) != 0:
+		schemas = append(schemas, job.Schemas...)
+	default:
+		schemas = append(schemas, m.Schemas...)
+	}
+	fkMode := cmp.Or(job.FkMode, "smart")
+	if !slices.Contains([]string{"smart", "parent", "field", "key"}, fkMode) {
+		return "", nil, fmt.Errorf("invalid fk mode %q", fkMode)
+	}
+	introspect := cmp.Or(job.Introspect, "auto")
+	if !slices.Contains(introspectModes, introspect) {
+		return "", nil, fmt.Errorf("invalid introspect strategy %q", introspect)
+	}
+	paramStyle := cmp.Or(job.ParamStyle, "delim")
+	if !slices.Contains(paramStyles, paramStyle) {
+		return "", nil, fmt.Errorf("invalid param style %q", paramStyle)
+	}
+	nullMode := cmp.Or(job.NullMode, "view")
+	if !slices.Contains(nullModes, nullMode) {
+		return "", nil, fmt.Errorf("invalid null mode %q", nullMode)
+	}
+	return mode, &Args{
+		LoaderParams: LoaderParams{
+			Schemas: schemas,
+		},
+		TemplateParams: TemplateParams{
+			Type:        typ,
+			TypeChanged: typ != "",
+			Src:         src,
+			SrcChanged:  src != "",
+		},
+		QueryParams: QueryParams{
+			Query:       job.Query,
+			File:        job.File,
+			Type:        job.Type,
+			TypeComment: job.TypeComment,
+			Func:        job.Func,
+			FuncComment: job.FuncComment,
+			Trim:        job.Trim,
+			Strip:       job.Strip,
+			One:         job.One,
+			Flat:        job.Flat,
+			Exec:        job.Exec,
+			ExecRows:    job.ExecRows,
+			ExpectRows:  job.ExpectRows,
+			Interpolate: job.Interpolate,
+			Delimiter:   cmp.Or(job.Delimiter, "%%"),
+			Fields:      job.Fields,
+			AllowNulls:  job.AllowNulls,
+			Introspect:  introspect,
+			ParamStyle:  paramStyle,
+			NullMode:    nullMode,
+			ReuseTypes:  job.ReuseTypes,
+			Results:     job.Results,
+		},
+		SchemaParams: SchemaParams{
+			FkMode:        fkMode,
+			Include:       include,
+			Exclude:       exclude,
+			UseIndexNames: job.UseIndexNames,
+			Concurrency:   cmp.Or(job.Concurrency, m.Concurrency, 1),
+			ProcResults:   job.ProcResults,
+		},
+		OutParams: OutParams{
+			Out:    out,
+			Append: job.Append,
+			Single: job.Single,
+			Debug:  job.Debug,
+			Check:  job.Check,
+			DryRun: job.DryRun,
+			Prune:  job.Prune,
+		},
+	}, nil
+}
+
+// manifestContext builds a context for the mode and template, using the flag
+// values defined in the job or manifest (in order of precedence) or the flag's
+// default value.
+//
+// All flags must be known template or loader flags, except that the
+// manifest's flags may be for the template of another job.
+func manifestContext(ctx context.Context, mode string, ts *templates.Templates, args *Args, m *Manifest, job Job) (context.Context, error) {
+	ctx = outContext(ctx, args)
+	// merge flags
+	values := make(map[string]any)
+	maps.Copy(values, m.Flags)
+	maps.Copy(values, job.Flags)
+	// add flags
+	known := make(map[string]bool)
+	for _, g := range contextFlags(mode, ts, args) {
+		known[g.Key()] = true
+		z := flagDefault(g)
+		if v, ok := values[g.Key()]; ok {
+			var err error
+			if z, err = flagValue(g, v); err != nil {
+				return nil, err
+			}
+		}
+		ctx = context.WithValue(ctx, g.Flag.ContextKey, z)
+	}
+	// loader flags are known in any mode
+	for _, g := range loader.Flags() {
+		known[g.Key()] = true
+	}
+	// check for unknown flags
+	targets := m.targets()
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		_, isJob := job.Flags[k]
+		other := slices.ContainsFunc(targets, func(target string) bool {
+			return target != args.TemplateParams.Type && strings.HasPrefix(k, target+"-")
+		})
+		if !known[k] && (isJob || !other) {
+			return nil, fmt.Errorf("unknown flag %q", k)
+		}
+	}
+	return ctx, nil
+}
+
+// targets returns the template targets used by the manifest's jobs.
+func (m *Manifest) targets() []string {
+	var targets []string
+	for _, job := range m.Jobs {
+		target := "go"
+		switch typ, src := cmp.Or(job.Template, m.Template), cmp.Or(job.Src, m.Src); {
+		case typ != "":
+			target = typ
+		case src != "":
+			target = srcTarget(src)
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// flagDefault returns the default value for the flag, as would be set for the
+// flag on the command-line.
+func flagDefault(g xo.FlagSet) any {
+	switch g.Flag.Type {
+	case "bool":
+		b, _ := g.Flag.Default.(bool)
+		return b
+	case "int":
+		i, _ := g.Flag.Default.(int)
+		return i
+	case "[]string":
+		switch x := g.Flag.Default.(type) {
+		case string:
+			return strings.Split(x, ",")
+		case []string:
+			return x
+		}
+		return []string(nil)
+	}
+	s, _ := g.Flag.Default.(string)
+	if g.Flag.Enums != nil && s == "" {
+		s = g.Flag.Enums[0]
+	}
+	return s
+}
+
+// flagValue converts a manifest value to the flag's type.
+func flagValue(g xo.FlagSet, v any) (any, error) {
+	var z any
+	switch g.Flag.Type {
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("flag %q must be a bool", g.Key())
+		}
+		z = b
+	case "int":
+		i, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil {
+			return nil, fmt.Errorf("flag %q must be an int", g.Key())
+		}
+		z = i
+	case "[]string":
+		var s []string
+		switch x := v.(type) {
+		case []any:
+			for _, y := range x {
+				s = append(s, fmt.Sprint(y))
+			}
+		case []string:
+			s = x
+		default:
+			s = []string{fmt.Sprint(x)}
+		}
+		for _, y := range s {
+			if err := checkEnum(g, y); err != nil {
+				return nil, err
+			}
+		}
+		z = s
+	default:
+		s := fmt.Sprint(v)
+		if err := checkEnum(g, s); err != nil {
+			return nil, err
+		}
+		z = s
+	}
+	return z, nil
+}
+
+// checkEnum checks that s is a valid enum value for the flag.
+func checkEnum(g xo.FlagSet, s string) error {
+	if g.Flag.Enums != nil && !slices.Contains(g.Flag.Enums, s) {
+		return fmt.Errorf("flag %q has invalid value %q (must be one of: %s)", g.Key(), s, strings.Join(g.Flag.Enums, ", "))
+	}
+	return nil
+}
+
+// compileGlobs compiles the patterns.
+func compileGlobs(patterns []string) ([]*glob.Glob, error) {
+	var globs []*glob.Glob
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xo/dbtpl/ddl"
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		buf  string
+		exp  *Manifest
+	}{
+		{
+			"manifest.yaml",
+			"dsn: pg://\nsrc: tpl\njobs:\n  - out: a\n    check: true\n    dry_run: true\n    prune: true\n  - file: q.sql\n    reuse_types: snap.yaml\n    out: /abs\n",
+			&Manifest{DSN: "pg://", Src: filepath.Join(dir, "tpl"), Out: filepath.Join(dir, "models"), Jobs: []Job{
+				{Out: filepath.Join(dir, "a"), Check: true, DryRun: true, Prune: true},
+				{Out: "/abs", File: filepath.Join(dir, "q.sql"), ReuseTypes: filepath.Join(dir, "snap.yaml")},
+			}},
+		},
+		{
+			"manifest.json",
+			`{"dsn": "pg://", "out": "/out", "flags": {"go-pkg": "x"}, "jobs": [{"mode": "schema", "concurrency": 2}]}`,
+			&Manifest{DSN: "pg://", Out: "/out", Flags: map[string]any{"go-pkg": "x"}, Jobs: []Job{
+				{Mode: "schema", Concurrency: 2},
+			}},
+		},
+		{"no-dsn.yaml", "jobs:\n  - out: a\n", nil},
+		{"no-jobs.yaml", "dsn: pg://\n", nil},
+		{"invalid.yaml", "dsn: [\n", nil},
+		{"missing.yaml", "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(dir, test.name)
+			if test.buf != "" {
+				if err := os.WriteFile(name, []byte(test.buf), 0o644); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+			}
+			m, err := readManifest(name)
+			switch {
+			case test.exp == nil && err == nil:
+				t.Errorf("expected error")
+			case test.exp != nil && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			case !reflect.DeepEqual(m, test.exp):
+				t.Errorf("expected:\n%+v\ngot:\n%+v", test.exp, m)
+			}
+		})
+	}
+}
+
+func TestJobArgs(t *testing.T) {
+	m := &Manifest{Schema: "public", Schemas: []string{"auth"}, Template: "go", Out: "models", Concurrency: 4}
+	tests := []struct {
+		name  string
+		job   Job
+		mode  string
+		check func(*Args) bool
+	}{
+		{"schema", Job{}, "schema", func(args *Args) bool {
+			return reflect.DeepEqual(args.LoaderParams.Schemas, []string{"public", "auth"}) &&
+				args.TemplateParams.Type == "go" && args.TemplateParams.TypeChanged &&
+				args.OutParams.Out == "models" && args.SchemaParams.FkMode == "smart" &&
+				args.SchemaParams.Concurrency == 4
+		}},
+		{"schema override", Job{Schema: "billing", Schemas: []string{"audit"}, Out: "db", Concurrency: 2}, "schema", func(args *Args) bool {
+			return reflect.DeepEqual(args.LoaderParams.Schemas, []string{"billing", "audit"}) &&
+				args.OutParams.Out == "db" && args.SchemaParams.Concurrency == 2
+		}},
+		{"query", Job{Query: "SELECT 1"}, "query", func(args *Args) bool {
+			return reflect.DeepEqual(args.LoaderParams.Schemas, []string{"public"}) &&
+				args.QueryParams.Delimiter == "%%" && args.QueryParams.Introspect == "auto" &&
+				args.QueryParams.ParamStyle == "delim" && args.QueryParams.NullMode == "view"
+		}},
+		{"query file", Job{File: "q.sql", Delimiter: "$$", NullMode: "view"}, "query", func(args *Args) bool {
+			return args.QueryParams.File == "q.sql" && args.QueryParams.Delimiter == "$$" && args.QueryParams.NullMode == "view"
+		}},
+		{"check", Job{Check: true}, "schema", func(args *Args) bool {
+			return args.OutParams.Check
+		}},
+		{"dry run", Job{DryRun: true}, "schema", func(args *Args) bool {
+			return args.OutParams.DryRun
+		}},
+		{"prune", Job{Prune: true}, "schema", func(args *Args) bool {
+			return args.OutParams.Prune
+		}},
+		{"include", Job{Include: []string{"a*"}, Exclude: []string{"b*"}}, "schema", func(args *Args) bool {
+			return len(args.SchemaParams.Include) == 1 && len(args.SchemaParams.Exclude) == 1
+		}},
+		{"invalid mode", Job{Mode: "other"}, "", nil},
+		{"query without query", Job{Mode: "query"}, "", nil},
+		{"template and src", Job{Src: "tpl"}, "", nil},
+		{"invalid glob", Job{Include: []string{"a["}}, "", nil},
+		{"invalid fk mode", Job{FkMode: "other"}, "", nil},
+		{"invalid introspect", Job{Query: "SELECT 1", Introspect: "other"}, "", nil},
+		{"invalid param style", Job{Query: "SELECT 1", ParamStyle: "other"}, "", nil},
+		{"invalid null mode", Job{Query: "SELECT 1", NullMode: "other"}, "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mode, args, err := test.job.Args(m)
+			switch {
+			case test.check == nil && err == nil:
+				t.Errorf("expected error")
+			case test.check != nil && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			case test.check == nil:
+			case mode != test.mode:
+				t.Errorf("expected mode %q, got: %q", test.mode, mode)
+			case !test.check(args):
+				t.Errorf("unexpected args: %+v", args)
+			}
+		})
+	}
+}
+
+func TestFlagDefault(t *testing.T) {
+	tests := []struct {
+		flag xo.Flag
+		exp  any
+	}{
+		{xo.Flag{Type: "bool"}, false},
+		{xo.Flag{Type: "bool", Default: true}, true},
+		{xo.Flag{Type: "int"}, 0},
+		{xo.Flag{Type: "int", Default: 2}, 2},
+		{xo.Flag{Type: "[]string"}, []string(nil)},
+		{xo.Flag{Type: "[]string", Default: "a,b"}, []string{"a", "b"}},
+		{xo.Flag{Type: "[]string", Default: []string{"a"}}, []string{"a"}},
+		{xo.Flag{Type: "string"}, ""},
+		{xo.Flag{Type: "string", Default: "a"}, "a"},
+		{xo.Flag{Type: "string", Enums: []string{"none", "all"}}, "none"},
+		{xo.Flag{Type: "string", Default: "all", Enums: []string{"none", "all"}}, "all"},
+	}
+	for i, test := range tests {
+		if v := flagDefault(xo.FlagSet{Type: "t", Name: "f", Flag: test.flag}); !reflect.DeepEqual(v, test.exp) {
+			t.Errorf("test %d expected %#v, got: %#v", i, test.exp, v)
+		}
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	tests := []struct {
+		flag xo.Flag
+		v    any
+		exp  any
+		err  bool
+	}{
+		{xo.Flag{Type: "bool"}, true, true, false},
+		{xo.Flag{Type: "bool"}, "true", nil, true},
+		{xo.Flag{Type: "int"}, 3, 3, false},
+		{xo.Flag{Type: "int"}, uint64(3), 3, false},
+		{xo.Flag{Type: "int"}, "3", 3, false},
+		{xo.Flag{Type: "int"}, "x", nil, true},
+		{xo.Flag{Type: "[]string"}, []any{"a", 1}, []string{"a", "1"}, false},
+		{xo.Flag{Type: "[]string"}, []string{"a"}, []string{"a"}, false},
+		{xo.Flag{Type: "[]string"}, "a", []string{"a"}, false},
+		{xo.Flag{Type: "[]string", Enums: []string{"a", "b"}}, []any{"a", "c"}, nil, true},
+		{xo.Flag{Type: "string"}, "a", "a", false},
+		{xo.Flag{Type: "string"}, 1, "1", false},
+		{xo.Flag{Type: "string", Enums: []string{"none", "all"}}, "all", "all", false},
+		{xo.Flag{Type: "string", Enums: []string{"none", "all"}}, "some", nil, true},
+	}
+	for i, test := range tests {
+		v, err := flagValue(xo.FlagSet{Type: "t", Name: "f", Flag: test.flag}, test.v)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case !reflect.DeepEqual(v, test.exp):
+			t.Errorf("test %d expected %#v, got: %#v", i, test.exp, v)
+		}
+	}
+}
+
+func TestManifestContext(t *testing.T) {
+	ts, err := newTemplateSet(context.Background(), "", "createdb")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	args := &Args{TemplateParams: TemplateParams{Type: "createdb"}}
+	tests := []struct {
+		name       string
+		flags      map[string]any
+		jobFlags   map[string]any
+		other      string
+		constraint bool
+		escape     string
+		err        bool
+	}{
+		{"defaults", nil, nil, "", false, "none", false},
+		{"manifest", map[string]any{"createdb-constraint": true, "createdb-escape": "all"}, nil, "", true, "all", false},
+		{"job", map[string]any{"createdb-escape": "all"}, map[string]any{"createdb-escape": "types"}, "", false, "types", false},
+		{"loader", map[string]any{"postgres-oids": true}, map[string]any{"postgres-oids": true}, "", false, "none", false},
+		{"other template", map[string]any{"go-pkg": "models"}, nil, "go", false, "none", false},
+		{"invalid value", nil, map[string]any{"createdb-escape": "some"}, "", false, "", true},
+		{"unknown template flag", nil, map[string]any{"createdb-escap": "all"}, "", false, "", true},
+		{"unknown loader flag", nil, map[string]any{"postgre-oids": true}, "", false, "", true},
+		{"unknown manifest flag", map[string]any{"pkg": "models"}, nil, "go", false, "", true},
+		{"other template without job", map[string]any{"go-pkg": "models"}, nil, "", false, "", true},
+		{"other template in job", nil, map[string]any{"go-pkg": "models"}, "go", false, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := Job{Template: "createdb", Flags: test.jobFlags}
+			m := &Manifest{Flags: test.flags, Jobs: []Job{job}}
+			if test.other != "" {
+				m.Jobs = append(m.Jobs, Job{Template: test.other})
+			}
+			ctx, err := manifestContext(context.Background(), "schema", ts, args, m, job)
+			switch {
+			case test.err && err == nil:
+				t.Fatalf("expected error")
+			case !test.err && err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case test.err:
+				return
+			}
+			if b := ddl.Constraint(ctx); b != test.constraint {
+				t.Errorf("expected constraint %t, got: %t", test.constraint, b)
+			}
+			if s, _ := ctx.Value(ddl.EscKey).(string); s != test.escape {
+				t.Errorf("expected escape %q, got: %q", test.escape, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/models"
+	"github.com/xo/dbtpl/sqllex"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// nullModes are the query result nullability modes.
+//
+//   - view uses the nullability reported by introspection
+//   - infer traces result columns to their source table columns, marking
+//     columns from the nullable side of outer joins as nullable
+var nullModes = []string{"view", "infer"}
+
+// inferNulls infers the nullability of a query's n result columns by tracing
+// each column to its source table column, using the view's dependencies (when
+// available) to resolve the source tables.
+//
+// Returns the nullability of the traced result columns, by position. Columns
+// that could not be traced (ie, expressions) are not included.
+func inferNulls(ctx context.Context, query []string, n int, deps []*models.ViewDependency) (map[int]bool, error) {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	sources, tables, ok := parseQuerySources(strings.Join(query, "\n"), driver)
+	if !ok {
+		return nil, nil
+	}
+	// load source table columns
+	columns := make([][]*models.Column, len(tables))
+	for i, table := range tables {
+		var err error
+		if columns[i], err = sourceColumns(ctx, table, deps); err != nil {
+			return nil, err
+		}
+	}
+	// trace result columns
+	nulls := make(map[int]bool)
+	var i int
+	for _, src := range sources {
+		switch {
+		case src == nil:
+			i++
+		case src.column == "*":
+			for j, table := range tables {
+				if src.qualifier != "" && !table.is(src.qualifier) {
+					continue
+				}
+				// unable to determine the columns of a derived table
+				if len(columns[j]) == 0 {
+					return nil, nil
+				}
+				for _, col := range columns[j] {
+					nulls[i] = isNullable(col) || table.nullable
+					i++
+				}
+			}
+		default:
+			if j, col := findSource(tables, columns, src); col != nil {
+				nulls[i] = isNullable(col) || tables[j].nullable
+			}
+			i++
+		}
+	}
+	if i != n {
+		return nil, nil
+	}
+	return nulls, nil
+}
+
+// isNullable returns true when a table column is nullable. Primary keys are
+// never nullable (ie, a SQLite3 INTEGER PRIMARY KEY).
+func isNullable(col *models.Column) bool {
+	return !col.NotNull && !col.IsPrimaryKey
+}
+
+// sourceColumns returns the columns for a table referenced by a query.
+// Returns nil for derived tables, and for tables that are not dependencies of
+// the view.
+func sourceColumns(ctx context.Context, table queryTable, deps []*models.ViewDependency) ([]*models.Column, error) {
+	if table.name == "" {
+		return nil, nil
+	}
+	schema, name := table.schema, table.name
+	if deps != nil {
+		i := slices.IndexFunc(deps, func(dep *models.ViewDependency) bool {
+			return strings.EqualFold(dep.TableName, name) && (schema == "" || strings.EqualFold(dep.SchemaName, schema))
+		})
+		if i == -1 {
+			return nil, nil
+		}
+		schema, name = deps[i].SchemaName, deps[i].TableName
+	}
+	if schema != "" {
+		ctx = context.WithValue(ctx, xo.SchemaKey, schema)
+	}
+	return loader.TableColumns(ctx, name)
+}
+
+// findSource finds the table and table column for a result column source.
+func findSource(tables []queryTable, columns [][]*models.Column, src *querySource) (int, *models.Column) {
+	find := func(j int) *models.Column {
+		for _, col := range columns[j] {
+			if strings.EqualFold(col.ColumnName, src.column) {
+				return col
+			}
+		}
+		return nil
+	}
+	// qualified
+	if src.qualifier != "" {
+		for j, table := range tables {
+			if table.is(src.qualifier) {
+				return j, find(j)
+			}
+		}
+		return -1, nil
+	}
+	// unqualified, must be found in exactly one table, and there must not be
+	// a derived table that could also have the column
+	k, res := -1, (*models.Column)(nil)
+	for j, table := range tables {
+		if table.name == "" {
+			return -1, nil
+		}
+		if col := find(j); col != nil {
+			if res != nil {
+				return -1, nil
+			}
+			k, res = j, col
+		}
+	}
+	return k, res
+}
+
+// querySource is the source of a query's select list item.
+type querySource struct {
+	// qualifier is the table name or alias, when qualified.
+	qualifier string
+	// column is the column name, or "*".
+	column string
+}
+
+// queryTable is a table referenced in a query's FROM clause.
+type queryTable struct {
+	schema string
+	// name is the table name, empty for derived tables (ie, subqueries,
+	// functions, and common table expressions).
+	name  string
+	alias string
+	// nullable is whether the table is on the nullable side of an outer join.
+	nullable bool
+}
+
+// is returns true when the table is referred to as qualifier.
+func (t queryTable) is(qualifier string) bool {
+	if t.alias != "" {
+		return strings.EqualFold(t.alias, qualifier)
+	}
+	return strings.EqualFold(t.name, qualifier)
+}
+
+// parseQuerySources parses the select list and FROM clause of a query,
+// returning the source of each select list item (nil when the item is not a
+// column reference), and the tables referenced by the query.
+//
+// Returns false when the query is not understood (ie, it is not a SELECT, or
+// it contains a UNION).
+func parseQuerySources(query, driver string) ([]*querySource, []queryTable, bool) {
+	toks, err := sqllex.Lex(driver, query)
+	if err != nil {
+		return nil, nil, false
+	}
+	depth := sqllex.Depth(toks)
+	at := func(i int, kws ...string) bool {
+		return i < len(toks) && depth[i] == 0 && toks[i].Type == sqllex.Word && slices.ContainsFunc(kws, func(kw string) bool {
+			return strings.EqualFold(toks[i].Text, kw)
+		})
+	}
+	// find select, collecting common table expression names
+	var ctes []string
+	i := 0
+	for ; i < len(toks) && !at(i, "SELECT"); i++ {
+		if depth[i] == 0 && toks[i].IsName() && !at(i, "WITH", "RECURSIVE", "AS", "NOT", "MATERIALIZED") {
+			ctes = append(ctes, toks[i].Text)
+		}
+	}
+	if i == len(toks) {
+		return nil, nil, false
+	}
+	for j := i + 1; j < len(toks); j++ {
+		if at(j, "UNION", "INTERSECT", "EXCEPT", "MINUS") {
+			return nil, nil, false
+		}
+	}
+	// skip select modifiers
+	switch i++; {
+	case at(i, "DISTINCT") && at(i+1, "ON"):
+		i = skipParens(toks, i+2)
+	case at(i, "DISTINCT", "ALL"):
+		i++
+	}
+	if at(i, "TOP") {
+		if i += 2; i-1 < len(toks) && toks[i-1].Text == "(" {
+			i = skipParens(toks, i-1)
+		}
+		if at(i, "PERCENT") {
+			i++
+		}
+		if at(i, "WITH") && at(i+1, "TIES") {
+			i += 2
+		}
+	}
+	// select list
+	var sources []*querySource
+	start := i
+	for ; i <= len(toks); i++ {
+		end := i == len(toks) || at(i, "FROM", "INTO", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "WINDOW") || toks[i].Text == ";"
+		if end || depth[i] == 0 && toks[i].Text == "," {
+			sources = append(sources, parseSource(toks[start:i]))
+			start = i + 1
+		}
+		if end {
+			break
+		}
+	}
+	if !at(i, "FROM") {
+		return sources, nil, true
+	}
+	// from clause
+	i++
+	end := i
+	for end < len(toks) && !at(end, "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "WINDOW", "QUALIFY") && toks[end].Text != ";" {
+		end++
+	}
+	tables := parseTables(toks[i:end], depth[i:end], ctes)
+	return sources, tables, true
+}
+
+// parseSource parses a select list item, returning the column it refers to,
+// or nil when the item is not a column reference.
+//
+// Handles items in the form of "[[schema.]table.]column[::type] [[AS] alias]"
+// and "[table.]*".
+func parseSource(toks []sqllex.Token) *querySource {
+	if len(toks) == 0 {
+		return nil
+	}
+	if toks[0].Text == "*" && len(toks) == 1 {
+		return &querySource{column: "*"}
+	}
+	// reference
+	var parts []string
+	i := 0
+	for ; i < len(toks); i += 2 {
+		switch {
+		case toks[i].IsName():
+			parts = append(parts, toks[i].Text)
+		case toks[i].Text == "*" && i != 0:
+			parts = append(parts, "*")
+		default:
+			return nil
+		}
+		if i+1 == len(toks) || toks[i+1].Text != "." || parts[len(parts)-1] == "*" {
+			i++
+			break
+		}
+	}
+	if len(parts) == 1 && toks[0].Type == sqllex.Word && slices.ContainsFunc(niladic, func(s string) bool {
+		return strings.EqualFold(s, parts[0])
+	}) {
+		return nil
+	}
+	// remaining must be a cast and/or an alias
+	rest := toks[i:]
+	switch {
+	case len(rest) == 0:
+	case rest[0].Text == "::":
+		for _, tok := range rest[1:] {
+			if tok.Type == sqllex.String || tok.Type == sqllex.Punct && !slices.Contains(typePunct, tok.Text) {
+				return nil
+			}
+		}
+	case len(rest) == 2 && strings.EqualFold(rest[0].Text, "AS") && rest[1].IsName(),
+		len(rest) == 1 && rest[0].IsName():
+	default:
+		return nil
+	}
+	src := &querySource{column: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		src.qualifier = parts[len(parts)-2]
+	}
+	return src
+}
+
+// typePunct is the punctuation that can be part of a type name (ie,
+// numeric(10, 2), text[], pg_catalog.text).
+var typePunct = []string{"(", ")", ",", ".", "[", "]"}
+
+// niladic are the keywords that look like, but are not, column references.
+var niladic = []string{
+	"NULL", "TRUE", "FALSE", "DEFAULT",
+	"CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER",
+	"LOCALTIME", "LOCALTIMESTAMP", "SESSION_USER", "SYSDATE", "USER",
+}
+
+// parseTables parses the tables referenced in a FROM clause, marking the
+// tables on the nullable side of outer joins.
+func parseTables(toks []sqllex.Token, depth []int, ctes []string) []queryTable {
+	at := func(i int, kws ...string) bool {
+		return i < len(toks) && depth[i] == 0 && toks[i].Type == sqllex.Word && slices.ContainsFunc(kws, func(kw string) bool {
+			return strings.EqualFold(toks[i].Text, kw)
+		})
+	}
+	var tables []queryTable
+	// joins bind more tightly than commas, so outer joins only affect the
+	// tables since the last comma
+	kind, i, first := "", 0, 0
+	for i < len(toks) {
+		// table reference
+		if at(i, "ONLY", "LATERAL") {
+			i++
+		}
+		var table queryTable
+		switch {
+		case i < len(toks) && toks[i].Text == "(":
+			i = skipParens(toks, i)
+		case i < len(toks) && toks[i].IsName():
+			var parts []string
+			for ; i < len(toks) && toks[i].IsName(); i += 2 {
+				parts = append(parts, toks[i].Text)
+				if i+1 == len(toks) || toks[i+1].Text != "." {
+					i++
+					break
+				}
+			}
+			switch {
+			case i < len(toks) && toks[i].Text == "(":
+				// function
+				if i = skipParens(toks, i); at(i, "WITH") && at(i+1, "ORDINALITY") {
+					i += 2
+				}
+			case len(parts) == 1 && slices.ContainsFunc(ctes, func(s string) bool { return strings.EqualFold(s, parts[0]) }):
+				table.alias = parts[0]
+			default:
+				table.name = parts[len(parts)-1]
+				if len(parts) > 1 {
+					table.schema = parts[len(parts)-2]
+				}
+			}
+		}
+		// alias
+		switch {
+		case at(i, "AS") && i+1 < len(toks) && toks[i+1].IsName():
+			table.alias, i = toks[i+1].Text, i+2
+		case i < len(toks) && toks[i].IsName() && !at(i, tableKeywords...):
+			table.alias, i = toks[i].Text, i+1
+		}
+		// join
+		switch kind {
+		case "left":
+			table.nullable = true
+		case "right", "full":
+			for j := first; j < len(tables); j++ {
+				tables[j].nullable = true
+			}
+			table.nullable = kind == "full"
+		}
+		tables = append(tables, table)
+		// find next table
+		kind = ""
+		for ; i < len(toks); i++ {
+			if depth[i] == 0 && toks[i].Text == "," {
+				i, first = i+1, len(tables)
+				break
+			}
+			if at(i, "JOIN", "APPLY", "STRAIGHT_JOIN") {
+				i++
+				break
+			}
+			join := at(i+1, "JOIN", "OUTER")
+			switch {
+			case at(i, "LEFT") && join, at(i, "OUTER") && at(i+1, "APPLY"):
+				kind = "left"
+			case at(i, "RIGHT") && join:
+				kind = "right"
+			case at(i, "FULL") && join:
+				kind = "full"
+			}
+		}
+	}
+	return tables
+}
+
+// tableKeywords are the keywords that can follow a table reference in a FROM
+// clause.
+var tableKeywords = []string{
+	"ON", "USING", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "OUTER", "CROSS",
+	"NATURAL", "APPLY", "STRAIGHT_JOIN", "TABLESAMPLE", "WITH", "USE", "FORCE",
+	"IGNORE", "PARTITION",
+}
+
+// skipParens returns the position after the parenthesis starting at i.
+func skipParens(toks []sqllex.Token, i int) int {
+	n := 0
+	for ; i < len(toks); i++ {
+		switch toks[i].Text {
+		case "(":
+			n++
+		case ")":
+			if n--; n == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuerySources(t *testing.T) {
+	tests := []struct {
+		query   string
+		sources []*querySource
+		tables  []queryTable
+		ok      bool
+	}{
+		{
+			`SELECT 1`,
+			[]*querySource{nil},
+			nil,
+			true,
+		},
+		{
+			`SELECT a.author_id, name AS author_name, b.title::varchar title, b.year + 1, NULL, COUNT(*) FROM authors a LEFT OUTER JOIN books AS b ON b.author_id = a.author_id WHERE a.name = NULL`,
+			[]*querySource{{"a", "author_id"}, {"", "name"}, {"b", "title"}, nil, nil, nil},
+			[]queryTable{{name: "authors", alias: "a"}, {name: "books", alias: "b", nullable: true}},
+			true,
+		},
+		{
+			`SELECT DISTINCT *, public.a.x FROM public.a RIGHT JOIN b USING (id), c FULL JOIN d ON d.id = c.id`,
+			[]*querySource{{"", "*"}, {"a", "x"}},
+			[]queryTable{{schema: "public", name: "a", nullable: true}, {name: "b"}, {name: "c", nullable: true}, {name: "d", nullable: true}},
+			true,
+		},
+		{
+			`WITH x AS (SELECT * FROM a) SELECT x.id, s.n, t.v FROM x JOIN (SELECT 1 AS n) s ON true CROSS JOIN LATERAL unnest(x.arr) WITH ORDINALITY AS t(v, o)`,
+			[]*querySource{{"x", "id"}, {"s", "n"}, {"t", "v"}},
+			[]queryTable{{alias: "x"}, {alias: "s"}, {alias: "t"}},
+			true,
+		},
+		{
+			"SELECT TOP (10) [a].[name], \"b\".\"left\" FROM [dbo].[a] OUTER APPLY (SELECT 1 AS [left]) b -- LEFT JOIN",
+			[]*querySource{{"a", "name"}, {"b", "left"}},
+			[]queryTable{{schema: "dbo", name: "a"}, {alias: "b", nullable: true}},
+			true,
+		},
+		{
+			`SELECT a FROM x UNION SELECT b FROM y`,
+			nil,
+			nil,
+			false,
+		},
+		{
+			`DELETE FROM x`,
+			nil,
+			nil,
+			false,
+		},
+	}
+	for i, test := range tests {
+		sources, tables, ok := parseQuerySources(test.query, "sqlserver")
+		if ok != test.ok {
+			t.Fatalf("test %d expected %t, got: %t", i, test.ok, ok)
+		}
+		if !reflect.DeepEqual(sources, test.sources) {
+			t.Errorf("test %d expected sources %v, got: %v", i, test.sources, sources)
+		}
+		if !reflect.DeepEqual(tables, test.tables) {
+			t.Errorf("test %d expected tables %+v, got: %+v", i, test.tables, tables)
+		}
+	}
+}
@@ -6,57 +6,137 @@ import (
 	"fmt"
 	"math/rand"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/models"
 	xo "github.com/xo/dbtpl/types"
 )
 
-// loadQuery loads a query.
+// loadQuery loads a query, or the queries in an annotated SQL file.
 func loadQuery(ctx context.Context, set *xo.Set, args *Args) error {
+	// load table types to reuse
+	tables, err := loadReuseTables(ctx, args.QueryParams.ReuseTypes)
+	if err != nil {
+		return err
+	}
+	if args.QueryParams.File == "" {
+		return addQuery(ctx, set, args.QueryParams, tables)
+	}
+	// load queries from file
+	queries, err := readQueryFiles(args.QueryParams.File, args.QueryParams)
+	if err != nil {
+		return err
+	}
+	// exec queries do not have a type to name their file after
+	if i := slices.IndexFunc(queries, func(params QueryParams) bool {
+		return params.Exec || params.ExecRows
+	}); i != -1 && args.OutParams.Single == "" {
+		return fmt.Errorf("query %s: :exec and :execrows queries require --single (-S)", queries[i].Func)
+	}
+	for _, params := range queries {
+		if err := addQuery(ctx, set, params, tables); err != nil {
+			return fmt.Errorf("query %s: %w", params.Func, err)
+		}
+	}
+	return nil
+}
+
+// addQuery introspects and adds a query to the set, reusing the types of the
+// tables matching the query's results.
+func addQuery(ctx context.Context, set *xo.Set, params QueryParams, tables []xo.Table) error {
 	driver, _, _ := xo.DriverDbSchema(ctx)
+	// check exec rows
+	switch {
+	case params.ExpectRows < 0:
+		return errors.New("expect rows cannot be negative")
+	case params.ExpectRows != 0 && !params.ExecRows:
+		return errors.New("expect rows requires exec rows")
+	case params.ExecRows:
+		params.Exec = true
+	}
+	// check results
+	if len(params.Results) != 0 && (params.One || params.Flat || params.Exec || params.Fields != "") {
+		return errors.New("results cannot be used with one, flat, exec, or fields")
+	}
+	// rewrite native params
+	sqlstr, err := nativeParams(params.Query, params.ParamStyle, params.Delimiter)
+	if err != nil {
+		return err
+	}
 	// introspect query if not exec mode
 	query, inspect, comments, fields, err := parseQuery(
 		ctx,
-		args.QueryParams.Query,
-		args.QueryParams.Delimiter,
-		args.QueryParams.Interpolate,
-		args.QueryParams.Trim,
-		args.QueryParams.Strip,
+		sqlstr,
+		params.Delimiter,
+		params.Interpolate,
+		params.Trim,
+		params.Strip,
 	)
 	if err != nil {
 		return err
 	}
+	// infer param types not provided
+	if err := inferParams(ctx, sqlstr, params.Delimiter, params.Interpolate, fields); err != nil {
+		return err
+	}
 	var typeFields []xo.Field
-	if !args.QueryParams.Exec {
+	var reuse []xo.Table
+	var results []xo.Result
+	switch {
+	case params.Exec:
+	case len(params.Results) != 0:
+		// load result sets
+		results, err = loadQueryResults(
+			ctx,
+			inspect,
+			params.Results,
+			params.AllowNulls,
+			params.Introspect,
+			params.NullMode,
+		)
+		if err != nil {
+			return err
+		}
+	default:
 		// build query type
 		typeFields, err = loadQueryFields(
 			ctx,
 			inspect,
-			args.QueryParams.Fields,
-			args.QueryParams.AllowNulls,
-			args.QueryParams.Flat,
+			params.Fields,
+			params.AllowNulls,
+			params.Flat,
+			params.Introspect,
+			params.NullMode,
 		)
 		if err != nil {
 			return err
 		}
+		if !params.Flat && params.Fields == "" {
+			reuse = matchTables(typeFields, tables)
+		}
 	}
 	set.Queries = append(set.Queries, xo.Query{
 		Driver:       driver,
-		Name:         args.QueryParams.Func,
-		Comment:      args.QueryParams.FuncComment,
-		Exec:         args.QueryParams.Exec,
-		Flat:         args.QueryParams.Flat,
-		One:          args.QueryParams.One,
-		Interpolate:  args.QueryParams.Interpolate,
-		Type:         args.QueryParams.Type,
-		TypeComment:  args.QueryParams.TypeComment,
+		Name:         params.Func,
+		Comment:      params.FuncComment,
+		Exec:         params.Exec,
+		ExecRows:     params.ExecRows,
+		ExpectRows:   params.ExpectRows,
+		Flat:         params.Flat,
+		One:          params.One,
+		Interpolate:  params.Interpolate,
+		Type:         params.Type,
+		TypeComment:  params.TypeComment,
 		Fields:       typeFields,
-		ManualFields: args.QueryParams.Fields != "",
+		ManualFields: params.Fields != "",
 		Params:       fields,
 		Query:        query,
 		Comments:     comments,
+		Tables:       reuse,
+		Results:      results,
 	})
 	return nil
 }
@@ -80,6 +160,29 @@ func parseQuery(ctx context.Context, sqlstr, delimiter string, interpolate, trim
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
+	// rebuild query with markers for the params and conditional blocks when
+	// the query is built at runtime
+	blockstr, blocks, err := queryBlocks(sqlstr, fields)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if blocks || slices.ContainsFunc(fields, func(f xo.Field) bool { return f.Expand }) {
+		var params []string
+		for _, f := range fields {
+			if !f.Interpolate {
+				params = append(params, f.Name)
+			}
+		}
+		if qstr, _, err = parseQueryFields(
+			blockstr,
+			delimiter,
+			interpolate,
+			true,
+			func(n int) string { return "` + param(" + params[n] + ") + `" },
+		); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
 	// build introspection query
 	istr, _, err := parseQueryFields(
 		sqlstr,
@@ -119,6 +222,174 @@ func parseQuery(ctx context.Context, sqlstr, delimiter string, interpolate, trim
 	return query, inspect, comments, fields, nil
 }
 
+// inferParams infers the types of params without a type, using the
+// database's param metadata for the query.
+//
+// Interpolated params are not sent to the database, and must have a type.
+func inferParams(ctx context.Context, sqlstr, delimiter string, interpolate bool, fields []xo.Field) error {
+	var untyped string
+	for _, field := range fields {
+		switch {
+		case field.Type.Type != "":
+		case field.Interpolate:
+			return fmt.Errorf("interpolated query parameter %q must have a type", field.Name)
+		case untyped == "":
+			untyped = field.Name
+		}
+	}
+	if untyped == "" {
+		return nil
+	}
+	// build query, numbering only the params sent to the database
+	nth, err := loader.NthParam(ctx)
+	if err != nil {
+		return err
+	}
+	var n []int
+	for i, field := range fields {
+		if !field.Interpolate {
+			n = append(n, i)
+		}
+	}
+	qstr, _, err := parseQueryFields(sqlstr, delimiter, interpolate, false, func(i int) string {
+		if fields[i].Interpolate {
+			return "NULL"
+		}
+		return nth(slices.Index(n, i))
+	})
+	if err != nil {
+		return err
+	}
+	// retrieve param types
+	params, err := loader.QueryParams(ctx, queryID(ctx), strings.Split(qstr, "\n"))
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		return fmt.Errorf("query parameter %q must have a type: %w", untyped, err)
+	case err != nil:
+		return err
+	case len(params) != len(n):
+		return fmt.Errorf("query has %d parameters, database returned %d types", len(n), len(params))
+	}
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	for j, i := range n {
+		if fields[i].Type.Type != "" {
+			continue
+		}
+		if fields[i].Type, err = xo.ParseType(params[j].ParamType, driver); err != nil {
+			return err
+		}
+		fields[i].Inferred = true
+	}
+	return nil
+}
+
+// paramStyles are the query param styles.
+//
+//   - delim is "<delim><name> [type]<delim>" (ie, %%name string%%)
+//   - colon is ":name"
+//   - at is "@name"
+//   - sqlc is "sqlc.arg(name)"
+//
+// Params in a native (non-delim) style may be followed by a type comment (ie,
+// ":name /* string */").
+var paramStyles = []string{"delim", "colon", "at", "sqlc"}
+
+// nativeParamREs are the regexps for native param styles. The first submatch
+// is the text preceding the param, the second the param name, and the third
+// the optional type.
+var nativeParamREs = map[string]*regexp.Regexp{
+	"colon": regexp.MustCompile(`(^|[^:\w]):([A-Za-z_]\w*)` + typeCommentRE),
+	"at":    regexp.MustCompile(`(^|[^@\w])@([A-Za-z_]\w*)` + typeCommentRE),
+	"sqlc":  regexp.MustCompile(`()sqlc\.arg\(\s*'?([A-Za-z_]\w*)'?\s*\)` + typeCommentRE),
+}
+
+// typeCommentRE matches an optional type comment following a native param.
+const typeCommentRE = `(?:[ \t]*/\*\s*([^*]*?)\s*\*/)?`
+
+// literalRE matches string literals, quoted identifiers, and line comments,
+// where native params are not recognized.
+var literalRE = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"|--[^\n]*`)
+
+// nativeParams rewrites the native params in a query to the
+// "<delim><name> [type]<delim>" form.
+func nativeParams(query, style, delim string) (string, error) {
+	if style == "" || style == "delim" {
+		return query, nil
+	}
+	re, ok := nativeParamREs[style]
+	if !ok {
+		return "", fmt.Errorf("invalid param style %q", style)
+	}
+	literals := literalRE.FindAllStringIndex(query, -1)
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(query, -1) {
+		// m[3] is the end of the preceding text, and the start of the param
+		if inLiteral(literals, m[3]) {
+			continue
+		}
+		param, end := query[m[4]:m[5]], m[1]
+		switch {
+		case m[6] == -1:
+		case blockRE.MatchString("/*" + query[m[6]:m[7]] + "*/"):
+			// leave conditional block markers following the param
+			end = len(strings.TrimRight(query[:strings.LastIndex(query[:m[6]], "/*")], " \t"))
+		default:
+			param += " " + query[m[6]:m[7]]
+		}
+		sb.WriteString(query[last:m[3]] + delim + param + delim)
+		last = end
+	}
+	return sb.String() + query[last:], nil
+}
+
+// inLiteral returns true when i is within one of the literals.
+func inLiteral(literals [][]int, i int) bool {
+	for _, l := range literals {
+		if l[0] <= i && i < l[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// blockRE matches the start and end of conditional query blocks, in the form
+// of "/*if:<name>*/" and "/*end*/".
+var blockRE = regexp.MustCompile(`/\*\s*(?:if:\s*([A-Za-z_]\w*)|end)\s*\*/`)
+
+// queryBlocks replaces the conditional blocks in a query with the markers
+// used when building the query at runtime. A block is included in the query
+// when its param is not the zero value.
+func queryBlocks(query string, fields []xo.Field) (string, bool, error) {
+	literals := literalRE.FindAllStringIndex(query, -1)
+	var sb strings.Builder
+	depth, last := 0, 0
+	for _, m := range blockRE.FindAllStringSubmatchIndex(query, -1) {
+		if inLiteral(literals, m[0]) {
+			continue
+		}
+		marker := "` + end() + `"
+		switch {
+		case m[2] != -1:
+			name := query[m[2]:m[3]]
+			if index(fields, name) == -1 {
+				return "", false, fmt.Errorf("conditional block on undefined query parameter %q", name)
+			}
+			marker, depth = "` + if("+name+") + `", depth+1
+		case depth == 0:
+			return "", false, errors.New("conditional block end without a matching start")
+		default:
+			depth--
+		}
+		sb.WriteString(query[last:m[0]] + marker)
+		last = m[1]
+	}
+	if depth != 0 {
+		return "", false, errors.New("conditional block missing end")
+	}
+	return sb.String() + query[last:], last != 0, nil
+}
+
 // parseQueryFields takes a SQL query and looks for strings in the form of
 // "<delim><name> <type>[,<option>,...]<delim>", replacing them with the nth
 // param value.
@@ -134,13 +405,17 @@ func parseQueryFields(query, delim string, interpolate, paramInterpolate bool, n
 	matches := placeholderRE.FindAllStringIndex(query, -1)
 	// return vals and placeholders
 	var fields []xo.Field
-	sqlstr, i, last := "", 0, 0
+	nums := make(map[string]int)
+	sqlstr, last := "", 0
 	// loop over matches, extracting each placeholder and splitting to name/type
 	for _, m := range matches {
 		// extract parameter info
 		paramStr := query[m[0]+len(delim) : m[1]-len(delim)]
-		p := strings.SplitN(paramStr, " ", 2)
-		name, typ := p[0], p[1]
+		// a param without a type has its type inferred
+		name, typ, _ := strings.Cut(paramStr, " ")
+		if i := strings.Index(name, ","); typ == "" && i != -1 {
+			name, typ = name[:i], name[i:]
+		}
 		field := xo.Field{
 			Name: name,
 			Type: xo.Type{
@@ -159,11 +434,20 @@ func parseQueryFields(query, delim string, interpolate, paramInterpolate bool, n
 					field.Interpolate = true
 				case "join": // enable string join of the variable
 					field.Join = true
+				case "expand": // enable runtime expansion of the slice variable
+					field.Expand = true
 				default:
 					return "", nil, fmt.Errorf("unknown option encountered on query parameter %q", paramStr)
 				}
 			}
 		}
+		// check expanded params
+		switch {
+		case field.Expand && field.Interpolate:
+			return "", nil, fmt.Errorf("query parameter %q cannot be both expanded and interpolated", paramStr)
+		case field.Expand && field.Type.Type != "" && !strings.HasPrefix(field.Type.Type, "[]"):
+			return "", nil, fmt.Errorf("expanded query parameter %q must be a slice", paramStr)
+		}
 		// add to string
 		sqlstr = sqlstr + query[last:m[0]]
 		// determine if parameter previously defined or not
@@ -178,11 +462,10 @@ func parseQueryFields(query, delim string, interpolate, paramInterpolate bool, n
 			}
 			sqlstr += "` + " + name + " + `"
 		} else {
-			n := i
-			if prevIndex != -1 {
-				n = prevIndex
-			} else {
-				i++
+			n, ok := nums[name]
+			if !ok {
+				n = len(nums)
+				nums[name] = n
 			}
 			sqlstr += nth(n)
 		}
@@ -197,72 +480,80 @@ func parseQueryFields(query, delim string, interpolate, paramInterpolate bool, n
 }
 
 // loadQueryFields loads the query type fields.
-func loadQueryFields(ctx context.Context, query []string, fields string, allowNulls, flat bool) ([]xo.Field, error) {
+func loadQueryFields(ctx context.Context, query []string, fields string, allowNulls, flat bool, strategy, nullMode string) ([]xo.Field, error) {
 	// introspect or use defined user fields
 	f := introspect
 	if fields != "" {
 		// wrap ...
-		f = func(context.Context, []string, bool, bool) ([]xo.Field, error) {
+		f = func(context.Context, []string, bool, bool, string, string) ([]xo.Field, error) {
 			return splitFields(fields)
 		}
 	}
-	return f(ctx, query, allowNulls, flat)
+	return f(ctx, query, allowNulls, flat, strategy, nullMode)
 }
 
-// introspect creates a view of a query, introspecting the query's columns and
-// returning as fields.
+// introspectModes are the query introspection strategies.
 //
-// Creates a temporary view/table, retrieves its column definitions and
-// dropping the temporary view/table.
-func introspect(ctx context.Context, query []string, allowNulls, flat bool) ([]xo.Field, error) {
-	// determine prefix
+//   - auto creates a view, describing the query when view creation fails
+//   - view creates a view
+//   - describe uses the database's result metadata for the query
+var introspectModes = []string{"auto", "view", "describe"}
+
+// introspect introspects the query's columns using the strategy, returning
+// as fields. The nullability of the fields is determined by the null mode.
+func introspect(ctx context.Context, query []string, allowNulls, flat bool, strategy, nullMode string) ([]xo.Field, error) {
 	driver, _, _ := xo.DriverDbSchema(ctx)
-	prefix := "_xo_"
-	if driver == "oracle" {
-		prefix = "XO$"
+	id := queryID(ctx)
+	// introspect the columns returned by an insert, update, or delete query
+	// as a select from its table
+	if q, ok := returningQuery(strings.Join(query, "\n"), driver); ok {
+		query = []string{q}
 	}
-	// create random id
-	id := func(r *rand.Rand) string {
-		buf := make([]byte, 8)
-		for i := range buf {
-			buf[i] = letters[r.Intn(len(letters))]
+	// retrieve column info
+	var cols []*models.Column
+	var deps []*models.ViewDependency
+	var err error
+	switch strategy {
+	case "view":
+		cols, deps, err = introspectView(ctx, id, query)
+	case "describe":
+		cols, err = loader.QueryColumns(ctx, id, query)
+	default:
+		// describe the query when the view cannot be created (ie, on a
+		// read-only replica)
+		cols, deps, err = introspectView(ctx, id, query)
+		var viewErr *viewCreateError
+		if errors.As(err, &viewErr) {
+			switch cols, err = loader.QueryColumns(ctx, id, query); {
+			case errors.Is(err, errors.ErrUnsupported):
+				return nil, viewErr.err
+			case err != nil:
+				return nil, errors.Join(viewErr.err, err)
+			}
 		}
-		return prefix + string(buf)
-	}(rand.New(rand.NewSource(time.Now().UTC().UnixNano())))
-	// create introspection view
-	if _, err := loader.ViewCreate(ctx, id, query); err != nil {
-		return nil, err
-	}
-	// determine schema the view was created in (if applicable)
-	schema, err := loader.ViewSchema(ctx, id)
-	switch {
-	case err != nil:
-		return nil, err
-	case schema != "":
-		ctx = context.WithValue(ctx, xo.SchemaKey, schema)
 	}
-	// retrieve column info
-	cols, err := loader.TableColumns(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	// truncate view
-	if _, err := loader.ViewTruncate(ctx, id); err != nil {
-		return nil, err
-	}
-	// drop view
-	if _, err := loader.ViewDrop(ctx, id); err != nil {
-		return nil, err
+	// infer nullability
+	var nulls map[int]bool
+	if nullMode == "infer" {
+		if nulls, err = inferNulls(ctx, query, len(cols), deps); err != nil {
+			return nil, err
+		}
 	}
 	// process columns
 	var fields []xo.Field
-	for _, col := range cols {
+	for i, col := range cols {
 		// get type
 		d, err := xo.ParseType(col.DataType, driver)
 		if err != nil {
 			return nil, err
 		}
-		if allowNulls {
+		switch nullable, ok := nulls[i]; {
+		case ok:
+			d.Nullable = nullable
+		case allowNulls:
 			d.Nullable = !col.NotNull
 		}
 		fields = append(fields, xo.Field{
@@ -273,6 +564,80 @@ func introspect(ctx context.Context, query []string, allowNulls, flat bool) ([]x
 	return fields, nil
 }
 
+// introspectView creates a view of a query, introspecting the query's
+// columns and the view's dependencies.
+//
+// Creates a temporary view/table, retrieves its column definitions and
+// dropping the temporary view/table. Returns a [viewCreateError] when the view
+// cannot be created.
+func introspectView(ctx context.Context, id string, query []string) (_ []*models.Column, _ []*models.ViewDependency, err error) {
+	// create introspection view
+	if _, err := loader.ViewCreate(ctx, id, query); err != nil {
+		return nil, nil, &viewCreateError{err: err}
+	}
+	// truncate and drop view, even when introspection fails
+	defer func() {
+		if _, truncErr := loader.ViewTruncate(ctx, id); truncErr != nil {
+			err = errors.Join(err, truncErr)
+		}
+		if _, dropErr := loader.ViewDrop(ctx, id); dropErr != nil {
+			err = errors.Join(err, dropErr)
+		}
+	}()
+	// determine schema the view was created in (if applicable)
+	schema, err := loader.ViewSchema(ctx, id)
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case schema != "":
+		ctx = context.WithValue(ctx, xo.SchemaKey, schema)
+	}
+	// retrieve column info
+	cols, err := loader.TableColumns(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	// retrieve dependencies
+	deps, err := loader.ViewDependencies(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cols, deps, nil
+}
+
+// viewCreateError is the error returned when the introspection view cannot be
+// created.
+type viewCreateError struct {
+	err error
+}
+
+// Error satisfies the error interface.
+func (err *viewCreateError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap satisfies the errors.Unwrap interface.
+func (err *viewCreateError) Unwrap() error {
+	return err.err
+}
+
+// queryID returns a random id for the objects used to introspect a query.
+func queryID(ctx context.Context) string {
+	// determine prefix
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	prefix := "_xo_"
+	if driver == "oracle" {
+		prefix = "XO$"
+	}
+	// create random id
+	r := rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
+	buf := make([]byte, 8)
+	for i := range buf {
+		buf[i] = letters[r.Intn(len(letters))]
+	}
+	return prefix + string(buf)
+}
+
 // letters are used for random IDs.
 const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
 
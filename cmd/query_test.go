@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/models"
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestIntrospect(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", name)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE authors (author_id INTEGER PRIMARY KEY, name VARCHAR(255) NOT NULL, born TIMESTAMP)`); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	query := []string{`SELECT author_id, name AS author_name, born, author_id + 1 AS next FROM authors WHERE name = NULL`}
+	introspectFields := func(urlstr, strategy string) ([]string, error) {
+		ctx, err := open(context.Background(), urlstr, "")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		fields, err := introspect(ctx, query, true, false, strategy, "view")
+		if err != nil {
+			return nil, err
+		}
+		var v []string
+		for _, f := range fields {
+			v = append(v, f.Name+" "+f.Type.Type)
+		}
+		return v, nil
+	}
+	exp, err := introspectFields("sq:"+name, "view")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(exp) != 4 {
+		t.Fatalf("expected 4 fields, got: %v", exp)
+	}
+	tests := []struct {
+		readOnly bool
+		strategy string
+		err      bool
+	}{
+		{false, "describe", false},
+		{false, "auto", false},
+		{true, "view", true},
+		{true, "describe", false},
+		{true, "auto", false},
+	}
+	for i, test := range tests {
+		urlstr := "sq:" + name
+		if test.readOnly {
+			urlstr += "?_query_only=1"
+		}
+		fields, err := introspectFields(urlstr, test.strategy)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case !test.err && !reflect.DeepEqual(fields, exp):
+			t.Errorf("test %d expected %v, got: %v", i, exp, fields)
+		}
+	}
+}
+
+func TestIntrospectAuto(t *testing.T) {
+	var createErr, columnsErr error
+	var views []string
+	loader.Register("introspecttest", loader.Loader{
+		ViewCreate: func(_ context.Context, _ models.DB, _, id string, _ []string) (sql.Result, error) {
+			if createErr != nil {
+				return nil, createErr
+			}
+			views = append(views, id)
+			return nil, nil
+		},
+		ViewDrop: func(_ context.Context, _ models.DB, _, id string) (sql.Result, error) {
+			views = slices.DeleteFunc(views, func(s string) bool { return s == id })
+			return nil, nil
+		},
+		TableColumns: func(context.Context, models.DB, string, string) ([]*models.Column, error) {
+			if columnsErr != nil {
+				return nil, columnsErr
+			}
+			return []*models.Column{{ColumnName: "view_col", DataType: "integer"}}, nil
+		},
+		QueryColumns: func(context.Context, models.DB, string, string, []string) ([]*models.Column, error) {
+			return []*models.Column{{ColumnName: "query_col", DataType: "integer"}}, nil
+		},
+	})
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "introspecttest")
+	tests := []struct {
+		createErr  error
+		columnsErr error
+		exp        string
+		err        error
+	}{
+		{nil, nil, "view_col", nil},
+		{errors.New("read-only"), nil, "query_col", nil},
+		{nil, errors.New("columns"), "", errors.New("columns")},
+	}
+	for i, test := range tests {
+		createErr, columnsErr = test.createErr, test.columnsErr
+		fields, err := introspect(ctx, []string{"SELECT 1"}, false, false, "auto", "view")
+		switch {
+		case test.err != nil && (err == nil || err.Error() != test.err.Error()):
+			t.Errorf("test %d expected error %v, got: %v", i, test.err, err)
+		case test.err == nil && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case test.err == nil && (len(fields) != 1 || fields[0].Name != test.exp):
+			t.Errorf("test %d expected field %s, got: %v", i, test.exp, fields)
+		}
+		if len(views) != 0 {
+			t.Errorf("test %d expected views to be dropped, got: %v", i, views)
+		}
+	}
+}
+
+func TestInferParams(t *testing.T) {
+	var query string
+	loader.Register("paramtest", loader.Loader{
+		Mask: "$%d",
+		QueryParams: func(_ context.Context, _ models.DB, _, _ string, q []string) ([]*models.ProcParam, error) {
+			query = strings.Join(q, "\n")
+			return []*models.ProcParam{
+				{ParamName: "$1", ParamType: "integer"},
+				{ParamName: "$2", ParamType: "character varying(255)"},
+			}, nil
+		},
+	})
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "paramtest")
+	sqlstr := "SELECT * FROM %%table string,interpolate%%\nWHERE id = %%id%% AND name = %%name string%% AND %%id%% > 0"
+	_, _, _, fields, err := parseQuery(ctx, sqlstr, "%%", true, false, false)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := inferParams(ctx, sqlstr, "%%", true, fields); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := "SELECT * FROM NULL\nWHERE id = $1 AND name = $2 AND $1 > 0"; query != exp {
+		t.Errorf("expected query %q, got: %q", exp, query)
+	}
+	exp := []xo.Field{
+		{Name: "table", Type: xo.Type{Type: "string"}, Interpolate: true},
+		{Name: "id", Type: xo.Type{Type: "integer"}, Inferred: true},
+		{Name: "name", Type: xo.Type{Type: "string"}},
+	}
+	if !reflect.DeepEqual(fields, exp) {
+		t.Errorf("expected %+v, got: %+v", exp, fields)
+	}
+	// untyped interpolated params
+	_, _, _, fields, err = parseQuery(ctx, "SELECT * FROM %%table,interpolate%%", "%%", true, false, false)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(fields) != 1 || fields[0].Name != "table" || !fields[0].Interpolate {
+		t.Fatalf("expected interpolated table param, got: %+v", fields)
+	}
+	if err := inferParams(ctx, "SELECT * FROM %%table,interpolate%%", "%%", true, fields); err == nil {
+		t.Errorf("expected error for untyped interpolated param")
+	}
+}
+
+func TestNativeParams(t *testing.T) {
+	tests := []struct {
+		style string
+		query string
+		exp   string
+	}{
+		{"delim", `SELECT * FROM a WHERE id = %%id int%%`, `SELECT * FROM a WHERE id = %%id int%%`},
+		{"colon", `SELECT * FROM a WHERE id = :id`, `SELECT * FROM a WHERE id = %%id%%`},
+		{"colon", `SELECT b::text FROM a WHERE id = :id /* int */ AND :id > 0`, `SELECT b::text FROM a WHERE id = %%id int%% AND %%id%% > 0`},
+		{"colon", `SELECT ':id', c[1:n] FROM a -- :id`, `SELECT ':id', c[1:n] FROM a -- :id`},
+		{"colon", `:name/*string*/`, `%%name string%%`},
+		{"colon", `/*if:name*/ AND name = :name /*end*/`, `/*if:name*/ AND name = %%name%% /*end*/`},
+		{"at", `SELECT @@ROWCOUNT, 'a@b' FROM a WHERE id = @id /* int */ AND name = @name`, `SELECT @@ROWCOUNT, 'a@b' FROM a WHERE id = %%id int%% AND name = %%name%%`},
+		{"sqlc", `SELECT * FROM a WHERE id = sqlc.arg(id) AND name = sqlc.arg('name') /* string */`, `SELECT * FROM a WHERE id = %%id%% AND name = %%name string%%`},
+		{"sqlc", `/*if:id*/ AND id = sqlc.arg(id) /*end*/`, `/*if:id*/ AND id = %%id%% /*end*/`},
+	}
+	for i, test := range tests {
+		query, err := nativeParams(test.query, test.style, "%%")
+		switch {
+		case err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case query != test.exp:
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, query)
+		}
+	}
+}
+
+func TestExpandParams(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "postgres")
+	sqlstr := "SELECT * FROM %%table string,interpolate%%\nWHERE id IN (%%ids []int,expand%%) AND name = %%name string%% AND %%ids []int,expand%% IS NOT NULL"
+	query, inspect, _, fields, err := parseQuery(ctx, sqlstr, "%%", true, false, false)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := "SELECT * FROM ` + table + `\nWHERE id IN (` + param(ids) + `) AND name = ` + param(name) + ` AND ` + param(ids) + ` IS NOT NULL"; strings.Join(query, "\n") != exp {
+		t.Errorf("expected query %q, got: %q", exp, strings.Join(query, "\n"))
+	}
+	if exp := "SELECT * FROM NULL\nWHERE id IN (NULL) AND name = NULL AND NULL IS NOT NULL"; strings.Join(inspect, "\n") != exp {
+		t.Errorf("expected inspect %q, got: %q", exp, strings.Join(inspect, "\n"))
+	}
+	if len(fields) != 3 || !fields[1].Expand || fields[2].Expand {
+		t.Errorf("expected ids to be expanded, got: %+v", fields)
+	}
+	for _, s := range []string{"%%ids int,expand%%", "%%ids []int,expand,interpolate%%"} {
+		if _, _, _, _, err := parseQuery(ctx, s, "%%", true, false, false); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestQueryBlocks(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "postgres")
+	sqlstr := "SELECT * FROM a WHERE true /*if:name*/ AND name = %%name *string%% /*end*/\n/* if: ids */AND id IN (%%ids []int,expand%%)/*end*/ AND '/*end*/' <> %%name *string%%"
+	query, inspect, _, fields, err := parseQuery(ctx, sqlstr, "%%", false, false, false)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := "SELECT * FROM a WHERE true ` + if(name) + ` AND name = ` + param(name) + ` ` + end() + `\n` + if(ids) + `AND id IN (` + param(ids) + `)` + end() + ` AND '/*end*/' <> ` + param(name) + `"; strings.Join(query, "\n") != exp {
+		t.Errorf("expected query %q, got: %q", exp, strings.Join(query, "\n"))
+	}
+	if exp := "SELECT * FROM a WHERE true /*if:name*/ AND name = NULL /*end*/\n/* if: ids */AND id IN (NULL)/*end*/ AND '/*end*/' <> NULL"; strings.Join(inspect, "\n") != exp {
+		t.Errorf("expected inspect %q, got: %q", exp, strings.Join(inspect, "\n"))
+	}
+	if len(fields) != 2 {
+		t.Errorf("expected 2 fields, got: %+v", fields)
+	}
+	for _, s := range []string{
+		"SELECT 1 /*if:name*/",
+		"SELECT 1 /*end*/",
+		"SELECT %%id int%% /*if:name*/ /*end*/",
+	} {
+		if _, _, _, _, err := parseQuery(ctx, s, "%%", false, false, false); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
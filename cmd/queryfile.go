@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readQueryFiles reads the annotated queries from the named SQL file, or from
+// all *.sql files when name is a directory. Files in a directory are read in
+// lexical order.
+//
+// The returned query params use params as their defaults.
+func readQueryFiles(name string, params QueryParams) ([]QueryParams, error) {
+	files := []string{name}
+	if isDir(name) {
+		var err error
+		if files, err = filepath.Glob(filepath.Join(name, "*.sql")); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+	var queries []QueryParams
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		v, err := parseQueryFile(string(buf), params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		queries = append(queries, v...)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s: no annotated queries found", name)
+	}
+	return queries, nil
+}
+
+// parseQueryFile parses the annotated queries in s. Each query is preceded by a
+// header comment in the form of:
+//
+//	-- name: <Func> [:one|:many|:flat|:exec|:execrows] [<option>[=<value>] ...]
+//
+// Any comment lines directly following the header are used as the func
+// comment. The query continues until the next header, and any trailing
+// semicolon is removed.
+//
+// Recognized options are type, type-comment, func, fields, result,
+// allow-nulls, expect-rows, trim, strip and interpolate. The result option can
+// be repeated, once for each result set. Values containing spaces can be
+// double quoted.
+func parseQueryFile(s string, params QueryParams) ([]QueryParams, error) {
+	var queries []QueryParams
+	var q *QueryParams
+	var lines, comments []string
+	// funcs are the header lines of the funcs
+	funcs := make(map[string]int)
+	// flush adds the current query
+	flush := func() error {
+		if q == nil {
+			return nil
+		}
+		q.Query = strings.TrimRight(strings.TrimSpace(strings.Join(lines, "\n")), ";")
+		if q.Query == "" {
+			return fmt.Errorf("query %s is empty", q.Func)
+		}
+		if len(comments) != 0 && q.FuncComment == "" {
+			q.FuncComment = strings.Join(comments, " ")
+		}
+		queries = append(queries, *q)
+		return nil
+	}
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for i := 1; scanner.Scan(); i++ {
+		line := scanner.Text()
+		m := queryHeaderRE.FindStringSubmatch(line)
+		if m == nil {
+			switch c, ok := strings.CutPrefix(strings.TrimSpace(line), "--"); {
+			case q == nil:
+			case ok && len(lines) == 0:
+				comments = append(comments, strings.TrimSpace(c))
+			case len(lines) != 0 || strings.TrimSpace(line) != "":
+				lines = append(lines, line)
+			}
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		// new query
+		z := params
+		z.Query, z.Func, z.FuncComment = "", m[1], ""
+		if err := parseQueryHeader(&z, m[2]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i, err)
+		}
+		if n, ok := funcs[z.Func]; ok {
+			return nil, fmt.Errorf("line %d: func %s already defined on line %d", i, z.Func, n)
+		}
+		funcs[z.Func] = i
+		q, lines, comments = &z, nil, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// parseQueryHeader parses the options in a query header, setting them on q.
+func parseQueryHeader(q *QueryParams, s string) error {
+	opts, err := splitOptions(s)
+	if err != nil {
+		return err
+	}
+	var results []string
+	for _, opt := range opts {
+		name, value, hasValue := strings.Cut(opt, "=")
+		b := !hasValue || value == "true"
+		switch name {
+		case ":one":
+			q.One, q.Flat, q.Exec, q.ExecRows = true, false, false, false
+		case ":many":
+			q.One, q.Flat, q.Exec, q.ExecRows = false, false, false, false
+		case ":flat":
+			q.One, q.Flat, q.Exec, q.ExecRows = false, true, false, false
+		case ":exec":
+			q.One, q.Flat, q.Exec, q.ExecRows = false, false, true, false
+		case ":execrows":
+			q.One, q.Flat, q.Exec, q.ExecRows = false, false, true, true
+		case "type":
+			q.Type = value
+		case "type-comment":
+			q.TypeComment = value
+		case "func":
+			q.Func = value
+		case "fields":
+			q.Fields = value
+		case "result":
+			results = append(results, value)
+		case "allow-nulls":
+			q.AllowNulls = b
+		case "expect-rows":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid expect rows %q", value)
+			}
+			q.ExpectRows = n
+		case "trim":
+			q.Trim = b
+		case "strip":
+			q.Strip = b
+		case "interpolate":
+			q.Interpolate = b
+		case "introspect":
+			if !slices.Contains(introspectModes, value) {
+				return fmt.Errorf("invalid introspect strategy %q", value)
+			}
+			q.Introspect = value
+		case "param-style":
+			if !slices.Contains(paramStyles, value) {
+				return fmt.Errorf("invalid param style %q", value)
+			}
+			q.ParamStyle = value
+		case "null-mode":
+			if !slices.Contains(nullModes, value) {
+				return fmt.Errorf("invalid null mode %q", value)
+			}
+			q.NullMode = value
+		default:
+			return fmt.Errorf("unknown query option %q", name)
+		}
+	}
+	if results != nil {
+		q.Results = results
+	}
+	// default type name
+	switch {
+	case q.Type != "" || q.Exec || q.ExecRows:
+	case len(q.Results) != 0:
+		q.Type = q.Func + "Result"
+	default:
+		q.Type = q.Func + "Row"
+	}
+	return nil
+}
+
+// splitOptions splits s on whitespace, keeping double quoted values together.
+func splitOptions(s string) ([]string, error) {
+	var opts []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		i := strings.IndexAny(s, " \t\"")
+		switch {
+		case i == -1:
+			return append(opts, s), nil
+		case s[i] != '"':
+			opts, s = append(opts, s[:i]), s[i:]
+			continue
+		}
+		// quoted value
+		v, err := strconv.QuotedPrefix(s[i:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted value in %q", s)
+		}
+		u, _ := strconv.Unquote(v)
+		opts, s = append(opts, s[:i]+u), s[i+len(v):]
+	}
+	return opts, nil
+}
+
+// queryHeaderRE matches a query header comment.
+var queryHeaderRE = regexp.MustCompile(`^\s*--\s*name:\s*(\S+)(.*)$`)
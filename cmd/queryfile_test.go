@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestParseQueryFile(t *testing.T) {
+	const s = `-- Queries for authors.
+
+-- name: AuthorsByName :many type=Author
+-- AuthorsByName retrieves authors by name.
+SELECT author_id, name
+FROM authors
+WHERE name = %%name string%%;
+
+-- name: AuthorByID :one type=Author allow-nulls
+SELECT author_id, name FROM authors WHERE author_id = %%authorID int%%
+
+-- name: CountBooks :flat fields="count int"
+SELECT COUNT(*) FROM books;
+
+-- name: DeleteAuthor :exec
+DELETE FROM authors WHERE author_id = %%authorID int%%;
+
+-- name: AuthorBooks result=Author result="Books=book_id int,title string"
+SELECT author_id, name FROM authors WHERE author_id = %%authorID int%%;
+SELECT book_id, title FROM books WHERE author_id = %%authorID int%%;
+`
+	queries, err := parseQueryFile(s, QueryParams{Delimiter: "%%", Trim: true})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := []QueryParams{
+		{
+			Query:       "SELECT author_id, name\nFROM authors\nWHERE name = %%name string%%",
+			Type:        "Author",
+			Func:        "AuthorsByName",
+			FuncComment: "AuthorsByName retrieves authors by name.",
+			Trim:        true,
+			Delimiter:   "%%",
+		},
+		{
+			Query:      "SELECT author_id, name FROM authors WHERE author_id = %%authorID int%%",
+			Type:       "Author",
+			Func:       "AuthorByID",
+			Trim:       true,
+			One:        true,
+			Delimiter:  "%%",
+			AllowNulls: true,
+		},
+		{
+			Query:     "SELECT COUNT(*) FROM books",
+			Type:      "CountBooksRow",
+			Func:      "CountBooks",
+			Trim:      true,
+			Flat:      true,
+			Delimiter: "%%",
+			Fields:    "count int",
+		},
+		{
+			Query:     "DELETE FROM authors WHERE author_id = %%authorID int%%",
+			Func:      "DeleteAuthor",
+			Trim:      true,
+			Exec:      true,
+			Delimiter: "%%",
+		},
+		{
+			Query:     "SELECT author_id, name FROM authors WHERE author_id = %%authorID int%%;\nSELECT book_id, title FROM books WHERE author_id = %%authorID int%%",
+			Type:      "AuthorBooksResult",
+			Func:      "AuthorBooks",
+			Trim:      true,
+			Delimiter: "%%",
+			Results:   []string{"Author", "Books=book_id int,title string"},
+		},
+	}
+	if !reflect.DeepEqual(queries, exp) {
+		t.Errorf("expected:\n%#v\ngot:\n%#v", exp, queries)
+	}
+}
+
+func TestParseQueryFileErrors(t *testing.T) {
+	tests := []string{
+		"-- name: A :many unknown=1\nSELECT 1",
+		"-- name: A :many\n",
+		"-- name: A fields=\"a int\nSELECT 1",
+	}
+	for i, s := range tests {
+		if _, err := parseQueryFile(s, QueryParams{}); err == nil {
+			t.Errorf("test %d expected error, got nil", i)
+		}
+	}
+}
+
+func TestParseQueryFileDuplicateFunc(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{"-- name: A :one\nSELECT 1;\n\n-- name: B\nSELECT 2;\n-- name: A :many\nSELECT 3", "line 6: func A already defined on line 1"},
+		{"-- name: A\nSELECT 1;\n-- name: B func=A\nSELECT 2", "line 3: func A already defined on line 1"},
+	}
+	for i, test := range tests {
+		_, err := parseQueryFile(test.s, QueryParams{})
+		if err == nil || err.Error() != test.exp {
+			t.Errorf("test %d expected error %q, got: %v", i, test.exp, err)
+		}
+	}
+}
+
+func TestLoadQueryFileExec(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "queries.sql")
+	if err := os.WriteFile(name, []byte("-- name: AuthorByID :one\nSELECT 1;\n-- name: DeleteAuthor :exec\nDELETE FROM authors"), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	args := &Args{QueryParams: QueryParams{File: name}}
+	const exp = "query DeleteAuthor: :exec and :execrows queries require --single (-S)"
+	if err := loadQuery(context.Background(), new(xo.Set), args); err == nil || err.Error() != exp {
+		t.Errorf("expected error %q, got: %v", exp, err)
+	}
+}
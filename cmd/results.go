@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xo/dbtpl/sqllex"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// loadQueryResults loads the result sets of a query returning multiple result
+// sets. Each result is declared as <name>[=<fields>], and the fields of a
+// result without declared fields are introspected from the corresponding
+// statement of the query returning rows.
+func loadQueryResults(ctx context.Context, query, results []string, allowNulls bool, strategy, nullMode string) ([]xo.Result, error) {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	var statements []string
+	var res []xo.Result
+	for i, s := range results {
+		name, fields, ok := strings.Cut(s, "=")
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("result %d: name cannot be empty", i+1)
+		case ok && strings.TrimSpace(fields) == "":
+			return nil, fmt.Errorf("result %s: fields cannot be empty", name)
+		case slices.ContainsFunc(res, func(r xo.Result) bool { return r.Name == name }):
+			return nil, fmt.Errorf("result %s: defined more than once", name)
+		}
+		r := xo.Result{
+			Name:         name,
+			ManualFields: ok,
+		}
+		var err error
+		switch {
+		case ok:
+			r.Fields, err = splitFields(fields)
+		default:
+			// split query when first needed
+			if statements == nil {
+				if statements, err = resultStatements(strings.Join(query, "\n"), driver); err != nil {
+					return nil, err
+				}
+				if len(statements) != len(results) {
+					return nil, fmt.Errorf("query has %d statements returning rows, expected %d", len(statements), len(results))
+				}
+			}
+			r.Fields, err = introspect(ctx, []string{statements[i]}, allowNulls, false, strategy, nullMode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("result %s: %w", name, err)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// resultStatements splits the statements of query, returning the statements
+// returning rows.
+func resultStatements(query, driver string) ([]string, error) {
+	toks, err := sqllex.Lex(driver, query)
+	if err != nil {
+		return nil, err
+	}
+	depth := sqllex.Depth(toks)
+	var statements []string
+	for i := 0; i < len(toks); i++ {
+		start := i
+		for ; i < len(toks) && (depth[i] != 0 || toks[i].Type != sqllex.Punct || toks[i].Text != ";"); i++ {
+		}
+		if start == i {
+			continue
+		}
+		stmt := query[toks[start].Pos:toks[i-1].End]
+		if _, ok := returningQuery(stmt, driver); ok || returnsRows(toks[start:i], depth[start:i]) {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements, nil
+}
+
+// returnsRows returns true when the first top-level SELECT, VALUES, INSERT,
+// UPDATE, DELETE or MERGE of the statement is a SELECT or VALUES, skipping
+// any common table expressions.
+func returnsRows(toks []sqllex.Token, depth []int) bool {
+	for i, tok := range toks {
+		if depth[i] != 0 || tok.Type != sqllex.Word {
+			continue
+		}
+		switch strings.ToUpper(tok.Text) {
+		case "SELECT", "VALUES":
+			return true
+		case "INSERT", "UPDATE", "DELETE", "MERGE":
+			return false
+		}
+	}
+	return false
+}
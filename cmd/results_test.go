@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResultStatements(t *testing.T) {
+	tests := []struct {
+		query  string
+		driver string
+		exp    []string
+	}{
+		{`SELECT 1`, "postgres", []string{`SELECT 1`}},
+		{`SELECT a FROM t; SELECT b FROM u;`, "postgres", []string{`SELECT a FROM t`, `SELECT b FROM u`}},
+		{"SELECT ';' FROM t; -- ;\nVALUES (1)", "postgres", []string{`SELECT ';' FROM t`, `VALUES (1)`}},
+		{`WITH x AS (SELECT 1) SELECT * FROM x; WITH y AS (SELECT 1) DELETE FROM t`, "postgres", []string{`WITH x AS (SELECT 1) SELECT * FROM x`}},
+		{`SET NOCOUNT ON; INSERT INTO t (a) OUTPUT inserted.id VALUES (1); SELECT [;] FROM t`, "sqlserver", []string{`INSERT INTO t (a) OUTPUT inserted.id VALUES (1)`, `SELECT [;] FROM t`}},
+		{`INSERT INTO t (a) SELECT a FROM u; UPDATE t SET a = (SELECT 1)`, "mysql", nil},
+		{`CALL get_library(1)`, "mysql", nil},
+		{"SELECT a FROM t # ;\nWHERE b = \"c;\"; SELECT 1", "mysql", []string{"SELECT a FROM t # ;\nWHERE b = \"c;\"", `SELECT 1`}},
+	}
+	for i, test := range tests {
+		s, err := resultStatements(test.query, test.driver)
+		switch {
+		case err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case !reflect.DeepEqual(s, test.exp):
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+	if _, err := resultStatements(`SELECT 'a`, "postgres"); err == nil {
+		t.Errorf("expected error for unterminated string")
+	}
+}
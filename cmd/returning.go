@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xo/dbtpl/sqllex"
+)
+
+// returningQuery rewrites an insert, update, or delete query having a
+// RETURNING clause (or an OUTPUT clause for SQL Server) to a query selecting
+// the returned columns from the target table, allowing the returned columns
+// to be introspected without modifying the table.
+func returningQuery(query, driver string) (string, bool) {
+	sqlserver := driver == "sqlserver"
+	toks, err := sqllex.Lex(driver, query)
+	if err != nil {
+		return "", false
+	}
+	depth := sqllex.Depth(toks)
+	at := func(i int, kws ...string) bool {
+		return i < len(toks) && depth[i] == 0 && toks[i].Type == sqllex.Word && slices.ContainsFunc(kws, func(kw string) bool {
+			return strings.EqualFold(toks[i].Text, kw)
+		})
+	}
+	end := func(i int) bool {
+		return i == len(toks) || depth[i] == 0 && toks[i].Type == sqllex.Punct && toks[i].Text == ";"
+	}
+	// find statement, skipping any common table expressions
+	i := 0
+	for ; !end(i) && !at(i, "INSERT", "UPDATE", "DELETE", "SELECT"); i++ {
+	}
+	if end(i) || at(i, "SELECT") {
+		return "", false
+	}
+	stmt := strings.ToUpper(toks[i].Text)
+	if i++; at(i, "TOP") {
+		i = skipParens(toks, i+1)
+	}
+	if stmt == "INSERT" && at(i, "INTO") || stmt == "DELETE" && at(i, "FROM") {
+		i++
+	}
+	if at(i, "ONLY") {
+		i++
+	}
+	// target table
+	first := i
+	for i < len(toks) && toks[i].IsName() {
+		if i++; i == len(toks) || toks[i].Text != "." {
+			break
+		}
+		i++
+	}
+	if i == first || toks[i-1].Text == "." {
+		return "", false
+	}
+	table := query[toks[first].Pos:toks[i-1].End]
+	// alias
+	var alias string
+	switch {
+	case at(i, "AS") && i+1 < len(toks) && toks[i+1].IsName():
+		alias = query[toks[i+1].Pos:toks[i+1].End]
+	case i < len(toks) && toks[i].IsName() && !at(i, clauseKeywords...):
+		alias = query[toks[i].Pos:toks[i].End]
+	}
+	// returned columns
+	kw, stop := "RETURNING", []string{"INTO"}
+	if sqlserver {
+		kw, stop = "OUTPUT", append(slices.Clone(clauseKeywords), "INTO")
+	}
+	for ; !end(i) && !at(i, kw); i++ {
+	}
+	if end(i) {
+		return "", false
+	}
+	j := i + 1
+	for ; !end(j) && !at(j, stop...); j++ {
+	}
+	// returning into variables (Oracle) or a table (SQL Server) does not
+	// return a result
+	if j == i+1 || at(j, "INTO") {
+		return "", false
+	}
+	columns := query[toks[i+1].Pos:toks[j-1].End]
+	// build select, with the inserted and deleted tables used by SQL Server
+	from := table
+	switch {
+	case sqlserver:
+		from = table + " AS inserted, " + table + " AS deleted"
+	case alias != "":
+		from += " AS " + alias
+	}
+	return "SELECT " + columns + " FROM " + from, true
+}
+
+// clauseKeywords are the keywords starting the clauses that follow the target
+// table of an insert, update, or delete query.
+var clauseKeywords = []string{
+	"DEFAULT",
+	"FROM",
+	"ON",
+	"OPTION",
+	"OUTPUT",
+	"OVERRIDING",
+	"RETURNING",
+	"SELECT",
+	"SET",
+	"USING",
+	"VALUES",
+	"WHERE",
+	"WITH",
+}
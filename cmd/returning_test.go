@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestReturningQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		driver string
+		exp    string
+		ok     bool
+	}{
+		{`INSERT INTO books (title) VALUES ($1) RETURNING book_id, title`, "postgres", `SELECT book_id, title FROM books`, true},
+		{`INSERT INTO public.books AS b (title) VALUES ($1) RETURNING b.book_id`, "postgres", `SELECT b.book_id FROM public.books AS b`, true},
+		{`UPDATE books b SET title = $1 WHERE book_id = $2 RETURNING b.*`, "postgres", `SELECT b.* FROM books AS b`, true},
+		{`DELETE FROM books WHERE year < $1 RETURNING book_id;`, "sqlite3", `SELECT book_id FROM books`, true},
+		{`WITH x AS (SELECT 1) DELETE FROM books RETURNING (book_id + 1) AS id`, "postgres", `SELECT (book_id + 1) AS id FROM books`, true},
+		{`INSERT INTO books (title) OUTPUT inserted.book_id VALUES (@p1)`, "sqlserver", `SELECT inserted.book_id FROM books AS inserted, books AS deleted`, true},
+		{`UPDATE TOP (1) books SET title = @p1 OUTPUT deleted.title, inserted.title WHERE book_id = @p2`, "sqlserver", `SELECT deleted.title, inserted.title FROM books AS inserted, books AS deleted`, true},
+		{`INSERT INTO books (title) OUTPUT inserted.book_id INTO ids VALUES (@p1)`, "sqlserver", ``, false},
+		{`INSERT INTO books (title) VALUES (:1) RETURNING book_id INTO :2`, "oracle", ``, false},
+		{`INSERT INTO books (title) VALUES ($1)`, "postgres", ``, false},
+		{`SELECT 'RETURNING' FROM books`, "postgres", ``, false},
+	}
+	for i, test := range tests {
+		s, ok := returningQuery(test.query, test.driver)
+		if ok != test.ok || s != test.exp {
+			t.Errorf("test %d expected %q (%t), got: %q (%t)", i, test.exp, test.ok, s, ok)
+		}
+	}
+}
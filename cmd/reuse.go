@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+// loadReuseTables loads the tables and views of the active schema from the
+// schema snapshot, whose types are reused for query results.
+func loadReuseTables(ctx context.Context, name string) ([]xo.Table, error) {
+	if name == "" {
+		return nil, nil
+	}
+	set, err := xo.ReadSnapshot(strings.TrimPrefix(name, snapshotPrefix))
+	if err != nil {
+		return nil, err
+	}
+	_, _, schema := xo.DriverDbSchema(ctx)
+	i := slices.IndexFunc(set.Schemas, func(s xo.Schema) bool {
+		return s.Name == schema
+	})
+	switch {
+	case i == -1 && len(set.Schemas) == 1:
+		i = 0
+	case i == -1:
+		return nil, fmt.Errorf("schema %q not found in snapshot", schema)
+	}
+	return append(slices.Clone(set.Schemas[i].Tables), set.Schemas[i].Views...), nil
+}
+
+// matchTables returns the tables whose columns are exactly the fields, in
+// order, or nil when the fields are not the columns of one or more distinct
+// tables.
+func matchTables(fields []xo.Field, tables []xo.Table) []xo.Table {
+	if len(fields) == 0 || len(tables) == 0 {
+		return nil
+	}
+	// match tables with the most columns first
+	tables = slices.Clone(tables)
+	slices.SortStableFunc(tables, func(a, b xo.Table) int {
+		return cmp.Compare(len(b.Columns), len(a.Columns))
+	})
+	var matched []xo.Table
+	for i := 0; i < len(fields); {
+		j := slices.IndexFunc(tables, func(t xo.Table) bool {
+			return matchColumns(fields[i:], t.Columns)
+		})
+		if j == -1 || slices.ContainsFunc(matched, func(t xo.Table) bool {
+			return t.Name == tables[j].Name
+		}) {
+			return nil
+		}
+		matched = append(matched, tables[j])
+		i += len(tables[j].Columns)
+	}
+	return matched
+}
+
+// matchColumns returns true when the fields start with the columns, having
+// the same names and types. Fields that are not nullable can be scanned to
+// nullable columns.
+func matchColumns(fields, columns []xo.Field) bool {
+	if len(columns) == 0 || len(fields) < len(columns) {
+		return false
+	}
+	for i, col := range columns {
+		a, b := fields[i].Type, col.Type
+		if fields[i].Name != col.Name || a.Type != b.Type || a.Nullable && !b.Nullable || a.IsArray != b.IsArray || a.Unsigned != b.Unsigned {
+			return false
+		}
+	}
+	return true
+}
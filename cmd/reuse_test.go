@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestMatchTables(t *testing.T) {
+	field := func(name, typ string, nullable bool) xo.Field {
+		return xo.Field{Name: name, Type: xo.Type{Type: typ, Nullable: nullable}}
+	}
+	authors := xo.Table{Name: "authors", Columns: []xo.Field{
+		field("author_id", "integer", false),
+		field("name", "text", false),
+	}}
+	books := xo.Table{Name: "books", Columns: []xo.Field{
+		field("book_id", "integer", false),
+		field("title", "text", false),
+		field("year", "integer", true),
+	}}
+	names := xo.Table{Name: "names", Columns: []xo.Field{
+		field("author_id", "integer", false),
+	}}
+	tables := []xo.Table{names, authors, books}
+	tests := []struct {
+		fields []xo.Field
+		exp    []string
+	}{
+		{authors.Columns, []string{"authors"}},
+		{names.Columns, []string{"names"}},
+		{append(append([]xo.Field{}, authors.Columns...), books.Columns...), []string{"authors", "books"}},
+		{[]xo.Field{field("book_id", "integer", false), field("title", "text", false), field("year", "integer", false)}, []string{"books"}},
+		{[]xo.Field{field("book_id", "integer", true), field("title", "text", false), field("year", "integer", true)}, nil},
+		{[]xo.Field{field("author_id", "integer", false), field("name", "varchar", false)}, nil},
+		{authors.Columns[:1:1], []string{"names"}},
+		{append(append([]xo.Field{}, authors.Columns...), authors.Columns...), nil},
+		{append(append([]xo.Field{}, books.Columns...), field("extra", "text", false)), nil},
+	}
+	for i, test := range tests {
+		var names []string
+		for _, table := range matchTables(test.fields, tables) {
+			names = append(names, table.Name)
+		}
+		if !reflect.DeepEqual(names, test.exp) {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, names)
+		}
+	}
+}
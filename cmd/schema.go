@@ -1,54 +1,143 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/kenshaw/glob"
 	"github.com/kenshaw/inflector"
 	"github.com/xo/dbtpl/loader"
 	"github.com/xo/dbtpl/models"
+	"github.com/xo/dbtpl/sqllex"
 	xo "github.com/xo/dbtpl/types"
+	"golang.org/x/sync/errgroup"
 )
 
-// loadSchema loads a schema from a database.
+// loadSchema loads the schemas from a database.
 func loadSchema(ctx context.Context, set *xo.Set, args *Args) error {
+	ctx = loader.WithLoad(ctx)
+	// determine schemas
+	_, _, active := xo.DriverDbSchema(ctx)
+	names := []string{active}
+	if len(args.LoaderParams.Schemas) != 0 {
+		var available []string
+		if slices.ContainsFunc(args.LoaderParams.Schemas, isGlob) {
+			schemas, err := loader.Schemas(ctx)
+			if err != nil {
+				return err
+			}
+			for _, schema := range schemas {
+				available = append(available, schema.SchemaName)
+			}
+		}
+		var err error
+		if names, err = matchSchemas(active, args.LoaderParams.Schemas, available); err != nil {
+			return err
+		}
+	}
+	// load enums, procs, tables, views
+	var schemas []xo.Schema
+	for _, name := range names {
+		schema, err := loadSchemaObjects(context.WithValue(ctx, xo.SchemaKey, name), args)
+		if err != nil {
+			return err
+		}
+		schemas = append(schemas, schema)
+	}
+	// load foreign keys
+	if err := loadForeignKeys(ctx, args, schemas); err != nil {
+		return err
+	}
+	for i := range schemas {
+		fixEnums(&schemas[i])
+	}
+	// emit
+	set.Schemas = append(set.Schemas, schemas...)
+	return nil
+}
+
+// matchSchemas returns the schema names matching the names or globs in
+// patterns, with the active schema first when matched.
+func matchSchemas(active string, patterns, available []string) ([]string, error) {
+	var names []string
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, pattern := range patterns {
+		if !isGlob(pattern) {
+			add(pattern)
+			continue
+		}
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema glob %q: %w", pattern, err)
+		}
+		var found bool
+		for _, name := range available {
+			if g.Match(name) {
+				found = true
+				add(name)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no schemas match %q", pattern)
+		}
+	}
+	if i := slices.Index(names, active); i > 0 {
+		names = slices.Insert(slices.Delete(names, i, i+1), 0, active)
+	}
+	return names, nil
+}
+
+// loadSchemaObjects loads the enums, procs, tables, and views for the schema
+// in the context.
+func loadSchemaObjects(ctx context.Context, args *Args) (xo.Schema, error) {
 	driver, _, schemaName := xo.DriverDbSchema(ctx)
 	schema := xo.Schema{
 		Driver: driver,
 		Name:   schemaName,
 	}
 	var err error
-	// load enums, procs, tables, views
 	if schema.Enums, err = loadEnums(ctx, args); err != nil {
-		return err
+		return xo.Schema{}, err
 	}
 	if schema.Procs, err = loadProcs(ctx, args); err != nil {
-		return err
+		return xo.Schema{}, err
+	}
+	// bulk load catalog for tables and views
+	if ctx, err = withCatalog(ctx); err != nil {
+		return xo.Schema{}, err
 	}
 	if schema.Tables, err = loadTables(ctx, args, "table"); err != nil {
-		return err
+		return xo.Schema{}, err
 	}
 	if schema.Views, err = loadTables(ctx, args, "view"); err != nil {
-		return err
+		return xo.Schema{}, err
 	}
-	// fix enums for mysql
-	if driver == "mysql" {
-		for i := range len(schema.Tables) {
-			for j := range len(schema.Tables[i].Columns) {
-				if e := schema.EnumByName(schema.Tables[i].Columns[j].Type.Type); e != nil {
-					schema.Tables[i].Columns[j].Type.Enum = e
-				}
+	return schema, nil
+}
+
+// fixEnums sets the enum on mysql table columns.
+func fixEnums(schema *xo.Schema) {
+	if schema.Driver != "mysql" {
+		return
+	}
+	for i := range len(schema.Tables) {
+		for j := range len(schema.Tables[i].Columns) {
+			if e := schema.EnumByName(schema.Tables[i].Columns[j].Type.Type); e != nil {
+				schema.Tables[i].Columns[j].Type.Enum = e
 			}
 		}
 	}
-	// emit
-	set.Schemas = append(set.Schemas, schema)
-	return nil
 }
 
 // loadEnums loads enums.
@@ -105,6 +194,7 @@ func loadProcs(ctx context.Context, args *Args) ([]xo.Proc, error) {
 	}
 	// process procs
 	procMap := make(map[string]xo.Proc)
+	unnamed := make(map[string]bool)
 	for _, proc := range procs {
 		if !validType(args, false, proc.ProcName) {
 			continue
@@ -123,6 +213,9 @@ func loadProcs(ctx context.Context, args *Args) ([]xo.Proc, error) {
 		name := proc.ReturnName
 		if name == "" || name == "-" {
 			name = fmt.Sprintf("r%d", len(returnFields))
+			// an unnamed first return is a bare type (ie, not a RETURNS
+			// TABLE column)
+			unnamed[proc.ProcID] = len(returnFields) == 0
 		}
 		p := &xo.Proc{
 			Type: proc.ProcType,
@@ -132,6 +225,7 @@ func loadProcs(ctx context.Context, args *Args) ([]xo.Proc, error) {
 				Name: name,
 				Type: d,
 			}),
+			Set:        proc.ReturnsSet,
 			Definition: strings.TrimSpace(proc.ProcDef),
 		}
 		// load proc parameters
@@ -142,6 +236,12 @@ func loadProcs(ctx context.Context, args *Args) ([]xo.Proc, error) {
 	}
 	var m []xo.Proc
 	for _, proc := range procMap {
+		// expand the columns of a bare composite type or table-valued function
+		if proc.Set && len(proc.Returns) == 1 && unnamed[proc.ID] {
+			if err := loadProcColumns(ctx, &proc); err != nil {
+				return nil, err
+			}
+		}
 		if len(proc.Returns) == 1 && proc.Returns[0].Type.Type == "void" {
 			proc.Void, proc.Returns = true, proc.Returns[1:]
 		}
@@ -156,6 +256,111 @@ func loadProcs(ctx context.Context, args *Args) ([]xo.Proc, error) {
 	return m, nil
 }
 
+// loadProcColumns loads the columns of the composite type returned by a
+// set-returning function (postgres), or of a table-valued function
+// (sqlserver), as the function's returns.
+func loadProcColumns(ctx context.Context, proc *xo.Proc) error {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	// table-valued functions have no return type and their columns are
+	// stored under the function name
+	name := proc.Returns[0].Type.Type
+	switch i := strings.LastIndex(name, "."); {
+	case name == "void":
+		name = proc.Name
+	case i != -1:
+		// type qualified with the schema it is defined in
+		ctx = context.WithValue(ctx, xo.SchemaKey, strings.Trim(name[:i], `"`))
+		name = name[i+1:]
+	}
+	columns, err := loader.TableColumns(ctx, strings.Trim(name, `"`))
+	if err != nil {
+		return err
+	}
+	var returns []xo.Field
+	for _, c := range columns {
+		d, err := xo.ParseType(c.DataType, driver)
+		if err != nil {
+			return err
+		}
+		d.Nullable = !c.NotNull
+		returns = append(returns, xo.Field{
+			Name: c.ColumnName,
+			Type: d,
+		})
+	}
+	// scalar set-returning functions have no columns
+	if len(returns) != 0 {
+		proc.Returns = returns
+	}
+	return nil
+}
+
+// loadProcResults sets the result sets returned by the stored procedures in
+// the schemas, as defined by results (<proc>.<name>=<fields>). The proc may be
+// qualified with its schema (<schema>.<proc>.<name>=<fields>).
+//
+// Result sets returned by a procedure cannot be introspected, and are only
+// supported for MySQL.
+func loadProcResults(schemas []xo.Schema, results []string) error {
+	// results replace those of a procedure loaded from a snapshot
+	seen := make(map[*xo.Proc]bool)
+	for _, s := range results {
+		name, fields, ok := strings.Cut(s, "=")
+		i := strings.LastIndex(name, ".")
+		if !ok || i == -1 {
+			return fmt.Errorf("proc result %q: must be <proc>.<name>=<fields>", s)
+		}
+		procName, resultName := strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+		schemaName, n, qualified := strings.Cut(procName, ".")
+		if qualified {
+			procName = n
+		}
+		switch {
+		case procName == "" || resultName == "":
+			return fmt.Errorf("proc result %q: proc and name cannot be empty", s)
+		case strings.TrimSpace(fields) == "":
+			return fmt.Errorf("proc result %s: fields cannot be empty", name)
+		}
+		f, err := splitFields(fields)
+		if err != nil {
+			return fmt.Errorf("proc result %s: %w", name, err)
+		}
+		var found bool
+		for i := range schemas {
+			if qualified && schemas[i].Name != schemaName {
+				continue
+			}
+			for j := range schemas[i].Procs {
+				p := &schemas[i].Procs[j]
+				if p.Name != procName {
+					continue
+				}
+				if !seen[p] {
+					seen[p], p.Results = true, nil
+				}
+				switch {
+				case schemas[i].Driver != "mysql":
+					return fmt.Errorf("proc result %s: result sets are only supported for mysql procedures", name)
+				case p.Type != "procedure":
+					return fmt.Errorf("proc result %s: %s is not a procedure", name, procName)
+				case slices.ContainsFunc(p.Results, func(r xo.Result) bool { return r.Name == resultName }):
+					return fmt.Errorf("proc result %s: defined more than once", name)
+				}
+				p.Results = append(p.Results, xo.Result{
+					Name:         resultName,
+					Fields:       f,
+					ManualFields: true,
+				})
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("proc result %s: procedure %s not found", name, procName)
+		}
+	}
+	return nil
+}
+
 // loadProcParams loads stored procedure parameters.
 func loadProcParams(ctx context.Context, _ *Args, proc *xo.Proc) error {
 	driver, _, _ := xo.DriverDbSchema(ctx)
@@ -174,10 +379,22 @@ func loadProcParams(ctx context.Context, _ *Args, proc *xo.Proc) error {
 		if name == "" {
 			name = fmt.Sprintf("p%d", i)
 		}
+		var dir string
+		switch mode := strings.ToLower(param.ParamMode); mode {
+		case "out", "inout":
+			dir = mode
+		}
 		proc.Params = append(proc.Params, xo.Field{
-			Name: name,
-			Type: d,
+			Name:      name,
+			Type:      d,
+			Direction: dir,
 		})
+		// mark the return value of an out param
+		if dir != "" {
+			if i := index(proc.Returns, name); i != -1 {
+				proc.Returns[i].Direction = dir
+			}
+		}
 	}
 	return nil
 }
@@ -189,15 +406,16 @@ func loadTables(ctx context.Context, args *Args, typ string) ([]xo.Table, error)
 	if err != nil {
 		return nil, err
 	}
+	tables = slices.DeleteFunc(tables, func(table *models.Table) bool {
+		return !validType(args, false, table.TableName)
+	})
 	sort.Slice(tables, func(i, j int) bool {
 		return tables[i].TableName < tables[j].TableName
 	})
 	// create types
-	var m []xo.Table
-	for _, table := range tables {
-		if !validType(args, false, table.TableName) {
-			continue
-		}
+	m := make([]xo.Table, len(tables))
+	if err := parallel(ctx, len(tables), args.SchemaParams.Concurrency, func(ctx context.Context, i int) error {
+		table := tables[i]
 		// create table
 		t := &xo.Table{
 			Type:       typ,
@@ -212,28 +430,52 @@ func loadTables(ctx context.Context, args *Args, typ string) ([]xo.Table, error)
 
 		// process columns
 		if err := loadColumns(ctx, args, t); err != nil {
-			return nil, err
+			return err
 		}
 		// load indexes
 		if err := loadTableIndexes(ctx, args, t); err != nil {
-			return nil, err
+			return err
 		}
-		m = append(m, *t)
-	}
-	// load foreign keys
-	for i, table := range m {
-		if m[i].ForeignKeys, err = loadTableForeignKeys(ctx, args, m, table); err != nil {
-			return nil, err
+		// load check constraints
+		if typ == "table" {
+			if err := loadTableChecks(ctx, args, t); err != nil {
+				return err
+			}
 		}
+		m[i] = *t
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 	return m, nil
 }
 
+// parallel calls f for each index in [0, n), running at most concurrency
+// calls at once. After the first error, no further calls are started and the
+// context passed to the running calls is canceled. Returns the first error.
+func parallel(ctx context.Context, n, concurrency int, f func(context.Context, int) error) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(max(concurrency, 1))
+	for i := range n {
+		if ctx.Err() != nil {
+			break
+		}
+		eg.Go(func() error {
+			// the context may have been canceled while waiting to be started
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return f(ctx, i)
+		})
+	}
+	return eg.Wait()
+}
+
 // loadColumns loads table/view columns.
 func loadColumns(ctx context.Context, args *Args, table *xo.Table) error {
 	driver, _, _ := xo.DriverDbSchema(ctx)
 	// load sequences
-	sequences, err := loader.TableSequences(ctx, table.Name)
+	sequences, err := tableSequences(ctx, table.Name)
 	if err != nil {
 		return err
 	}
@@ -243,7 +485,7 @@ func loadColumns(ctx context.Context, args *Args, table *xo.Table) error {
 		sqMap[s.ColumnName] = true
 	}
 	// load columns
-	columns, err := loader.TableColumns(ctx, table.Name)
+	columns, err := tableColumns(ctx, table.Name)
 	if err != nil {
 		return err
 	}
@@ -286,7 +528,7 @@ func loadColumns(ctx context.Context, args *Args, table *xo.Table) error {
 // loadTableIndexes loads index definitions per table.
 func loadTableIndexes(ctx context.Context, args *Args, table *xo.Table) error {
 	// load indexes
-	indexes, err := loader.TableIndexes(ctx, table.Name)
+	indexes, err := tableIndexes(ctx, table.Name)
 	if err != nil {
 		return err
 	}
@@ -347,10 +589,53 @@ func loadTableIndexes(ctx context.Context, args *Args, table *xo.Table) error {
 	return nil
 }
 
+// loadTableChecks loads the check constraints for a table. The columns of a
+// check not provided by the database are the table's columns used in the
+// check's expression.
+func loadTableChecks(ctx context.Context, _ *Args, table *xo.Table) error {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	// load checks
+	checks, err := loader.TableChecks(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+	// process checks, with a row for each check column
+	for _, c := range checks {
+		i := slices.IndexFunc(table.Checks, func(check xo.Check) bool {
+			return check.Name == c.CheckName
+		})
+		if i == -1 {
+			table.Checks = append(table.Checks, xo.Check{
+				Name: c.CheckName,
+				Expr: strings.TrimSpace(c.CheckDef),
+			})
+			i = len(table.Checks) - 1
+		}
+		if j := index(table.Columns, c.ColumnName); j != -1 {
+			table.Checks[i].Fields = append(table.Checks[i].Fields, table.Columns[j])
+		}
+	}
+	for i, check := range table.Checks {
+		if len(check.Fields) != 0 {
+			continue
+		}
+		toks, _ := sqllex.Lex(driver, check.Expr)
+		for _, tok := range toks {
+			j := slices.IndexFunc(table.Columns, func(f xo.Field) bool {
+				return tok.IsName() && strings.EqualFold(f.Name, tok.Text)
+			})
+			if j != -1 && index(table.Checks[i].Fields, table.Columns[j].Name) == -1 {
+				table.Checks[i].Fields = append(table.Checks[i].Fields, table.Columns[j])
+			}
+		}
+	}
+	return nil
+}
+
 // loadIndexColumns loads the index column information.
 func loadIndexColumns(ctx context.Context, _ *Args, table *xo.Table, index *xo.Index) error {
 	// load index columns
-	cols, err := loader.IndexColumns(ctx, table.Name, index.Name)
+	cols, err := indexColumns(ctx, table.Name, index.Name)
 	if err != nil {
 		return err
 	}
@@ -373,25 +658,86 @@ func loadIndexColumns(ctx context.Context, _ *Args, table *xo.Table, index *xo.I
 	return nil
 }
 
-// loadTableForeignKeys loads foreign key definitions per table.
-func loadTableForeignKeys(ctx context.Context, args *Args, tables []xo.Table, table xo.Table) ([]xo.ForeignKey, error) {
+// loadForeignKeys loads the foreign keys for the tables and views in the
+// schemas. As table foreign keys can reference tables in any of the schemas,
+// foreign keys are loaded after all schemas have been loaded.
+func loadForeignKeys(ctx context.Context, args *Args, schemas []xo.Schema) error {
+	for i := range schemas {
+		ctx, err := withForeignKeyCatalog(context.WithValue(ctx, xo.SchemaKey, schemas[i].Name))
+		if err != nil {
+			return err
+		}
+		views := []xo.Schema{{Name: schemas[i].Name, Tables: schemas[i].Views}}
+		// load concurrently, setting the foreign keys only after all tables
+		// are loaded, as the referenced tables are read while loading
+		tableFkeys := make([][]xo.ForeignKey, len(schemas[i].Tables))
+		tableWarnings := make([][]string, len(schemas[i].Tables))
+		if err := parallel(ctx, len(tableFkeys), args.SchemaParams.Concurrency, func(ctx context.Context, j int) error {
+			var err error
+			tableFkeys[j], tableWarnings[j], err = loadTableForeignKeys(ctx, args, schemas, schemas[i].Tables[j])
+			return err
+		}); err != nil {
+			return err
+		}
+		viewFkeys := make([][]xo.ForeignKey, len(schemas[i].Views))
+		viewWarnings := make([][]string, len(schemas[i].Views))
+		if err := parallel(ctx, len(viewFkeys), args.SchemaParams.Concurrency, func(ctx context.Context, j int) error {
+			var err error
+			viewFkeys[j], viewWarnings[j], err = loadTableForeignKeys(ctx, args, views, schemas[i].Views[j])
+			return err
+		}); err != nil {
+			return err
+		}
+		for j, fkeys := range tableFkeys {
+			schemas[i].Tables[j].ForeignKeys = fkeys
+		}
+		for j, fkeys := range viewFkeys {
+			schemas[i].Views[j].ForeignKeys = fkeys
+		}
+		// write warnings in table order, regardless of the load order
+		for _, warning := range slices.Concat(slices.Concat(tableWarnings...), slices.Concat(viewWarnings...)) {
+			fmt.Fprintln(os.Stderr, "WARNING: "+warning)
+		}
+	}
+	return nil
+}
+
+// loadTableForeignKeys loads foreign key definitions per table, resolving the
+// referenced tables from the schemas. Returns warnings for the skipped foreign
+// keys.
+func loadTableForeignKeys(ctx context.Context, args *Args, schemas []xo.Schema, table xo.Table) ([]xo.ForeignKey, []string, error) {
+	_, _, schemaName := xo.DriverDbSchema(ctx)
 	// load foreign keys
-	foreignKeys, err := loader.TableForeignKeys(ctx, table.Name)
+	foreignKeys, err := tableForeignKeys(ctx, table.Name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	var warnings []string
 	fkMap := make(map[string]xo.ForeignKey)
 	// loop over foreign keys for table
 	for _, fkey := range foreignKeys {
 		// if the referenced table is excluded, we don't want to omit it
 		if !validType(args, false, fkey.RefTableName) {
-			fmt.Fprintf(os.Stderr, "WARNING: skipping table %q foreign key %q (%q previously excluded)\n", table.Name, fkey.ForeignKeyName, fkey.RefTableName)
+			warnings = append(warnings, fmt.Sprintf("skipping table %q foreign key %q (%q previously excluded)", table.Name, fkey.ForeignKeyName, fkey.RefTableName))
+			continue
+		}
+		// find the referenced schema, which is only kept when different than
+		// the table's schema
+		refSchema := fkey.RefSchemaName
+		if refSchema == schemaName {
+			refSchema = ""
+		}
+		i := slices.IndexFunc(schemas, func(s xo.Schema) bool {
+			return s.Name == cmp.Or(refSchema, schemaName)
+		})
+		if i == -1 {
+			warnings = append(warnings, fmt.Sprintf("skipping table %q foreign key %q (schema %q not loaded)", table.Name, fkey.ForeignKeyName, refSchema))
 			continue
 		}
 		// check foreign key
 		field, refTable, refField := xo.Field{}, xo.Table{}, xo.Field{}
-		if err := checkFk(tables, table, fkey, &field, &refTable, &refField); err != nil {
-			return nil, err
+		if err := checkFk(schemas[i].Tables, table, fkey, &field, &refTable, &refField); err != nil {
+			return nil, nil, err
 		}
 		// ForeignKeyName should only be empty on SQLite. When this happens, we
 		// resort to using the keyid (which is unique to each foreign key, even
@@ -404,6 +750,7 @@ func loadTableForeignKeys(ctx context.Context, args *Args, tables []xo.Table, ta
 		fkMap[key] = xo.ForeignKey{
 			Name:      fkey.ForeignKeyName,
 			Fields:    append(f.Fields, field),
+			RefSchema: refSchema,
 			RefTable:  refTable.Name,
 			RefFields: append(f.RefFields, refField),
 		}
@@ -432,7 +779,7 @@ func loadTableForeignKeys(ctx context.Context, args *Args, tables []xo.Table, ta
 	sort.Slice(fkeys, func(i, j int) bool {
 		return fkeys[i].Name < fkeys[j].Name
 	})
-	return fkeys, nil
+	return fkeys, warnings, nil
 }
 
 // validType returns whether the type name given is valid, given the --include
@@ -496,9 +843,13 @@ func checkFk(tables []xo.Table, table xo.Table, fkey *models.ForeignKey, field *
 }
 
 // resolveFkName returns the foreign key name for the passed foreign key.
-// The function converts all names to snake_case.
+// The function converts all names to snake_case. Foreign keys referencing a
+// table in another schema are prefixed with the schema name.
 func resolveFkName(fkey xo.ForeignKey, table xo.Table, mode string) string {
 	tableName := singularize(fkey.RefTable)
+	if fkey.RefSchema != "" {
+		tableName = fkey.RefSchema + "_" + tableName
+	}
 	switch mode {
 	case "parent":
 		// parent causes a foreign key field to be named in the form of
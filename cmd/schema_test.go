@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xo/dbtpl/loader"
+	"github.com/xo/dbtpl/models"
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestMatchSchemas(t *testing.T) {
+	available := []string{"auth", "auth_audit", "billing", "public"}
+	tests := []struct {
+		active   string
+		patterns []string
+		exp      []string
+		err      bool
+	}{
+		{"public", []string{"public"}, []string{"public"}, false},
+		{"public", []string{"public", "billing", "public"}, []string{"public", "billing"}, false},
+		{"public", []string{"auth*", "public"}, []string{"public", "auth", "auth_audit"}, false},
+		{"public", []string{"b*"}, []string{"billing"}, false},
+		{"public", []string{"*"}, []string{"public", "auth", "auth_audit", "billing"}, false},
+		{"public", []string{"other"}, []string{"other"}, false},
+		{"public", []string{"x*"}, nil, true},
+	}
+	for i, test := range tests {
+		names, err := matchSchemas(test.active, test.patterns, available)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case !reflect.DeepEqual(names, test.exp):
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, names)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		// all calls
+		var mu sync.Mutex
+		var called []int
+		if err := parallel(context.Background(), 10, concurrency, func(_ context.Context, i int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			called = append(called, i)
+			return nil
+		}); err != nil {
+			t.Fatalf("concurrency %d expected no error, got: %v", concurrency, err)
+		}
+		if slices.Sort(called); !reflect.DeepEqual(called, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+			t.Errorf("concurrency %d expected all indexes to be called, got: %v", concurrency, called)
+		}
+		// no calls started after an error, and running calls are canceled
+		var started atomic.Int32
+		err := parallel(context.Background(), 100, concurrency, func(ctx context.Context, i int) error {
+			started.Add(1)
+			if i == 0 {
+				return errors.New("failed")
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		switch n := started.Load(); {
+		case err == nil || err.Error() != "failed":
+			t.Errorf("concurrency %d expected error failed, got: %v", concurrency, err)
+		case int(n) > concurrency:
+			t.Errorf("concurrency %d expected at most %d calls, got: %d", concurrency, concurrency, n)
+		}
+	}
+}
+
+func TestLoadProcResults(t *testing.T) {
+	tests := []struct {
+		driver  string
+		results []string
+		exp     []xo.Result
+		err     bool
+	}{
+		{"mysql", nil, nil, false},
+		{"mysql", []string{"author_books.Authors=AuthorID int,Name"}, []xo.Result{
+			{Name: "Authors", Fields: []xo.Field{{Name: "AuthorID", Type: xo.Type{Type: "int"}}, {Name: "Name", Type: xo.Type{Type: "string"}}}, ManualFields: true},
+		}, false},
+		{"mysql", []string{"booktest.author_books.Authors=AuthorID int", "author_books.Books=BookID int"}, []xo.Result{
+			{Name: "Authors", Fields: []xo.Field{{Name: "AuthorID", Type: xo.Type{Type: "int"}}}, ManualFields: true},
+			{Name: "Books", Fields: []xo.Field{{Name: "BookID", Type: xo.Type{Type: "int"}}}, ManualFields: true},
+		}, false},
+		{"mysql", []string{"author_books=AuthorID int"}, nil, true},
+		{"mysql", []string{"author_books.Authors"}, nil, true},
+		{"mysql", []string{"author_books.Authors="}, nil, true},
+		{"mysql", []string{"author_books.Authors=AuthorID int", "author_books.Authors=AuthorID int"}, nil, true},
+		{"mysql", []string{"other.author_books.Authors=AuthorID int"}, nil, true},
+		{"mysql", []string{"books.Books=BookID int"}, nil, true},
+		{"mysql", []string{"say_hello.Books=BookID int"}, nil, true},
+		{"postgres", []string{"author_books.Authors=AuthorID int"}, nil, true},
+	}
+	for i, test := range tests {
+		schemas := []xo.Schema{{
+			Driver: test.driver,
+			Name:   "booktest",
+			Procs: []xo.Proc{
+				{Type: "function", Name: "say_hello"},
+				{Type: "procedure", Name: "author_books", Results: []xo.Result{{Name: "Old"}}},
+			},
+		}}
+		err := loadProcResults(schemas, test.results)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case test.err:
+		case test.results == nil:
+			if res := schemas[0].Procs[1].Results; len(res) != 1 || res[0].Name != "Old" {
+				t.Errorf("test %d expected snapshot results to be kept, got: %v", i, res)
+			}
+		case !reflect.DeepEqual(schemas[0].Procs[1].Results, test.exp):
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, schemas[0].Procs[1].Results)
+		}
+	}
+}
+
+func TestLoadProcsSet(t *testing.T) {
+	loader.Register("proctest", loader.Loader{
+		Procs: func(context.Context, models.DB, string) ([]*models.Proc, error) {
+			return []*models.Proc{
+				{ProcID: "1", ProcName: "author_books", ProcType: "function", ReturnType: "integer", ReturnName: "book_id", ReturnsSet: true},
+				{ProcID: "1", ProcName: "author_books", ProcType: "function", ReturnType: "text", ReturnName: "title", ReturnsSet: true},
+				{ProcID: "2", ProcName: "all_books", ProcType: "function", ReturnType: "public.books", ReturnsSet: true},
+				{ProcID: "3", ProcName: "top_books", ProcType: "function", ReturnType: "void", ReturnsSet: true},
+				{ProcID: "4", ProcName: "book_ids", ProcType: "function", ReturnType: "integer", ReturnsSet: true},
+				{ProcID: "5", ProcName: "book_rows", ProcType: "function", ReturnType: "books", ReturnName: "b", ReturnsSet: true},
+				{ProcID: "6", ProcName: "other_books", ProcType: "function", ReturnType: "other.books", ReturnsSet: true},
+				{ProcID: "7", ProcName: "quoted_books", ProcType: "function", ReturnType: `"Other".books`, ReturnsSet: true},
+			}, nil
+		},
+		ProcParams: func(context.Context, models.DB, string, string) ([]*models.ProcParam, error) {
+			return nil, nil
+		},
+		TableColumns: func(_ context.Context, _ models.DB, schema, table string) ([]*models.Column, error) {
+			switch {
+			case (schema == "other" || schema == "Other") && table == "books":
+				return []*models.Column{
+					{ColumnName: "other_id", DataType: "integer", NotNull: true},
+				}, nil
+			case schema == "public" && (table == "books" || table == "top_books"):
+				return []*models.Column{
+					{ColumnName: "book_id", DataType: "integer", NotNull: true},
+					{ColumnName: "title", DataType: "text"},
+				}, nil
+			}
+			return nil, nil
+		},
+	})
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "proctest")
+	ctx = context.WithValue(ctx, xo.SchemaKey, "public")
+	procs, err := loadProcs(ctx, &Args{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := map[string][]string{
+		"all_books":    {"book_id integer", "title text"},
+		"author_books": {"book_id integer", "title text"},
+		"book_ids":     {"r0 integer"},
+		"book_rows":    {"b books"},
+		"other_books":  {"other_id integer"},
+		"quoted_books": {"other_id integer"},
+		"top_books":    {"book_id integer", "title text"},
+	}
+	if len(procs) != len(exp) {
+		t.Fatalf("expected %d procs, got: %d", len(exp), len(procs))
+	}
+	for _, proc := range procs {
+		var returns []string
+		for _, f := range proc.Returns {
+			returns = append(returns, f.Name+" "+f.Type.Type)
+		}
+		switch {
+		case !proc.Set || proc.Void:
+			t.Errorf("%s expected set-returning function", proc.Name)
+		case !reflect.DeepEqual(returns, exp[proc.Name]):
+			t.Errorf("%s expected returns %v, got: %v", proc.Name, exp[proc.Name], returns)
+		}
+	}
+}
+
+func TestLoadProcParams(t *testing.T) {
+	loader.Register("paramdirtest", loader.Loader{
+		ProcParams: func(context.Context, models.DB, string, string) ([]*models.ProcParam, error) {
+			// modes as returned by the postgres, mysql and oracle queries
+			return []*models.ProcParam{
+				{ParamName: "author_id", ParamType: "int", ParamMode: "IN"},
+				{ParamName: "total", ParamType: "int", ParamMode: "OUT"},
+				{ParamName: "running", ParamType: "int", ParamMode: "INOUT"},
+				{ParamType: "int"},
+			}, nil
+		},
+	})
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "paramdirtest")
+	proc := &xo.Proc{
+		Type: "procedure",
+		Name: "book_counts",
+		Returns: []xo.Field{
+			{Name: "total", Type: xo.Type{Type: "int"}},
+			{Name: "running", Type: xo.Type{Type: "int"}},
+		},
+	}
+	if err := loadProcParams(ctx, &Args{}, proc); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var params, returns []string
+	for _, f := range proc.Params {
+		params = append(params, f.Name+" "+f.Direction)
+	}
+	for _, f := range proc.Returns {
+		returns = append(returns, f.Name+" "+f.Direction)
+	}
+	if exp := []string{"author_id ", "total out", "running inout", "p3 "}; !reflect.DeepEqual(params, exp) {
+		t.Errorf("expected params %q, got: %q", exp, params)
+	}
+	if exp := []string{"total out", "running inout"}; !reflect.DeepEqual(returns, exp) {
+		t.Errorf("expected returns %q, got: %q", exp, returns)
+	}
+}
+
+func TestLoadTableChecks(t *testing.T) {
+	loader.Register("checktest", loader.Loader{
+		TableChecks: func(context.Context, models.DB, string, string) ([]*models.Check, error) {
+			return []*models.Check{
+				{CheckName: "books_range", ColumnName: "pages", CheckDef: "pages > 0 AND rating < pages"},
+				{CheckName: "books_range", ColumnName: "rating", CheckDef: "pages > 0 AND rating < pages"},
+				{CheckName: "books_chk_1", CheckDef: " (`Title` <> _utf8mb4'') "},
+			}, nil
+		},
+	})
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "checktest")
+	table := &xo.Table{
+		Name: "books",
+		Columns: []xo.Field{
+			{Name: "title"},
+			{Name: "pages"},
+			{Name: "rating"},
+		},
+	}
+	if err := loadTableChecks(ctx, &Args{}, table); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var checks []string
+	for _, check := range table.Checks {
+		s := check.Name + " " + check.Expr + ":"
+		for _, f := range check.Fields {
+			s += " " + f.Name
+		}
+		checks = append(checks, s)
+	}
+	exp := []string{
+		"books_range pages > 0 AND rating < pages: pages rating",
+		"books_chk_1 (`Title` <> _utf8mb4''): title",
+	}
+	if !reflect.DeepEqual(checks, exp) {
+		t.Errorf("expected checks %q, got: %q", exp, checks)
+	}
+}
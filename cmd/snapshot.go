@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+// snapshotPrefix is the prefix for snapshot urls.
+const snapshotPrefix = "snapshot:"
+
+// snapshotKey is the snapshot context key.
+const snapshotKey xo.ContextKey = "snapshot"
+
+// isSnapshot determines if urlstr refers to a schema snapshot (ie,
+// "snapshot:path/to/dbtpl.dbtpl.json").
+func isSnapshot(urlstr string) bool {
+	return strings.HasPrefix(urlstr, snapshotPrefix)
+}
+
+// openSnapshot reads a snapshot previously generated by the json or yaml
+// templates, returning a context for use in template generation.
+//
+// The driver and schema name are set from the snapshot, and no database
+// connection is made.
+func openSnapshot(ctx context.Context, urlstr, schemaName string) (context.Context, error) {
+	set, err := xo.ReadSnapshot(strings.TrimPrefix(urlstr, snapshotPrefix))
+	if err != nil {
+		return nil, err
+	}
+	// find schema
+	var schema *xo.Schema
+	for i := range set.Schemas {
+		if schemaName == "" || set.Schemas[i].Name == schemaName {
+			schema = &set.Schemas[i]
+			break
+		}
+	}
+	switch {
+	case schema == nil && schemaName != "":
+		return nil, fmt.Errorf("schema %q not found in snapshot", schemaName)
+	case schema == nil:
+		return nil, errors.New("snapshot does not contain a schema")
+	case schema.Driver == "":
+		return nil, fmt.Errorf("snapshot schema %q does not define a driver", schema.Name)
+	}
+	// add driver, schema, snapshot to context
+	ctx = context.WithValue(ctx, xo.DriverKey, schema.Driver)
+	ctx = context.WithValue(ctx, xo.SchemaKey, schema.Name)
+	ctx = context.WithValue(ctx, snapshotKey, set)
+	return ctx, nil
+}
+
+// loadSnapshot loads the schemas from the snapshot in the context, applying
+// the same processing as when loaded from a database.
+func loadSnapshot(ctx context.Context, set *xo.Set, args *Args) error {
+	snapshot, ok := ctx.Value(snapshotKey).(*xo.Set)
+	if !ok {
+		return errors.New("no snapshot in context")
+	}
+	// determine schemas
+	_, _, active := xo.DriverDbSchema(ctx)
+	names := []string{active}
+	if len(args.LoaderParams.Schemas) != 0 {
+		var available []string
+		for _, schema := range snapshot.Schemas {
+			available = append(available, schema.Name)
+		}
+		var err error
+		if names, err = matchSchemas(active, args.LoaderParams.Schemas, available); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		i := slices.IndexFunc(snapshot.Schemas, func(s xo.Schema) bool {
+			return s.Name == name
+		})
+		if i == -1 {
+			return fmt.Errorf("schema %q not found in snapshot", name)
+		}
+		set.Schemas = append(set.Schemas, snapshotSchema(args, snapshot.Schemas[i]))
+	}
+	return nil
+}
+
+// snapshotSchema filters the schema's enums, procs, tables, and views.
+func snapshotSchema(args *Args, schema xo.Schema) xo.Schema {
+	// filter enums, procs
+	var enums []xo.Enum
+	for _, e := range schema.Enums {
+		if validType(args, false, e.Name) {
+			enums = append(enums, e)
+		}
+	}
+	var procs []xo.Proc
+	for _, p := range schema.Procs {
+		if validType(args, false, p.Name) {
+			procs = append(procs, p)
+		}
+	}
+	schema.Enums, schema.Procs = enums, procs
+	// process tables, views
+	schema.Tables = snapshotTables(args, schema.Tables)
+	schema.Views = snapshotTables(args, schema.Views)
+	fixEnums(&schema)
+	return schema
+}
+
+// snapshotTables filters the tables and columns, and generates the index and
+// foreign key func names not stored in snapshots.
+func snapshotTables(args *Args, tables []xo.Table) []xo.Table {
+	var m []xo.Table
+	for _, t := range tables {
+		if !validType(args, false, t.Name) {
+			continue
+		}
+		var cols []xo.Field
+		for _, c := range t.Columns {
+			if validType(args, true, t.Name, c.Name) {
+				cols = append(cols, c)
+			}
+		}
+		t.Columns = cols
+		// index func names
+		indexes := make([]xo.Index, len(t.Indexes))
+		for i, index := range t.Indexes {
+			index.Func = indexFuncName(index, t.Name, args.SchemaParams.UseIndexNames)
+			indexes[i] = index
+		}
+		t.Indexes = indexes
+		m = append(m, t)
+	}
+	// foreign key func names
+	for i, t := range m {
+		var fkeys []xo.ForeignKey
+		for _, fkey := range t.ForeignKeys {
+			if !validType(args, false, fkey.RefTable) {
+				fmt.Fprintf(os.Stderr, "WARNING: skipping table %q foreign key %q (%q previously excluded)\n", t.Name, fkey.Name, fkey.RefTable)
+				continue
+			}
+			// as with loadTableForeignKeys, the name is resolved prior to the
+			// table's foreign keys being set
+			fkey.Func = resolveFkName(fkey, xo.Table{Name: t.Name}, args.SchemaParams.FkMode)
+			fkey.RefFunc = indexFuncName(xo.Index{
+				IsUnique: true,
+				Fields:   fkey.RefFields,
+			}, fkey.RefTable, false)
+			fkeys = append(fkeys, fkey)
+		}
+		m[i].ForeignKeys = fkeys
+	}
+	return m
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kenshaw/glob"
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestOpenSnapshot(t *testing.T) {
+	tests := []struct {
+		schema string
+		exp    string
+		err    bool
+	}{
+		{"", "public", false},
+		{"public", "public", false},
+		{"auth", "auth", false},
+		{"other", "", true},
+	}
+	for _, name := range []string{"snapshot.json", "snapshot.yaml"} {
+		for i, test := range tests {
+			ctx, err := openSnapshot(context.Background(), "snapshot:testdata/"+name, test.schema)
+			switch {
+			case test.err && err == nil:
+				t.Errorf("%s test %d expected error", name, i)
+			case !test.err && err != nil:
+				t.Errorf("%s test %d expected no error, got: %v", name, i, err)
+			case test.err:
+			default:
+				driver, _, schema := xo.DriverDbSchema(ctx)
+				if driver != "postgres" || schema != test.exp {
+					t.Errorf("%s test %d expected postgres %s, got: %s %s", name, i, test.exp, driver, schema)
+				}
+				if set, ok := ctx.Value(snapshotKey).(*xo.Set); !ok || len(set.Schemas) != 2 {
+					t.Errorf("%s test %d expected snapshot with 2 schemas in context", name, i)
+				}
+			}
+		}
+	}
+	if _, err := openSnapshot(context.Background(), "snapshot:testdata/missing.json", ""); err == nil {
+		t.Errorf("expected error for missing snapshot")
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		schemas []string
+		exclude []string
+		params  SchemaParams
+		exp     []string
+		err     bool
+	}{
+		{
+			"default", nil, nil, SchemaParams{FkMode: "smart"},
+			[]string{
+				"public.authors: author_id name; index author_by_author_id",
+				"public.books: book_id author_id title book_type; index book_by_book_id books_by_title; fkey author author_by_author_id",
+			},
+			false,
+		},
+		{
+			"schemas", []string{"public", "auth"}, nil, SchemaParams{FkMode: "smart"},
+			[]string{
+				"public.authors: author_id name; index author_by_author_id",
+				"public.books: book_id author_id title book_type; index book_by_book_id books_by_title; fkey author author_by_author_id",
+				"auth.users: user_id email; index user_by_user_id",
+			},
+			false,
+		},
+		{
+			"glob", []string{"au*"}, []string{"books", "authors"}, SchemaParams{FkMode: "smart"},
+			[]string{
+				"auth.users: user_id email; index user_by_user_id",
+			},
+			false,
+		},
+		{
+			"missing schema", []string{"other"}, nil, SchemaParams{FkMode: "smart"}, nil, true,
+		},
+		{
+			"exclude", nil, []string{"authors", "books.title"}, SchemaParams{FkMode: "smart"},
+			[]string{
+				"public.books: book_id author_id book_type; index book_by_book_id books_by_title",
+			},
+			false,
+		},
+		{
+			"func names", nil, nil, SchemaParams{FkMode: "field", UseIndexNames: true},
+			[]string{
+				"public.authors: author_id name; index author_by_authors",
+				"public.books: book_id author_id title book_type; index book_by_books books_by_title; fkey author_by_author_id author_by_author_id",
+			},
+			false,
+		},
+	}
+	for _, name := range []string{"snapshot.json", "snapshot.yaml"} {
+		for _, test := range tests {
+			t.Run(name+" "+test.name, func(t *testing.T) {
+				ctx, err := openSnapshot(context.Background(), "snapshot:testdata/"+name, "")
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				args := &Args{LoaderParams: LoaderParams{Schemas: test.schemas}, SchemaParams: test.params}
+				for _, pattern := range test.exclude {
+					g, err := glob.Compile(pattern)
+					if err != nil {
+						t.Fatalf("expected no error, got: %v", err)
+					}
+					args.SchemaParams.Exclude = append(args.SchemaParams.Exclude, g)
+				}
+				set := new(xo.Set)
+				err = loadSnapshot(ctx, set, args)
+				switch {
+				case test.err && err == nil:
+					t.Fatalf("expected error")
+				case !test.err && err != nil:
+					t.Fatalf("expected no error, got: %v", err)
+				case test.err:
+					return
+				}
+				var tables []string
+				for _, schema := range set.Schemas {
+					for _, table := range schema.Tables {
+						tables = append(tables, snapshotTableString(schema.Name, table))
+					}
+				}
+				if !reflect.DeepEqual(tables, test.exp) {
+					t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(test.exp, "\n"), strings.Join(tables, "\n"))
+				}
+			})
+		}
+	}
+}
+
+// snapshotTableString returns a string describing the table's columns, and
+// index and foreign key func names.
+func snapshotTableString(schema string, table xo.Table) string {
+	var cols, indexes []string
+	for _, c := range table.Columns {
+		cols = append(cols, c.Name)
+	}
+	for _, index := range table.Indexes {
+		indexes = append(indexes, index.Func)
+	}
+	s := schema + "." + table.Name + ": " + strings.Join(cols, " ") + "; index " + strings.Join(indexes, " ")
+	for _, fkey := range table.ForeignKeys {
+		s += "; fkey " + fkey.Func + " " + fkey.RefFunc
+	}
+	return s
+}
@@ -0,0 +1,374 @@
+// Package ddl contains the SQL DDL generation funcs shared by the createdb and
+// migrate templates.
+package ddl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+// Funcs is a set of SQL DDL generation funcs for a driver.
+type Funcs struct {
+	Driver      string
+	Constraint  bool
+	EscCols     bool
+	EscTypes    bool
+	Engine      string
+	TrimComment bool
+}
+
+// NewFuncs creates the SQL DDL generation funcs for the context.
+func NewFuncs(ctx context.Context) *Funcs {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	return &Funcs{
+		Driver:      driver,
+		Constraint:  Constraint(ctx),
+		EscCols:     Esc(ctx, "columns"),
+		EscTypes:    Esc(ctx, "types"),
+		Engine:      Engine(ctx),
+		TrimComment: TrimComment(ctx),
+	}
+}
+
+// Coldef generates a column definition.
+func (f *Funcs) Coldef(table xo.Table, field xo.Field) string {
+	// normalize type
+	typ := f.Normalize(field.Type)
+	// add sequence definition
+	if field.IsSequence {
+		typ = f.ResolveSequence(typ, field)
+	}
+	// column def
+	def := []string{f.EscCol(field.Name), typ}
+	// add default value
+	if field.Default != "" && !field.IsSequence {
+		def = append(def, "DEFAULT", f.AlterDefault(field.Default))
+	}
+	if !field.Type.Nullable && !field.IsSequence {
+		def = append(def, "NOT NULL")
+	}
+	// add constraints
+	if fk := f.ColFKey(table, field); fk != "" {
+		def = append(def, fk)
+	}
+	return strings.Join(def, " ")
+}
+
+// AlterDefault parses and alters default column values based on the driver.
+func (f *Funcs) AlterDefault(s string) string {
+	switch f.Driver {
+	case "postgres":
+		if m := postgresDefaultCastRE.FindStringSubmatch(s); m != nil {
+			return m[1]
+		}
+	case "mysql":
+		if v := strings.ToUpper(s); v == "CURRENT_TIMESTAMP()" {
+			return "CURRENT_TIMESTAMP"
+		}
+	case "sqlite3":
+		if s != "" && !sqliteDefaultNeedsParenRE.MatchString(s) {
+			return "(" + s + ")"
+		}
+	}
+	return s
+}
+
+// postgresDefaultCastRE is the regexp to strip the datatype cast from the
+// postgres default value.
+var postgresDefaultCastRE = regexp.MustCompile(`(.*)::[a-zA-Z_ ]*(\[\])?$`)
+
+// sqliteDefaultNeedsParen is the regexp to test whether the given value is
+// correctly surrounded with parenthesis
+//
+// If it starts and ends with a parenthesis or a single or double quote, it
+// does not need to be quoted with parenthesis.
+var sqliteDefaultNeedsParenRE = regexp.MustCompile(`^([\('"].*[\)'"]|\d+)$`)
+
+// ResolveSequence resolves a sequence name.
+func (f *Funcs) ResolveSequence(typ string, field xo.Field) string {
+	switch f.Driver {
+	case "postgres":
+		switch typ {
+		case "SMALLINT":
+			return "SMALLSERIAL"
+		case "INTEGER":
+			return "SERIAL"
+		case "BIGINT":
+			return "BIGSERIAL"
+		}
+	case "mysql":
+		return typ + " AUTO_INCREMENT"
+	case "sqlite3":
+		ext := " PRIMARY KEY AUTOINCREMENT"
+		if !field.Type.Nullable {
+			ext = " NOT NULL" + ext
+		}
+		return typ + ext
+	case "sqlserver":
+		return typ + " IDENTITY(1, 1)"
+	case "oracle":
+		return typ + " GENERATED ALWAYS AS IDENTITY"
+	}
+	return ""
+}
+
+// ColFKey generates a column foreign key reference.
+func (f *Funcs) ColFKey(table xo.Table, field xo.Field) string {
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Fields) == 1 && fk.Fields[0] == field {
+			tblName, fieldName := f.EscType(fk.RefTable), fk.RefFields[0].Name
+			return fmt.Sprintf("%sREFERENCES %s (%s)", f.ConstraintName(fk.Name), tblName, fieldName)
+		}
+	}
+	return ""
+}
+
+// Viewdef generates a view definition.
+func (f *Funcs) Viewdef(view xo.Table) string {
+	def := view.Definition
+	switch f.Driver {
+	case "postgres", "mysql", "oracle":
+		def = fmt.Sprintf("CREATE VIEW %s AS\n%s", f.EscType(view.Name), view.Definition)
+	}
+	if f.TrimComment {
+		if strings.HasPrefix(def, "--") {
+			def = def[strings.Index(def, "\n")+1:]
+		}
+	}
+	return strings.TrimSuffix(def, ";")
+}
+
+// Checkdef generates a check constraint definition.
+func (f *Funcs) Checkdef(check xo.Check) string {
+	expr := check.Expr
+	// wrap the expression unless already enclosed in parentheses
+	depth, enclosed := 0, strings.HasPrefix(expr, "(")
+	for i := 0; i < len(expr) && enclosed; i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			enclosed = depth != 0 || i == len(expr)-1
+		}
+	}
+	if !enclosed {
+		expr = "(" + expr + ")"
+	}
+	return f.ConstraintName(check.Name) + "CHECK " + expr
+}
+
+// IsDriver determines if the driver is any of the allowed drivers.
+func (f *Funcs) IsDriver(allowed ...string) bool {
+	for _, d := range allowed {
+		if f.Driver == d {
+			return true
+		}
+	}
+	return false
+}
+
+// ConstraintName generates a constraint name definition.
+func (f *Funcs) ConstraintName(name string) string {
+	if f.Constraint || f.Driver == "sqlserver" || f.Driver == "oracle" {
+		return fmt.Sprintf("CONSTRAINT %s ", f.EscType(name))
+	}
+	return ""
+}
+
+// Fields generates a comma separated list of escaped field names.
+func (f *Funcs) Fields(v any) string {
+	switch x := v.(type) {
+	case []xo.Field:
+		var fs []string
+		for _, field := range x {
+			fs = append(fs, f.EscCol(field.Name))
+		}
+		return strings.Join(fs, ", ")
+	}
+	return fmt.Sprintf("[[ UNKNOWN TYPE %T ]]", v)
+}
+
+// Escape escapes s.
+func (f *Funcs) Escape(s string, esc bool) string {
+	if !esc {
+		return s
+	}
+	var start, end string
+	switch f.Driver {
+	case "postgres", "sqlite3", "oracle":
+		start, end = `"`, `"`
+	case "mysql":
+		start, end = "`", "`"
+	case "sqlserver":
+		start, end = "[", "]"
+	}
+	return start + s + end
+}
+
+// EscType escapes a type name.
+func (f *Funcs) EscType(value string) string {
+	return f.Escape(value, f.EscTypes)
+}
+
+// EscCol escapes a column name.
+func (f *Funcs) EscCol(value string) string {
+	return f.Escape(value, f.EscCols)
+}
+
+// EngineDef returns the engine definition for the database (mysql).
+func (f *Funcs) EngineDef() string {
+	if f.Driver != "mysql" || f.Engine == "" {
+		return ""
+	}
+	return fmt.Sprintf(" ENGINE=%s", f.Engine)
+}
+
+// Normalize normalizes a datatype.
+func (f *Funcs) Normalize(datatype xo.Type) string {
+	typ := f.Convert(datatype)
+	if datatype.Scale > 0 && !omitPrecision[f.Driver][typ] {
+		typ += fmt.Sprintf("(%d, %d)", datatype.Prec, datatype.Scale)
+	} else if datatype.Prec > 0 && !omitPrecision[f.Driver][typ] {
+		typ += fmt.Sprintf("(%d)", datatype.Prec)
+	}
+	if datatype.Unsigned {
+		typ += " UNSIGNED"
+	}
+	if datatype.IsArray {
+		typ += "[]"
+	}
+	return typ
+}
+
+// Convert converts a datatype to its definition.
+func (f *Funcs) Convert(datatype xo.Type) string {
+	// mysql enums
+	if f.Driver == "mysql" && datatype.Enum != nil {
+		var enums []string
+		for _, v := range datatype.Enum.Values {
+			enums = append(enums, fmt.Sprintf("'%s'", v.Name))
+		}
+		return fmt.Sprintf("ENUM(%s)", strings.Join(enums, ", "))
+	}
+	// check aliases
+	typ := datatype.Type
+	if alias, ok := typeAliases[f.Driver][typ]; ok {
+		typ = alias
+	}
+	return strings.ToUpper(typ)
+}
+
+// Literal properly escapes string literals within single quotes
+// (Used for enum values in postgres)
+func (f *Funcs) Literal(literal string) string {
+	return fmt.Sprint("'", strings.ReplaceAll(literal, "'", "''"), "'")
+}
+
+// IsEndConstraint determines if the index is defined as a table constraint.
+func (f *Funcs) IsEndConstraint(idx xo.Index) bool {
+	if f.Driver == "sqlite3" && idx.Fields[0].IsSequence {
+		return false
+	}
+	return idx.IsPrimary || idx.IsUnique
+}
+
+var typeAliases = map[string]map[string]string{
+	"postgres": {
+		"character varying":           "varchar",
+		"character":                   "char",
+		"time without time zone":      "time",
+		"timestamp without time zone": "timestamp",
+		"time with time zone":         "timetz",
+		"timestamp with time zone":    "timestamptz",
+	},
+}
+
+var omitPrecision = map[string]map[string]bool{
+	"sqlserver": {
+		"TINYINT":        true,
+		"SMALLINT":       true,
+		"INT":            true,
+		"BIGINT":         true,
+		"REAL":           true,
+		"SMALLMONEY":     true,
+		"MONEY":          true,
+		"BIT":            true,
+		"DATE":           true,
+		"TIME":           true,
+		"DATETIME":       true,
+		"DATETIME2":      true,
+		"SMALLDATETIME":  true,
+		"DATETIMEOFFSET": true,
+	},
+	"oracle": {
+		"TIMESTAMP":                      true,
+		"TIMESTAMP WITH TIME ZONE":       true,
+		"TIMESTAMP WITH LOCAL TIME ZONE": true,
+	},
+}
+
+// Comma returns a comma when i is not the last index of v.
+func Comma(i int, v any) string {
+	var l int
+	switch x := v.(type) {
+	case []xo.Field:
+		l = len(x)
+	}
+	if i+1 < l {
+		return ","
+	}
+	return ""
+}
+
+// cleanRE matches empty lines.
+var cleanRE = regexp.MustCompile(`([\.;])\n{2,}--`)
+
+// Clean removes empty lines preceding comments, and cleans the end of buf.
+func Clean(buf []byte) []byte {
+	return CleanEnd(cleanRE.ReplaceAll(buf, []byte("$1\n\n--")))
+}
+
+// CleanEnd trims the end of any spaces, ensuring it ends with exactly one
+// newline.
+func CleanEnd(buf []byte) []byte {
+	return append(bytes.TrimRightFunc(buf, unicode.IsSpace), '\n')
+}
+
+// Context keys.
+var (
+	ConstraintKey  xo.ContextKey = "constraint"
+	EscKey         xo.ContextKey = "escape"
+	EngineKey      xo.ContextKey = "engine"
+	TrimCommentKey xo.ContextKey = "trim-comment"
+)
+
+// Constraint returns constraint from the context.
+func Constraint(ctx context.Context) bool {
+	b, _ := ctx.Value(ConstraintKey).(bool)
+	return b
+}
+
+// Esc returns esc from the context.
+func Esc(ctx context.Context, esc string) bool {
+	v, _ := ctx.Value(EscKey).(string)
+	return v == "all" || v == esc
+}
+
+// Engine returns engine from the context.
+func Engine(ctx context.Context) string {
+	s, _ := ctx.Value(EngineKey).(string)
+	return s
+}
+
+// TrimComment returns trim-comment from the context.
+func TrimComment(ctx context.Context) bool {
+	b, _ := ctx.Value(TrimCommentKey).(bool)
+	return b
+}
@@ -0,0 +1,56 @@
+package ddl
+
+import (
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestCheckdef(t *testing.T) {
+	tests := []struct {
+		driver     string
+		constraint bool
+		expr       string
+		exp        string
+	}{
+		{"postgres", false, "(a > 0)", "CHECK (a > 0)"},
+		{"postgres", false, "a > 0", "CHECK (a > 0)"},
+		{"postgres", false, "(a > 0) AND (b > 0)", "CHECK ((a > 0) AND (b > 0))"},
+		{"postgres", true, "((a > 0) AND (b > 0))", "CONSTRAINT c CHECK ((a > 0) AND (b > 0))"},
+		{"sqlserver", false, "([a]>(0))", "CONSTRAINT c CHECK ([a]>(0))"},
+	}
+	for i, test := range tests {
+		f := &Funcs{Driver: test.driver, Constraint: test.constraint}
+		if s := f.Checkdef(xo.Check{Name: "c", Expr: test.expr}); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestColdef(t *testing.T) {
+	authorID := xo.Field{Name: "author_id", Type: xo.Type{Type: "integer"}}
+	table := xo.Table{
+		Name: "books",
+		ForeignKeys: []xo.ForeignKey{
+			{Name: "books_author_id_fkey", Fields: []xo.Field{authorID}, RefTable: "authors", RefFields: []xo.Field{{Name: "author_id"}}},
+		},
+	}
+	tests := []struct {
+		driver string
+		field  xo.Field
+		exp    string
+	}{
+		{"postgres", xo.Field{Name: "book_id", Type: xo.Type{Type: "integer"}, IsSequence: true}, "book_id SERIAL"},
+		{"postgres", xo.Field{Name: "title", Type: xo.Type{Type: "character varying", Prec: 100}, Default: "''::character varying"}, "title VARCHAR(100) DEFAULT '' NOT NULL"},
+		{"postgres", authorID, "author_id INTEGER NOT NULL REFERENCES authors (author_id)"},
+		{"sqlite3", xo.Field{Name: "created", Type: xo.Type{Type: "timestamp", Nullable: true}, Default: "CURRENT_TIMESTAMP"}, "created TIMESTAMP DEFAULT (CURRENT_TIMESTAMP)"},
+		{"sqlserver", xo.Field{Name: "n", Type: xo.Type{Type: "int", Prec: 10}}, "n INT NOT NULL"},
+		{"mysql", xo.Field{Name: "n", Type: xo.Type{Type: "int", Prec: 10, Unsigned: true}, IsSequence: true}, "n INT(10) UNSIGNED AUTO_INCREMENT"},
+	}
+	for i, test := range tests {
+		f := &Funcs{Driver: test.driver}
+		if s := f.Coldef(table, test.field); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
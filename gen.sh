@@ -50,6 +50,20 @@ $DBTPLBIN query $PGDB -M -B -X -F PostgresViewDrop --func-comment "$COMMENT" --s
 DROP VIEW %%id string,interpolate%%
 ENDSQL
 
+# postgres query prepare query
+COMMENT='{{ . }} prepares a query for introspection.'
+$DBTPLBIN query $PGDB -M -B -X -F PostgresQueryPrepare --func-comment "$COMMENT" --single=models.dbtpl.go -I -a -o $DEST $@ << ENDSQL
+/* %%schema string,interpolate%% */
+PREPARE %%id string,interpolate%% AS %%query []string,interpolate,join%%
+ENDSQL
+
+# postgres query deallocate query
+COMMENT='{{ . }} deallocates a query prepared for introspection.'
+$DBTPLBIN query $PGDB -M -B -X -F PostgresQueryDeallocate --func-comment "$COMMENT" --single=models.dbtpl.go -I -a -o $DEST $@ << ENDSQL
+/* %%schema string,interpolate%% */
+DEALLOCATE %%id string,interpolate%%
+ENDSQL
+
 # postgres schema query
 COMMENT='{{ . }} retrieves the schema.'
 $DBTPLBIN query $PGDB -M -B -l -F PostgresSchema --func-comment "$COMMENT" --single=models.dbtpl.go -a -o $DEST $@ << ENDSQL
@@ -57,6 +71,18 @@ SELECT
   CURRENT_SCHEMA()::varchar AS schema_name
 ENDSQL
 
+# postgres schema list query
+COMMENT='{{ . }} is a schema.'
+$DBTPLBIN query $PGDB -M -B -2 -T Schema -F PostgresSchemas --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  nspname::varchar AS schema_name
+FROM pg_namespace
+WHERE nspname NOT IN ('information_schema', 'pg_catalog', 'pg_toast')
+  AND nspname NOT LIKE 'pg_temp%'
+  AND nspname NOT LIKE 'pg_toast_temp%'
+ORDER BY nspname
+ENDSQL
+
 # postgres enum list query
 COMMENT='{{ . }} is a enum.'
 $DBTPLBIN query $PGDB -M -B -2 -T Enum -F PostgresEnums --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
@@ -90,7 +116,8 @@ SELECT
   pp.proc_type::varchar AS proc_type,
   format_type(pp.return_type, NULL)::varchar AS return_type,
   pp.return_name::varchar AS return_name,
-  p.prosrc::varchar AS proc_def
+  p.prosrc::varchar AS proc_def,
+  p.proretset::boolean AS returns_set
 FROM pg_catalog.pg_proc p
   JOIN pg_catalog.pg_namespace n ON (p.pronamespace = n.oid)
   JOIN (
@@ -122,7 +149,7 @@ WHERE p.prorettype <> 'pg_catalog.cstring'::pg_catalog.regtype
     OR p.proargtypes[0] <> 'pg_catalog.cstring'::pg_catalog.regtype)
   AND (pp.proc_type = 'function'
     OR pp.proc_type = 'procedure')
-  AND pp.param_type = 'o'
+  AND pp.param_type IN ('o', 'b', 't')
   AND n.nspname = %%schema string%%
 ENDSQL
 
@@ -130,20 +157,31 @@ ENDSQL
 COMMENT='{{ . }} is a stored procedure param.'
 $DBTPLBIN query $PGDB -M -B -2 -T ProcParam -F PostgresProcParams --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
 SELECT
-  COALESCE(pp.param_name, '')::varchar AS param_name,
-  pp.param_type::varchar AS param_type
+  COALESCE(p.proargnames[a.ord], '')::varchar AS param_name,
+  format_type(a.typ, NULL)::varchar AS param_type,
+  (CASE COALESCE(p.proargmodes[a.ord], 'i')
+    WHEN 'o' THEN 'OUT'
+    WHEN 'b' THEN 'INOUT'
+    ELSE 'IN'
+  END)::varchar AS param_mode
 FROM pg_proc p
   JOIN ONLY pg_namespace n ON p.pronamespace = n.oid
-  JOIN (
-    SELECT
-      p.oid,
-      UNNEST(p.proargnames) AS param_name,
-      format_type(UNNEST(p.proargtypes), NULL) AS param_type
-    FROM pg_proc p
-  ) AS pp ON p.oid = pp.oid
+  CROSS JOIN LATERAL unnest(COALESCE(p.proallargtypes, p.proargtypes::oid[])) WITH ORDINALITY AS a(typ, ord)
 WHERE n.nspname = %%schema string%%
   AND p.oid::varchar = %%id string%%
-  AND pp.param_type IS NOT NULL
+  AND COALESCE(p.proargmodes[a.ord], 'i') <> 't'
+ORDER BY a.ord
+ENDSQL
+
+# postgres prepared query parameter type list query
+$DBTPLBIN query $PGDB -M -B -2 -T ProcParam -F PostgresQueryParamTypes -a -o $DEST $@ << ENDSQL
+SELECT
+  ('\$' || t.ord)::varchar AS param_name,
+  format_type(t.typ, NULL)::varchar AS param_type
+FROM pg_prepared_statements p
+  CROSS JOIN LATERAL unnest(p.parameter_types) WITH ORDINALITY AS t(typ, ord)
+WHERE p.name = %%id string%%
+ORDER BY t.ord
 ENDSQL
 
 # postgres table list query
@@ -171,10 +209,72 @@ WHERE n.nspname = %%schema string%%
   END) = LOWER(%%typ string%%)
 ENDSQL
 
+# postgres schema column list query
+FIELDS='TableName string,FieldOrdinal int,ColumnName string,DataType string,NotNull bool,DefaultValue sql.NullString,IsPrimaryKey bool,Comment sql.NullString'
+COMMENT='{{ . }} is a column.'
+$DBTPLBIN query $PGDB -M -B -2 -T Column -F PostgresAllColumns -Z "$FIELDS" --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  c.relname::varchar AS table_name,
+  a.attnum::integer AS field_ordinal,
+  a.attname::varchar AS column_name,
+  format_type(a.atttypid, a.atttypmod)::varchar AS data_type,
+  a.attnotnull::boolean AS not_null,
+  COALESCE(pg_get_expr(ad.adbin, ad.adrelid), '')::varchar AS default_value,
+  COALESCE(ct.contype = 'p', false)::boolean AS is_primary_key,
+  d.description::varchar as comment
+FROM pg_attribute a
+  JOIN ONLY pg_class c ON c.oid = a.attrelid
+  JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+  LEFT JOIN pg_constraint ct ON ct.conrelid = c.oid
+    AND a.attnum = ANY(ct.conkey)
+    AND ct.contype = 'p'
+  LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid
+    AND ad.adnum = a.attnum
+  LEFT JOIN pg_description d on d.objoid = c.oid
+    AND d.objsubid = a.attnum
+WHERE a.attisdropped = false
+  AND c.relkind IN ('r', 'v')
+  AND n.nspname = %%schema string%%
+  AND (%%sys bool%% OR a.attnum > 0)
+ORDER BY c.relname, a.attnum
+ENDSQL
+
+# postgres prepared query result type list query
+$DBTPLBIN query $PGDB -M -B -2 -T Column -F PostgresQueryResultTypes -a -o $DEST $@ << ENDSQL
+SELECT
+  t.ord::integer AS field_ordinal,
+  format_type(t.typ, NULL)::varchar AS data_type
+FROM pg_prepared_statements p
+  CROSS JOIN LATERAL unnest(p.result_types) WITH ORDINALITY AS t(typ, ord)
+WHERE p.name = %%id string%%
+ORDER BY t.ord
+ENDSQL
+
+# postgres view dependency list query
+COMMENT='{{ . }} is a table column a view depends on.'
+$DBTPLBIN query $PGDB -M -B -2 -T ViewDependency -F PostgresViewDependencies --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  DISTINCT n.nspname::varchar AS schema_name,
+  c.relname::varchar AS table_name,
+  a.attname::varchar AS column_name
+FROM pg_depend d
+  JOIN pg_rewrite r ON r.oid = d.objid
+  JOIN ONLY pg_class v ON v.oid = r.ev_class
+  JOIN ONLY pg_namespace vn ON vn.oid = v.relnamespace
+  JOIN ONLY pg_class c ON c.oid = d.refobjid
+  JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+  JOIN pg_attribute a ON a.attrelid = c.oid
+    AND a.attnum = d.refobjsubid
+WHERE d.classid = 'pg_rewrite'::regclass
+  AND d.refclassid = 'pg_class'::regclass
+  AND c.oid <> v.oid
+  AND vn.nspname = %%schema string%%
+  AND v.relname = %%id string%%
+ENDSQL
+
 # postgres table column list query
 FIELDS='FieldOrdinal int,ColumnName string,DataType string,NotNull bool,DefaultValue sql.NullString,IsPrimaryKey bool,Comment sql.NullString'
-COMMENT='{{ . }} is a column.'
-$DBTPLBIN query $PGDB -M -B -2 -T Column -F PostgresTableColumns -Z "$FIELDS" --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+$DBTPLBIN query $PGDB -M -B -2 -T Column -F PostgresTableColumns -Z "$FIELDS" -a -o $DEST $@ << ENDSQL
 SELECT
   a.attnum::integer AS field_ordinal,
   a.attname::varchar AS column_name,
@@ -200,9 +300,24 @@ WHERE a.attisdropped = false
 ORDER BY a.attnum
 ENDSQL
 
-# postgres sequence list query
+# postgres schema sequence list query
 COMMENT='{{ . }} is a sequence.'
-$DBTPLBIN query $PGDB -M -B -2 -T Sequence -F PostgresTableSequences --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+$DBTPLBIN query $PGDB -M -B -2 -T Sequence -F PostgresAllSequences --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  t.relname::varchar AS table_name,
+  a.attname::varchar as column_name
+FROM pg_class s
+  JOIN pg_depend d ON d.objid = s.oid
+  JOIN pg_class t ON d.objid = s.oid AND d.refobjid = t.oid
+  JOIN pg_attribute a ON (d.refobjid, d.refobjsubid) = (a.attrelid, a.attnum)
+  JOIN pg_namespace n ON n.oid = s.relnamespace
+WHERE s.relkind = 'S'
+  AND n.nspname = %%schema string%%
+ORDER BY t.relname
+ENDSQL
+
+# postgres sequence list query
+$DBTPLBIN query $PGDB -M -B -2 -T Sequence -F PostgresTableSequences -a -o $DEST $@ << ENDSQL
 SELECT
   a.attname::varchar as column_name
 FROM pg_class s
@@ -215,12 +330,32 @@ WHERE s.relkind = 'S'
   AND t.relname = %%table string%%
 ENDSQL
 
-# postgres table foreign key list query
+# postgres table check constraint list query
+COMMENT='{{ . }} is a check constraint.'
+$DBTPLBIN query $PGDB -M -B -2 -T Check -F PostgresTableChecks --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  c.conname::varchar AS check_name,
+  COALESCE(a.attname, '')::varchar AS column_name,
+  pg_get_expr(c.conbin, c.conrelid)::varchar AS check_def
+FROM pg_constraint c
+  JOIN ONLY pg_class t ON t.oid = c.conrelid
+  JOIN ONLY pg_namespace n ON n.oid = t.relnamespace
+  LEFT JOIN pg_attribute a ON a.attrelid = c.conrelid
+    AND a.attnum = ANY(c.conkey)
+WHERE c.contype = 'c'
+  AND n.nspname = %%schema string%%
+  AND t.relname = %%table string%%
+ORDER BY c.conname, a.attnum
+ENDSQL
+
+# postgres schema foreign key list query
 COMMENT='{{ . }} is a foreign key.'
-$DBTPLBIN query $PGDB -M -B -2 -T ForeignKey -F PostgresTableForeignKeys --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+$DBTPLBIN query $PGDB -M -B -2 -T ForeignKey -F PostgresAllForeignKeys --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
 SELECT
+  tc.table_name::varchar AS table_name,
   tc.constraint_name::varchar AS foreign_key_name,
   kcu.column_name::varchar AS column_name,
+  ccu.table_schema::varchar AS ref_schema_name,
   ccu.table_name::varchar AS ref_table_name,
   ccu.column_name::varchar AS ref_column_name,
   0::integer AS key_id
@@ -232,11 +367,13 @@ FROM information_schema.table_constraints tc
     SELECT
       ROW_NUMBER() OVER (
         PARTITION BY
+          constraint_schema,
           table_schema,
           table_name,
           constraint_name
         ORDER BY row_num
       ) AS ordinal_position,
+      constraint_schema,
       table_schema,
       table_name,
       column_name,
@@ -244,6 +381,7 @@ FROM information_schema.table_constraints tc
     FROM (
       SELECT
         ROW_NUMBER() OVER (ORDER BY 1) AS row_num,
+        constraint_schema,
         table_schema,
         table_name,
         column_name,
@@ -251,16 +389,78 @@ FROM information_schema.table_constraints tc
       FROM information_schema.constraint_column_usage
     ) t
   ) AS ccu ON ccu.constraint_name = tc.constraint_name
-    AND ccu.table_schema = tc.table_schema
+    AND ccu.constraint_schema = tc.constraint_schema
+    AND ccu.ordinal_position = kcu.ordinal_position
+WHERE tc.constraint_type = 'FOREIGN KEY'
+  AND tc.table_schema = %%schema string%%
+ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position
+ENDSQL
+
+# postgres table foreign key list query
+$DBTPLBIN query $PGDB -M -B -2 -T ForeignKey -F PostgresTableForeignKeys -a -o $DEST $@ << ENDSQL
+SELECT
+  tc.constraint_name::varchar AS foreign_key_name,
+  kcu.column_name::varchar AS column_name,
+  ccu.table_schema::varchar AS ref_schema_name,
+  ccu.table_name::varchar AS ref_table_name,
+  ccu.column_name::varchar AS ref_column_name,
+  0::integer AS key_id
+FROM information_schema.table_constraints tc
+  JOIN information_schema.key_column_usage AS kcu ON tc.constraint_name = kcu.constraint_name
+    AND tc.table_schema = kcu.table_schema
+    AND tc.table_name = kcu.table_name
+  JOIN (
+    SELECT
+      ROW_NUMBER() OVER (
+        PARTITION BY
+          constraint_schema,
+          table_schema,
+          table_name,
+          constraint_name
+        ORDER BY row_num
+      ) AS ordinal_position,
+      constraint_schema,
+      table_schema,
+      table_name,
+      column_name,
+      constraint_name
+    FROM (
+      SELECT
+        ROW_NUMBER() OVER (ORDER BY 1) AS row_num,
+        constraint_schema,
+        table_schema,
+        table_name,
+        column_name,
+        constraint_name
+      FROM information_schema.constraint_column_usage
+    ) t
+  ) AS ccu ON ccu.constraint_name = tc.constraint_name
+    AND ccu.constraint_schema = tc.constraint_schema
     AND ccu.ordinal_position = kcu.ordinal_position
 WHERE tc.constraint_type = 'FOREIGN KEY'
   AND tc.table_schema = %%schema string%%
   AND tc.table_name = %%table string%%
 ENDSQL
 
-# postgres table index list query
+# postgres schema index list query
 COMMENT='{{ . }} is a index.'
-$DBTPLBIN query $PGDB -M -B -2 -T Index -F PostgresTableIndexes --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+$DBTPLBIN query $PGDB -M -B -2 -T Index -F PostgresAllIndexes --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  DISTINCT c.relname::varchar AS table_name,
+  ic.relname::varchar AS index_name,
+  i.indisunique::boolean AS is_unique,
+  i.indisprimary::boolean AS is_primary
+FROM pg_index i
+  JOIN ONLY pg_class c ON c.oid = i.indrelid
+  JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+  JOIN ONLY pg_class ic ON ic.oid = i.indexrelid
+WHERE i.indkey <> '0'
+  AND n.nspname = %%schema string%%
+ORDER BY c.relname, ic.relname
+ENDSQL
+
+# postgres table index list query
+$DBTPLBIN query $PGDB -M -B -2 -T Index -F PostgresTableIndexes -a -o $DEST $@ << ENDSQL
 SELECT
   DISTINCT ic.relname::varchar AS index_name,
   i.indisunique::boolean AS is_unique,
@@ -274,9 +474,30 @@ WHERE i.indkey <> '0'
   AND c.relname = %%table string%%
 ENDSQL
 
-# postgres index column list query
+# postgres schema index column list query
 COMMENT='{{ . }} is a index column.'
-$DBTPLBIN query $PGDB -M -B -2 -T IndexColumn -F PostgresIndexColumns --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+$DBTPLBIN query $PGDB -M -B -2 -T IndexColumn -F PostgresAllIndexColumns --type-comment "$COMMENT" -o $DEST $@ << ENDSQL
+SELECT
+  c.relname::varchar AS table_name,
+  ic.relname::varchar AS index_name,
+  k.seq_no::integer AS seq_no,
+  a.attnum::integer AS cid,
+  a.attname::varchar AS column_name
+FROM pg_index i
+  JOIN ONLY pg_class c ON c.oid = i.indrelid
+  JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+  JOIN ONLY pg_class ic ON ic.oid = i.indexrelid
+  CROSS JOIN LATERAL unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, seq_no)
+  JOIN pg_attribute a ON i.indrelid = a.attrelid
+    AND a.attnum = k.attnum
+    AND a.attisdropped = false
+WHERE i.indkey <> '0'
+  AND n.nspname = %%schema string%%
+ORDER BY c.relname, ic.relname, k.seq_no
+ENDSQL
+
+# postgres index column list query
+$DBTPLBIN query $PGDB -M -B -2 -T IndexColumn -F PostgresIndexColumns -a -o $DEST $@ << ENDSQL
 SELECT
   (row_number() over())::integer AS seq_no,
   a.attnum::integer AS cid,
@@ -327,6 +548,15 @@ SELECT
   SCHEMA() AS schema_name
 ENDSQL
 
+# mysql schema list query
+$DBTPLBIN query $MYDB -M -B -2 -T Schema -F MysqlSchemas -a -o $DEST $@ << ENDSQL
+SELECT
+  schema_name
+FROM information_schema.schemata
+WHERE schema_name NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+ORDER BY schema_name
+ENDSQL
+
 # mysql enum list query
 $DBTPLBIN query $MYDB -M -B -2 -T Enum -F MysqlEnums -a -o $DEST $@ << ENDSQL
 SELECT
@@ -354,11 +584,12 @@ SELECT
   LOWER(r.routine_type) AS proc_type,
   COALESCE(p.dtd_identifier, 'void') AS return_type,
   COALESCE(p.parameter_name, '') AS return_name,
-  r.routine_definition AS proc_def
+  r.routine_definition AS proc_def,
+  false AS returns_set
 FROM information_schema.routines r
   LEFT JOIN information_schema.parameters p ON p.specific_schema = r.routine_schema
     AND p.specific_name = r.routine_name
-    AND (p.parameter_mode = 'OUT' OR p.ordinal_position = 0)
+    AND (p.parameter_mode IN ('OUT', 'INOUT') OR p.ordinal_position = 0)
 WHERE r.routine_schema = %%schema string%%
 ORDER BY r.routine_name, p.ordinal_position
 ENDSQL
@@ -367,11 +598,12 @@ ENDSQL
 $DBTPLBIN query $MYDB -M -B -2 -T ProcParam -F MysqlProcParams -a -o $DEST $@ << ENDSQL
 SELECT
   p.parameter_name AS param_name,
-  p.dtd_identifier AS param_type
+  p.dtd_identifier AS param_type,
+  p.parameter_mode AS param_mode
 FROM information_schema.routines r
   INNER JOIN information_schema.parameters p ON p.specific_schema = r.routine_schema
     AND p.specific_name = r.routine_name
-    AND p.parameter_mode = 'IN'
+    AND p.ordinal_position > 0
 WHERE r.routine_schema = %%schema string%%
   AND r.routine_name = %%id string%%
 ORDER BY p.ordinal_position
@@ -399,6 +631,22 @@ WHERE t.table_schema = %%schema string%%
   END) = LOWER(%%typ string%%)
 ENDSQL
 
+# mysql schema column list query
+$DBTPLBIN query $MYDB -M -B -2 -T Column -F MysqlAllColumns -a -o $DEST $@ << ENDSQL
+SELECT
+  table_name,
+  ordinal_position AS field_ordinal,
+  column_name,
+  IF(data_type = 'enum', column_name, column_type) AS data_type,
+  IF(is_nullable = 'YES', false, true) AS not_null,
+  column_default AS default_value,
+  IF(column_key = 'PRI', true, false) AS is_primary_key,
+  column_comment AS comment
+FROM information_schema.columns
+WHERE table_schema = %%schema string%%
+ORDER BY table_name, ordinal_position
+ENDSQL
+
 # mysql table column list query
 $DBTPLBIN query $MYDB -M -B -2 -T Column -F MysqlTableColumns -a -o $DEST $@ << ENDSQL
 SELECT
@@ -415,6 +663,17 @@ WHERE table_schema = %%schema string%%
 ORDER BY ordinal_position
 ENDSQL
 
+# mysql schema sequence list query
+$DBTPLBIN query $MYDB -M -B -2 -T Sequence -F MysqlAllSequences -a -o $DEST $@ << ENDSQL
+SELECT
+  table_name,
+  column_name
+FROM information_schema.columns c
+WHERE c.extra = 'auto_increment'
+  AND c.table_schema = %%schema string%%
+ORDER BY table_name
+ENDSQL
+
 # mysql sequence list query
 $DBTPLBIN query $MYDB -M -B -2 -T Sequence -F MysqlTableSequences -a -o $DEST $@ << ENDSQL
 SELECT
@@ -425,11 +684,42 @@ WHERE c.extra = 'auto_increment'
   AND c.table_name = %%table string%%
 ENDSQL
 
+# mysql table check constraint list query
+$DBTPLBIN query $MYDB -M -B -2 -T Check -F MysqlTableChecks -a -o $DEST $@ << ENDSQL
+SELECT
+  c.constraint_name AS check_name,
+  '' AS column_name,
+  c.check_clause AS check_def
+FROM information_schema.table_constraints t
+  JOIN information_schema.check_constraints c ON c.constraint_schema = t.constraint_schema
+    AND c.constraint_name = t.constraint_name
+WHERE t.constraint_type = 'CHECK'
+  AND t.table_schema = %%schema string%%
+  AND t.table_name = %%table string%%
+ORDER BY c.constraint_name
+ENDSQL
+
+# mysql schema foreign key list query
+$DBTPLBIN query $MYDB -M -B -2 -T ForeignKey -F MysqlAllForeignKeys -a -o $DEST $@ << ENDSQL
+SELECT
+  table_name,
+  constraint_name AS foreign_key_name,
+  column_name AS column_name,
+  referenced_table_schema AS ref_schema_name,
+  referenced_table_name AS ref_table_name,
+  referenced_column_name AS ref_column_name
+FROM information_schema.key_column_usage
+WHERE referenced_table_name IS NOT NULL
+  AND table_schema = %%schema string%%
+ORDER BY table_name, constraint_name, ordinal_position
+ENDSQL
+
 # mysql table foreign key list query
 $DBTPLBIN query $MYDB -M -B -2 -T ForeignKey -F MysqlTableForeignKeys -a -o $DEST $@ << ENDSQL
 SELECT
   constraint_name AS foreign_key_name,
   column_name AS column_name,
+  referenced_table_schema AS ref_schema_name,
   referenced_table_name AS ref_table_name,
   referenced_column_name AS ref_column_name
 FROM information_schema.key_column_usage
@@ -438,6 +728,18 @@ WHERE referenced_table_name IS NOT NULL
   AND table_name = %%table string%%
 ENDSQL
 
+# mysql schema index list query
+$DBTPLBIN query $MYDB -M -B -2 -T Index -F MysqlAllIndexes -a -o $DEST $@ << ENDSQL
+SELECT
+  DISTINCT table_name,
+  index_name,
+  NOT non_unique AS is_unique
+FROM information_schema.statistics
+WHERE index_name <> 'PRIMARY'
+  AND index_schema = %%schema string%%
+ORDER BY table_name, index_name
+ENDSQL
+
 # mysql table index list query
 $DBTPLBIN query $MYDB -M -B -2 -T Index -F MysqlTableIndexes -a -o $DEST $@ << ENDSQL
 SELECT
@@ -449,6 +751,18 @@ WHERE index_name <> 'PRIMARY'
   AND table_name = %%table string%%
 ENDSQL
 
+# mysql schema index column list query
+$DBTPLBIN query $MYDB -M -B -2 -T IndexColumn -F MysqlAllIndexColumns -a -o $DEST $@ << ENDSQL
+SELECT
+  table_name,
+  index_name,
+  seq_in_index AS seq_no,
+  column_name
+FROM information_schema.statistics
+WHERE index_schema = %%schema string%%
+ORDER BY table_name, index_name, seq_in_index
+ENDSQL
+
 # mysql index column list query
 $DBTPLBIN query $MYDB -M -B -2 -T IndexColumn -F MysqlIndexColumns -a -o $DEST $@ << ENDSQL
 SELECT
@@ -597,6 +911,16 @@ SELECT
   SCHEMA_NAME() AS schema_name
 ENDSQL
 
+# sqlserver schema list query
+$DBTPLBIN query $MSDB -M -B -2 -T Schema -F SqlserverSchemas -a -o $DEST $@ << ENDSQL
+SELECT
+  name AS schema_name
+FROM sys.schemas
+WHERE schema_id < 16384
+  AND name NOT IN ('guest', 'INFORMATION_SCHEMA', 'sys')
+ORDER BY name
+ENDSQL
+
 # sqlserver proc list query
 $DBTPLBIN query $MSDB -M -B -2 -T Proc -F SqlserverProcs -a -o $DEST $@ << ENDSQL
 SELECT
@@ -605,6 +929,8 @@ SELECT
   (CASE o.type
     WHEN 'P' THEN 'procedure'
     WHEN 'FN' THEN 'function'
+    WHEN 'IF' THEN 'function'
+    WHEN 'TF' THEN 'function'
   END) AS proc_type,
   CASE
     WHEN p.object_id IS NOT NULL
@@ -616,26 +942,28 @@ SELECT
       THEN SUBSTRING(p.name, 2, LEN(p.name)-1)
     ELSE ''
   END AS return_name,
-  OBJECT_DEFINITION(o.object_id) AS proc_def
+  OBJECT_DEFINITION(o.object_id) AS proc_def,
+  IIF(o.type IN ('IF', 'TF'), 1, 0) AS returns_set
 FROM sys.objects o
   LEFT JOIN sys.parameters p ON o.object_id = p.object_id
     AND (p.object_id IS NULL OR p.is_output = 'true')
-WHERE o.type = 'P'
-   OR o.type = 'FN'
+WHERE o.type IN ('P', 'FN', 'IF', 'TF')
   AND SCHEMA_NAME(o.schema_id) = %%schema string%%
 ORDER BY o.object_id
 ENDSQL
 
-# sqlserver proc parameter list query
+# sqlserver proc parameter list query (OUTPUT params are INOUT, as whether the
+# proc reads an OUTPUT param is not recorded)
 $DBTPLBIN query $MSDB -M -B -2 -T ProcParam -F SqlserverProcParams -a -o $DEST $@ << ENDSQL
 SELECT
   SUBSTRING(p.name, 2, LEN(p.name)-1) AS param_name,
-  TYPE_NAME(p.user_type_id) AS param_type
+  TYPE_NAME(p.user_type_id) AS param_type,
+  IIF(p.is_output = 'true', 'INOUT', 'IN') AS param_mode
 FROM sys.objects o
   INNER JOIN sys.parameters p ON o.object_id = p.object_id
 WHERE SCHEMA_NAME(schema_id) = %%schema string%%
   AND STR(o.object_id) = %%id string%%
-  AND p.is_output = 'false'
+  AND p.parameter_id > 0
 ORDER BY p.parameter_id
 ENDSQL
 
@@ -681,12 +1009,35 @@ FROM syscolumns c
   LEFT JOIN sysobjects k ON k.xtype = 'PK'
     AND k.parent_obj = o.id
   LEFT JOIN syscomments x ON x.id = c.cdefault
-WHERE o.type IN('U', 'V')
+WHERE o.type IN('U', 'V', 'IF', 'TF')
   AND SCHEMA_NAME(o.uid) = %%schema string%%
   AND o.name = %%table string%%
 ORDER BY c.colid
 ENDSQL
 
+# sqlserver query column list query
+$DBTPLBIN query $MSDB -M -B -2 -T Column -F SqlserverQueryColumns -a -o $DEST $@ << ENDSQL
+SELECT
+  column_ordinal AS field_ordinal,
+  COALESCE(name, '') AS column_name,
+  system_type_name AS data_type,
+  IIF(is_nullable = 1, 0, 1) AS not_null
+FROM sys.dm_exec_describe_first_result_set(%%query string%%, NULL, 0)
+WHERE is_hidden = 0
+ORDER BY column_ordinal
+ENDSQL
+
+# sqlserver view dependency list query
+$DBTPLBIN query $MSDB -M -B -2 -T ViewDependency -F SqlserverViewDependencies -a -o $DEST $@ << ENDSQL
+SELECT
+  table_schema AS schema_name,
+  table_name AS table_name,
+  column_name AS column_name
+FROM information_schema.view_column_usage
+WHERE view_schema = %%schema string%%
+  AND view_name = %%id string%%
+ENDSQL
+
 # sqlserver sequence list query
 $DBTPLBIN query $MSDB -M -B -2 -T Sequence -F SqlserverTableSequences -a -o $DEST $@ << ENDSQL
 SELECT
@@ -699,6 +1050,22 @@ WHERE c.is_identity = 1
   AND o.name = %%table string%%
 ENDSQL
 
+# sqlserver table check constraint list query
+$DBTPLBIN query $MSDB -M -B -2 -T Check -F SqlserverTableChecks -a -o $DEST $@ << ENDSQL
+SELECT
+  k.name AS check_name,
+  COALESCE(c.name, '') AS column_name,
+  k.definition AS check_def
+FROM sys.check_constraints k
+  JOIN sys.objects o ON o.object_id = k.parent_object_id
+  LEFT JOIN sys.columns c ON c.object_id = k.parent_object_id
+    AND c.column_id = k.parent_column_id
+WHERE o.type = 'U'
+  AND SCHEMA_NAME(o.schema_id) = %%schema string%%
+  AND o.name = %%table string%%
+ORDER BY k.name
+ENDSQL
+
 # sqlserver table foreign key list query
 $DBTPLBIN query $MSDB -M -B -2 -T ForeignKey -F SqlserverTableForeignKeys -a -o $DEST $@ << ENDSQL
 SELECT
@@ -796,10 +1163,11 @@ SELECT
   LOWER(CASE
     WHEN a.argument_name IS NULL THEN '-'
     ELSE a.argument_name END) AS return_name,
-  s.src AS proc_def
+  s.src AS proc_def,
+  '0' AS returns_set
 FROM all_objects o
   LEFT JOIN sys.all_arguments a ON a.object_id = o.object_id
-    AND a.in_out = 'OUT'
+    AND a.in_out IN ('OUT', 'IN/OUT')
   JOIN (
     SELECT
       s.owner,
@@ -822,10 +1190,11 @@ SELECT
     WHEN 'CHAR' THEN 'CHAR(' || a.data_length || ')'
     WHEN 'VARCHAR2' THEN 'VARCHAR2(' || a.data_length || ')'
     WHEN 'NUMBER' THEN 'NUMBER(' || NVL(a.data_precision, 0) || ',' || NVL(a.data_scale, 0) || ')'
-    ELSE a.data_type END) AS param_type
+    ELSE a.data_type END) AS param_type,
+  REPLACE(a.in_out, '/', '') AS param_mode
 FROM all_objects o
   JOIN sys.all_arguments a ON a.object_id = o.object_id
-    AND a.in_out = 'IN'
+    AND a.position > 0
 WHERE o.object_type IN ('FUNCTION','PROCEDURE')
   AND o.owner = UPPER(%%schema string%%)
   AND CAST(o.object_id AS NVARCHAR2(255)) = UPPER(%%id string%%)
@@ -896,6 +1265,22 @@ WHERE c.identity_column='YES'
   AND c.table_name  = UPPER(%%table string%%)
 ENDSQL
 
+# oracle table check constraint list query
+$DBTPLBIN query $ORDB -M -B -2 -T Check -F OracleTableChecks -a -o $DEST $@ << ENDSQL
+SELECT
+  LOWER(c.constraint_name) AS check_name,
+  LOWER(k.column_name) AS column_name,
+  c.search_condition_vc AS check_def
+FROM all_constraints c
+  JOIN all_cons_columns k ON k.owner = c.owner
+    AND k.constraint_name = c.constraint_name
+WHERE c.constraint_type = 'C'
+  AND c.search_condition_vc NOT LIKE '"%" IS NOT NULL'
+  AND c.owner = UPPER(%%schema string%%)
+  AND c.table_name = UPPER(%%table string%%)
+ORDER BY c.constraint_name, k.position
+ENDSQL
+
 # oracle table foreign key list query
 $DBTPLBIN query $ORDB -M -B -2 -T ForeignKey -F OracleTableForeignKeys -a -o $DEST $@ << ENDSQL
 SELECT
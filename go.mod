@@ -17,6 +17,7 @@ require (
 	github.com/xo/dburl v0.23.8
 	github.com/xo/ox v0.0.0-20250529002803-30865a99877b
 	github.com/yookoala/realpath v1.0.0
+	golang.org/x/sync v0.15.0
 	golang.org/x/tools v0.34.0
 	mvdan.cc/gofumpt v0.8.0
 )
@@ -37,6 +38,5 @@ require (
 	github.com/spf13/cast v1.9.2 // indirect
 	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/mod v0.25.0 // indirect
-	golang.org/x/sync v0.15.0 // indirect
 	golang.org/x/text v0.26.0 // indirect
 )
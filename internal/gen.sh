@@ -2,6 +2,7 @@
 
 set -ex
 
+yaegi extract github.com/xo/dbtpl/ddl
 yaegi extract github.com/xo/dbtpl/loader
 yaegi extract github.com/xo/dbtpl/types
 yaegi extract os/exec
@@ -10,40 +10,63 @@ import (
 func init() {
 	Symbols["github.com/xo/dbtpl/loader/loader"] = map[string]reflect.Value{
 		// function, constant and variable definitions
-		"EnumValues":           reflect.ValueOf(loader.EnumValues),
-		"Enums":                reflect.ValueOf(loader.Enums),
-		"Flags":                reflect.ValueOf(loader.Flags),
-		"IndexColumns":         reflect.ValueOf(loader.IndexColumns),
-		"MysqlEnumValues":      reflect.ValueOf(loader.MysqlEnumValues),
-		"MysqlGoType":          reflect.ValueOf(loader.MysqlGoType),
-		"NthParam":             reflect.ValueOf(loader.NthParam),
-		"OracleGoType":         reflect.ValueOf(loader.OracleGoType),
-		"PQPostgresGoType":     reflect.ValueOf(loader.PQPostgresGoType),
-		"PostgresFlags":        reflect.ValueOf(loader.PostgresFlags),
-		"PostgresGoType":       reflect.ValueOf(loader.PostgresGoType),
-		"PostgresIndexColumns": reflect.ValueOf(loader.PostgresIndexColumns),
-		"PostgresTableColumns": reflect.ValueOf(loader.PostgresTableColumns),
-		"PostgresViewStrip":    reflect.ValueOf(loader.PostgresViewStrip),
-		"ProcParams":           reflect.ValueOf(loader.ProcParams),
-		"Procs":                reflect.ValueOf(loader.Procs),
-		"Register":             reflect.ValueOf(loader.Register),
-		"Schema":               reflect.ValueOf(loader.Schema),
-		"Sqlite3GoType":        reflect.ValueOf(loader.Sqlite3GoType),
-		"SqlserverGoType":      reflect.ValueOf(loader.SqlserverGoType),
-		"SqlserverViewStrip":   reflect.ValueOf(loader.SqlserverViewStrip),
-		"StdlibPostgresGoType": reflect.ValueOf(loader.StdlibPostgresGoType),
-		"TableColumns":         reflect.ValueOf(loader.TableColumns),
-		"TableForeignKeys":     reflect.ValueOf(loader.TableForeignKeys),
-		"TableIndexes":         reflect.ValueOf(loader.TableIndexes),
-		"TableSequences":       reflect.ValueOf(loader.TableSequences),
-		"Tables":               reflect.ValueOf(loader.Tables),
-		"ViewCreate":           reflect.ValueOf(loader.ViewCreate),
-		"ViewDrop":             reflect.ValueOf(loader.ViewDrop),
-		"ViewSchema":           reflect.ValueOf(loader.ViewSchema),
-		"ViewStrip":            reflect.ValueOf(loader.ViewStrip),
-		"ViewTruncate":         reflect.ValueOf(loader.ViewTruncate),
+		"AllColumns":            reflect.ValueOf(loader.AllColumns),
+		"AllForeignKeys":        reflect.ValueOf(loader.AllForeignKeys),
+		"AllIndexColumns":       reflect.ValueOf(loader.AllIndexColumns),
+		"AllIndexes":            reflect.ValueOf(loader.AllIndexes),
+		"AllSequences":          reflect.ValueOf(loader.AllSequences),
+		"DDLKey":                reflect.ValueOf(loader.DDLKey),
+		"EnumValues":            reflect.ValueOf(loader.EnumValues),
+		"Enums":                 reflect.ValueOf(loader.Enums),
+		"Flags":                 reflect.ValueOf(loader.Flags),
+		"IndexColumns":          reflect.ValueOf(loader.IndexColumns),
+		"MysqlEnumValues":       reflect.ValueOf(loader.MysqlEnumValues),
+		"MysqlGoType":           reflect.ValueOf(loader.MysqlGoType),
+		"MysqlQueryColumns":     reflect.ValueOf(loader.MysqlQueryColumns),
+		"MysqlTableChecks":      reflect.ValueOf(loader.MysqlTableChecks),
+		"NewDDL":                reflect.ValueOf(loader.NewDDL),
+		"NthParam":              reflect.ValueOf(loader.NthParam),
+		"OracleGoType":          reflect.ValueOf(loader.OracleGoType),
+		"OracleQueryColumns":    reflect.ValueOf(loader.OracleQueryColumns),
+		"PQPostgresGoType":      reflect.ValueOf(loader.PQPostgresGoType),
+		"PostgresAllColumns":    reflect.ValueOf(loader.PostgresAllColumns),
+		"PostgresFlags":         reflect.ValueOf(loader.PostgresFlags),
+		"PostgresGoType":        reflect.ValueOf(loader.PostgresGoType),
+		"PostgresIndexColumns":  reflect.ValueOf(loader.PostgresIndexColumns),
+		"PostgresQueryColumns":  reflect.ValueOf(loader.PostgresQueryColumns),
+		"PostgresQueryParams":   reflect.ValueOf(loader.PostgresQueryParams),
+		"PostgresTableColumns":  reflect.ValueOf(loader.PostgresTableColumns),
+		"PostgresViewStrip":     reflect.ValueOf(loader.PostgresViewStrip),
+		"ProcParams":            reflect.ValueOf(loader.ProcParams),
+		"Procs":                 reflect.ValueOf(loader.Procs),
+		"QueryColumns":          reflect.ValueOf(loader.QueryColumns),
+		"QueryParams":           reflect.ValueOf(loader.QueryParams),
+		"Register":              reflect.ValueOf(loader.Register),
+		"Schema":                reflect.ValueOf(loader.Schema),
+		"Schemas":               reflect.ValueOf(loader.Schemas),
+		"Sqlite3GoType":         reflect.ValueOf(loader.Sqlite3GoType),
+		"Sqlite3QueryColumns":   reflect.ValueOf(loader.Sqlite3QueryColumns),
+		"Sqlite3TableChecks":    reflect.ValueOf(loader.Sqlite3TableChecks),
+		"SqlserverGoType":       reflect.ValueOf(loader.SqlserverGoType),
+		"SqlserverQueryColumns": reflect.ValueOf(loader.SqlserverQueryColumns),
+		"SqlserverQueryParams":  reflect.ValueOf(loader.SqlserverQueryParams),
+		"SqlserverViewStrip":    reflect.ValueOf(loader.SqlserverViewStrip),
+		"StdlibPostgresGoType":  reflect.ValueOf(loader.StdlibPostgresGoType),
+		"TableChecks":           reflect.ValueOf(loader.TableChecks),
+		"TableColumns":          reflect.ValueOf(loader.TableColumns),
+		"TableForeignKeys":      reflect.ValueOf(loader.TableForeignKeys),
+		"TableIndexes":          reflect.ValueOf(loader.TableIndexes),
+		"TableSequences":        reflect.ValueOf(loader.TableSequences),
+		"Tables":                reflect.ValueOf(loader.Tables),
+		"ViewCreate":            reflect.ValueOf(loader.ViewCreate),
+		"ViewDependencies":      reflect.ValueOf(loader.ViewDependencies),
+		"ViewDrop":              reflect.ValueOf(loader.ViewDrop),
+		"ViewSchema":            reflect.ValueOf(loader.ViewSchema),
+		"ViewStrip":             reflect.ValueOf(loader.ViewStrip),
+		"ViewTruncate":          reflect.ValueOf(loader.ViewTruncate),
 
 		// type definitions
+		"DDL":    reflect.ValueOf((*loader.DDL)(nil)),
 		"Loader": reflect.ValueOf((*loader.Loader)(nil)),
 	}
 }
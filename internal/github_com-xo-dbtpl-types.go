@@ -17,12 +17,15 @@ func init() {
 		"DriverKey":      reflect.ValueOf(types.DriverKey),
 		"Out":            reflect.ValueOf(types.Out),
 		"OutKey":         reflect.ValueOf(types.OutKey),
+		"ParseCheck":     reflect.ValueOf(types.ParseCheck),
 		"ParseType":      reflect.ValueOf(types.ParseType),
+		"ReadSnapshot":   reflect.ValueOf(types.ReadSnapshot),
 		"SchemaKey":      reflect.ValueOf(types.SchemaKey),
 		"Single":         reflect.ValueOf(types.Single),
 		"SingleKey":      reflect.ValueOf(types.SingleKey),
 
 		// type definitions
+		"Check":        reflect.ValueOf((*types.Check)(nil)),
 		"ContextKey":   reflect.ValueOf((*types.ContextKey)(nil)),
 		"Enum":         reflect.ValueOf((*types.Enum)(nil)),
 		"Field":        reflect.ValueOf((*types.Field)(nil)),
@@ -32,6 +35,8 @@ func init() {
 		"Index":        reflect.ValueOf((*types.Index)(nil)),
 		"Proc":         reflect.ValueOf((*types.Proc)(nil)),
 		"Query":        reflect.ValueOf((*types.Query)(nil)),
+		"Result":       reflect.ValueOf((*types.Result)(nil)),
+		"Rule":         reflect.ValueOf((*types.Rule)(nil)),
 		"Schema":       reflect.ValueOf((*types.Schema)(nil)),
 		"Set":          reflect.ValueOf((*types.Set)(nil)),
 		"Table":        reflect.ValueOf((*types.Table)(nil)),
@@ -0,0 +1,500 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xo/dbtpl/models"
+	"github.com/xo/dbtpl/sqllex"
+	xo "github.com/xo/dbtpl/types"
+)
+
+func init() {
+	Register("ddl", Loader{
+		Schemas:          ddlSchemas,
+		Enums:            ddlEnums,
+		EnumValues:       ddlEnumValues,
+		Tables:           ddlTables,
+		TableColumns:     ddlTableColumns,
+		TableSequences:   ddlTableSequences,
+		TableForeignKeys: ddlTableForeignKeys,
+		TableIndexes:     ddlTableIndexes,
+		IndexColumns:     ddlIndexColumns,
+		TableChecks:      ddlTableChecks,
+	})
+}
+
+// DDLKey is the ddl context key.
+//
+// When a [DDL] is present in the context, it is used in place of the database
+// loader for the driver.
+const DDLKey xo.ContextKey = "ddl"
+
+// DDL is a schema parsed from SQL DDL statements (CREATE TABLE, CREATE INDEX,
+// ALTER TABLE, ...), allowing schemas to be loaded without a database
+// connection.
+type DDL struct {
+	dialect string
+	schema  string
+	enums   []*ddlEnum
+	tables  []*ddlTable
+}
+
+// NewDDL creates a new ddl schema for the dialect (postgres, mysql, or
+// sqlite3).
+func NewDDL(dialect string) (*DDL, error) {
+	switch strings.ToLower(dialect) {
+	case "postgres", "postgresql", "pg", "pgsql":
+		dialect = "postgres"
+	case "mysql", "my", "mariadb", "maria":
+		dialect = "mysql"
+	case "sqlite3", "sqlite", "sq":
+		dialect = "sqlite3"
+	default:
+		return nil, fmt.Errorf("unsupported ddl dialect %q", dialect)
+	}
+	return &DDL{
+		dialect: dialect,
+	}, nil
+}
+
+// Driver returns the driver name for the ddl dialect.
+func (d *DDL) Driver() string {
+	return d.dialect
+}
+
+// SetSchema sets the schema of objects not qualified with a schema name (ie,
+// the postgres search path).
+func (d *DDL) SetSchema(schema string) {
+	d.schema = schema
+}
+
+// Parse parses the DDL statements in src, adding them to the schema.
+// Statements not affecting the schema's tables, views, indexes, foreign keys,
+// or enums are ignored.
+func (d *DDL) Parse(src string) error {
+	stmts, err := sqllex.Split(d.dialect, src)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		p := &ddlParser{
+			dialect: d.dialect,
+			src:     src,
+			toks:    stmt,
+		}
+		if err := d.parse(p); err != nil {
+			return fmt.Errorf("line %d: %w", strings.Count(src[:stmt[0].Pos], "\n")+1, err)
+		}
+	}
+	return nil
+}
+
+// ddlEnum is a ddl enum.
+type ddlEnum struct {
+	schema string
+	name   string
+	values []string
+}
+
+// ddlTable is a ddl table or view.
+type ddlTable struct {
+	typ     string
+	schema  string
+	name    string
+	def     string
+	columns []*ddlColumn
+	indexes []*ddlIndex
+	fkeys   []*ddlForeignKey
+	checks  []*ddlCheck
+	// query is the view query, and names are the view column names.
+	query []sqllex.Token
+	names []string
+}
+
+// ddlColumn is a ddl column.
+type ddlColumn struct {
+	name     string
+	typ      string
+	notNull  bool
+	def      sql.NullString
+	primary  bool
+	sequence bool
+	comment  string
+}
+
+// ddlIndex is a ddl index.
+type ddlIndex struct {
+	name    string
+	unique  bool
+	primary bool
+	columns []string
+}
+
+// ddlForeignKey is a ddl foreign key.
+type ddlForeignKey struct {
+	name       string
+	columns    []string
+	refSchema  string
+	refTable   string
+	refColumns []string
+}
+
+// ddlCheck is a ddl check constraint.
+type ddlCheck struct {
+	name string
+	expr string
+	// columns are the columns of a column check constraint, and idents are
+	// the identifiers of the expression.
+	columns []string
+	idents  []string
+}
+
+// ddlGet returns the ddl from the context.
+func ddlGet(ctx context.Context) (*DDL, error) {
+	d, ok := ctx.Value(DDLKey).(*DDL)
+	if !ok {
+		return nil, errors.New("no ddl in context")
+	}
+	return d, nil
+}
+
+// ddlTableGet returns the ddl and named table from the context.
+func ddlTableGet(ctx context.Context, schema, table string) (*DDL, *ddlTable, error) {
+	d, err := ddlGet(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range d.tables {
+		if t.name == table && d.inSchema(t.schema, schema) {
+			return d, t, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("table %q not defined", table)
+}
+
+// inSchema determines if an object in the objSchema is in the schema. Only
+// postgres schema qualifiers are considered, and unqualified objects are in
+// the default schema.
+func (d *DDL) inSchema(objSchema, schema string) bool {
+	switch {
+	case d.dialect != "postgres", objSchema == schema:
+		return true
+	case objSchema == "":
+		return d.schema == "" || d.schema == schema
+	}
+	return false
+}
+
+// ddlSchemas returns the default schema and the schemas qualifying objects.
+func ddlSchemas(ctx context.Context, _ models.DB) ([]*models.Schema, error) {
+	d, err := ddlGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if d.schema != "" {
+		names = append(names, d.schema)
+	}
+	if d.dialect == "postgres" {
+		for _, e := range d.enums {
+			names = append(names, e.schema)
+		}
+		for _, t := range d.tables {
+			names = append(names, t.schema)
+		}
+	}
+	slices.Sort(names)
+	var res []*models.Schema
+	for _, name := range slices.Compact(names) {
+		if name != "" {
+			res = append(res, &models.Schema{
+				SchemaName: name,
+			})
+		}
+	}
+	return res, nil
+}
+
+// ddlEnums returns the enums.
+func ddlEnums(ctx context.Context, _ models.DB, schema string) ([]*models.Enum, error) {
+	d, err := ddlGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var res []*models.Enum
+	for _, e := range d.enums {
+		if d.inSchema(e.schema, schema) {
+			res = append(res, &models.Enum{
+				EnumName: e.name,
+			})
+		}
+	}
+	return res, nil
+}
+
+// ddlEnumValues returns the enum values.
+func ddlEnumValues(ctx context.Context, _ models.DB, schema, enum string) ([]*models.EnumValue, error) {
+	d, err := ddlGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range d.enums {
+		if e.name != enum || !d.inSchema(e.schema, schema) {
+			continue
+		}
+		var res []*models.EnumValue
+		for i, v := range e.values {
+			res = append(res, &models.EnumValue{
+				EnumValue:  v,
+				ConstValue: i + 1,
+			})
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("enum %q not defined", enum)
+}
+
+// ddlTables returns the tables or views.
+func ddlTables(ctx context.Context, _ models.DB, schema, typ string) ([]*models.Table, error) {
+	d, err := ddlGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var res []*models.Table
+	for _, t := range d.tables {
+		if t.typ == typ && d.inSchema(t.schema, schema) {
+			res = append(res, &models.Table{
+				Type:      t.typ,
+				TableName: t.name,
+				ViewDef:   t.def,
+			})
+		}
+	}
+	return res, nil
+}
+
+// ddlTableColumns returns the table columns. View columns are determined from
+// the view's query.
+func ddlTableColumns(ctx context.Context, _ models.DB, schema, table string) ([]*models.Column, error) {
+	d, t, err := ddlTableGet(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := d.columns(t, 0)
+	if err != nil {
+		return nil, err
+	}
+	var res []*models.Column
+	for i, c := range columns {
+		res = append(res, &models.Column{
+			FieldOrdinal: i + 1,
+			ColumnName:   c.name,
+			DataType:     c.typ,
+			NotNull:      c.notNull,
+			DefaultValue: c.def,
+			IsPrimaryKey: c.primary,
+			Comment:      sql.NullString{String: c.comment, Valid: c.comment != ""},
+		})
+	}
+	return res, nil
+}
+
+// ddlTableSequences returns the table sequences.
+func ddlTableSequences(ctx context.Context, _ models.DB, schema, table string) ([]*models.Sequence, error) {
+	_, t, err := ddlTableGet(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	var res []*models.Sequence
+	for _, c := range t.columns {
+		if c.sequence {
+			res = append(res, &models.Sequence{
+				ColumnName: c.name,
+			})
+		}
+	}
+	return res, nil
+}
+
+// ddlTableForeignKeys returns the table foreign keys. Foreign keys not
+// specifying the referenced columns reference the primary key of the
+// referenced table.
+func ddlTableForeignKeys(ctx context.Context, _ models.DB, schema, table string) ([]*models.ForeignKey, error) {
+	d, t, err := ddlTableGet(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	var res []*models.ForeignKey
+	for i, fkey := range t.fkeys {
+		// unqualified references are to the default schema
+		refSchema := fkey.refSchema
+		if d.dialect != "postgres" {
+			refSchema = ""
+		} else if refSchema == "" {
+			refSchema = d.schema
+		}
+		refColumns := fkey.refColumns
+		if len(refColumns) == 0 {
+			if ref := d.table(refSchema, fkey.refTable); ref != nil {
+				refColumns = ref.primaryKeys()
+			}
+		}
+		if len(refColumns) != len(fkey.columns) {
+			return nil, fmt.Errorf("table %q foreign key %q: could not determine referenced columns", table, fkey.name)
+		}
+		for j, c := range fkey.columns {
+			res = append(res, &models.ForeignKey{
+				ForeignKeyName: fkey.name,
+				ColumnName:     c,
+				RefSchemaName:  refSchema,
+				RefTableName:   fkey.refTable,
+				RefColumnName:  refColumns[j],
+				KeyID:          i + 1,
+			})
+		}
+	}
+	return res, nil
+}
+
+// ddlTableIndexes returns the table indexes.
+//
+// As with mysql, an index is created for foreign keys not otherwise having an
+// index.
+func ddlTableIndexes(ctx context.Context, _ models.DB, schema, table string) ([]*models.Index, error) {
+	d, t, err := ddlTableGet(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	var res []*models.Index
+	for _, index := range d.indexes(t) {
+		res = append(res, &models.Index{
+			IndexName: index.name,
+			IsUnique:  index.unique,
+			IsPrimary: index.primary,
+		})
+	}
+	return res, nil
+}
+
+// ddlIndexColumns returns the index columns.
+func ddlIndexColumns(ctx context.Context, _ models.DB, schema, table, index string) ([]*models.IndexColumn, error) {
+	d, t, err := ddlTableGet(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range d.indexes(t) {
+		if i.name != index {
+			continue
+		}
+		var res []*models.IndexColumn
+		for j, name := range i.columns {
+			res = append(res, &models.IndexColumn{
+				SeqNo:      j + 1,
+				Cid:        slices.IndexFunc(t.columns, func(c *ddlColumn) bool { return c.name == name }),
+				ColumnName: name,
+			})
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("table %q index %q not defined", table, index)
+}
+
+// ddlTableChecks returns the table check constraints.
+func ddlTableChecks(ctx context.Context, _ models.DB, schema, table string) ([]*models.Check, error) {
+	d, t, err := ddlTableGet(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	return d.checks(t), nil
+}
+
+// checks returns the check constraints for the table, with a row for each
+// column of the check. Columns of table check constraints are the table
+// columns used in the expression, and unnamed checks are named as the
+// database would.
+func (d *DDL) checks(t *ddlTable) []*models.Check {
+	var res []*models.Check
+	names := make(map[string]bool)
+	for i, check := range t.checks {
+		columns := check.columns
+		if columns == nil {
+			for _, ident := range check.idents {
+				if t.column(ident) != nil && !slices.Contains(columns, ident) {
+					columns = append(columns, ident)
+				}
+			}
+		}
+		name := check.name
+		switch {
+		case name != "":
+		case d.dialect == "mysql":
+			name = fmt.Sprintf("%s_chk_%d", t.name, i+1)
+		default:
+			base := t.name + "_check"
+			if len(columns) != 0 {
+				base = t.name + "_" + columns[0] + "_check"
+			}
+			name = base
+			for n := 1; names[name]; n++ {
+				name = fmt.Sprintf("%s%d", base, n)
+			}
+		}
+		names[name] = true
+		if len(columns) == 0 {
+			columns = []string{""}
+		}
+		for _, column := range columns {
+			res = append(res, &models.Check{
+				CheckName:  name,
+				ColumnName: column,
+				CheckDef:   check.expr,
+			})
+		}
+	}
+	return res
+}
+
+// indexes returns the indexes for the table, adding the implicit mysql
+// foreign key indexes.
+func (d *DDL) indexes(t *ddlTable) []*ddlIndex {
+	if d.dialect != "mysql" {
+		return t.indexes
+	}
+	indexes := slices.Clone(t.indexes)
+	// the primary key is not included in the indexes for mysql
+	pkeys := t.primaryKeys()
+	for _, fkey := range t.fkeys {
+		found := hasPrefix(pkeys, fkey.columns)
+		for _, index := range indexes {
+			found = found || hasPrefix(index.columns, fkey.columns)
+		}
+		if !found {
+			indexes = append(indexes, &ddlIndex{
+				name:    fkey.name,
+				columns: fkey.columns,
+			})
+		}
+	}
+	return indexes
+}
+
+// primaryKeys returns the primary key column names.
+func (t *ddlTable) primaryKeys() []string {
+	var pkeys []string
+	for _, c := range t.columns {
+		if c.primary {
+			pkeys = append(pkeys, c.name)
+		}
+	}
+	return pkeys
+}
+
+// hasPrefix determines if a starts with the elements of b.
+func hasPrefix(a, b []string) bool {
+	return len(b) <= len(a) && slices.Equal(a[:len(b)], b)
+}
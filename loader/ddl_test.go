@@ -0,0 +1,277 @@
+package loader
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestDDLPostgres(t *testing.T) {
+	const src = `CREATE TYPE public.book_type AS ENUM ('FICTION', 'NONFICTION');
+ALTER TYPE book_type ADD VALUE 'POETRY' AFTER 'FICTION';
+CREATE FUNCTION f() RETURNS text AS $$ BEGIN RETURN 'a;b'; END; $$ LANGUAGE plpgsql;
+CREATE TABLE authors (
+  author_id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL -- comment
+);
+CREATE TABLE books (
+  book_id integer NOT NULL,
+  author_id int REFERENCES authors ON DELETE CASCADE,
+  isbn text NOT NULL UNIQUE,
+  book_type book_type NOT NULL DEFAULT 'FICTION',
+  tags text[],
+  pages int CHECK (pages > 0),
+  created timestamptz,
+  CONSTRAINT books_dates CHECK (created > '2000-01-01' OR pages IS NULL)
+);
+ALTER TABLE ONLY books ADD CONSTRAINT books_pkey PRIMARY KEY (book_id);
+CREATE INDEX ON books (author_id, created);
+CREATE VIEW book_authors AS SELECT b.book_id, a.name AS author, count(*) FROM books b JOIN authors a USING (author_id);`
+	ctx := ddlContext(t, "pg", src)
+	enums, err := ddlEnumValues(ctx, nil, "public", "book_type")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var values []string
+	for _, e := range enums {
+		values = append(values, e.EnumValue)
+	}
+	if exp := []string{"FICTION", "POETRY", "NONFICTION"}; !reflect.DeepEqual(values, exp) {
+		t.Errorf("expected enum values %v, got: %v", exp, values)
+	}
+	checkColumns(t, ctx, "authors", []string{"author_id integer pk not null", "name character varying(255) not null"})
+	checkColumns(t, ctx, "books", []string{
+		"book_id integer pk not null",
+		"author_id integer",
+		"isbn text not null",
+		"book_type book_type not null",
+		"tags text[]",
+		"pages integer",
+		"created timestamp with time zone",
+	})
+	checkChecks(t, ctx, "books", []string{"books_pages_check pages", "books_dates created", "books_dates pages"})
+	checkColumns(t, ctx, "book_authors", []string{"book_id integer", "author character varying(255)", "count bigint"})
+	checkIndexes(t, ctx, "books", []string{"books_isbn_key unique", "books_pkey primary", "books_author_id_created_idx"})
+	fkeys, err := ddlTableForeignKeys(ctx, nil, "public", "books")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(fkeys) != 1 || fkeys[0].ForeignKeyName != "books_author_id_fkey" || fkeys[0].RefTableName != "authors" || fkeys[0].RefColumnName != "author_id":
+		t.Errorf("unexpected foreign keys: %v", fkeys)
+	}
+	seqs, err := ddlTableSequences(ctx, nil, "public", "authors")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(seqs) != 1 || seqs[0].ColumnName != "author_id":
+		t.Errorf("expected author_id sequence, got: %v", seqs)
+	}
+}
+
+func TestDDLMysql(t *testing.T) {
+	const src = "/*!40101 SET NAMES utf8 */;\n" +
+		"CREATE TABLE `authors` (\n" +
+		"  `author_id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `name` varchar(255) CHARACTER SET utf8mb4 NOT NULL DEFAULT '' COMMENT 'author name',\n" +
+		"  `active` boolean,\n" +
+		"  PRIMARY KEY (`author_id`),\n" +
+		"  KEY `authors_name_idx` (`name`(10))\n" +
+		") ENGINE=InnoDB;\n" +
+		"CREATE TABLE books (\n" +
+		"  book_id INTEGER NOT NULL PRIMARY KEY,\n" +
+		"  author_id INT(10) UNSIGNED NOT NULL,\n" +
+		"  book_type ENUM('FICTION', 'NONFICTION') NOT NULL,\n" +
+		"  FOREIGN KEY (author_id) REFERENCES authors (author_id),\n" +
+		"  CHECK (book_id > 0)\n" +
+		");\n" +
+		"DELIMITER ;;\n" +
+		"CREATE TRIGGER t BEFORE INSERT ON books FOR EACH ROW BEGIN SET NEW.book_type = 'FICTION'; END ;;\n" +
+		"DELIMITER ;\n"
+	ctx := ddlContext(t, "mysql", src)
+	checkColumns(t, ctx, "authors", []string{
+		"author_id int(10) unsigned pk not null",
+		"name varchar(255) not null",
+		"active tinyint(1)",
+	})
+	checkColumns(t, ctx, "books", []string{
+		"book_id int pk not null",
+		"author_id int(10) unsigned not null",
+		"book_type book_type not null",
+	})
+	checkIndexes(t, ctx, "authors", []string{"authors_name_idx"})
+	checkIndexes(t, ctx, "books", []string{"books_ibfk_1"})
+	checkChecks(t, ctx, "books", []string{"books_chk_1 book_id"})
+	enums, err := ddlEnums(ctx, nil, "my")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(enums) != 1 || enums[0].EnumName != "book_type":
+		t.Errorf("expected book_type enum, got: %v", enums)
+	}
+}
+
+func TestDDLSqlite3(t *testing.T) {
+	const src = `CREATE TABLE authors (author_id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL);
+CREATE TABLE [book tags] (book_id integer, tag text, PRIMARY KEY (book_id, tag), UNIQUE (tag));
+CREATE TRIGGER t AFTER INSERT ON authors BEGIN UPDATE authors SET name = 'a'; END;
+CREATE VIEW v AS SELECT * FROM authors;
+DROP TABLE IF EXISTS missing;`
+	ctx := ddlContext(t, "sqlite3", src)
+	checkColumns(t, ctx, "authors", []string{"author_id integer pk not null", "name text not null"})
+	checkColumns(t, ctx, "book tags", []string{"book_id integer pk", "tag text pk"})
+	checkColumns(t, ctx, "v", []string{"author_id integer", "name text"})
+	checkIndexes(t, ctx, "authors", nil)
+	checkIndexes(t, ctx, "book tags", []string{"sqlite_autoindex_book tags_1 primary", "sqlite_autoindex_book tags_2 unique"})
+}
+
+func TestDDLSchemas(t *testing.T) {
+	const src = `CREATE TABLE users (id serial PRIMARY KEY);
+CREATE TABLE auth.users (id serial PRIMARY KEY);
+CREATE TABLE billing.invoices (
+  id serial PRIMARY KEY,
+  user_id integer REFERENCES users,
+  auth_user_id integer REFERENCES auth.users (id)
+);`
+	ctx := ddlContext(t, "pg", src)
+	d := ctx.Value(DDLKey).(*DDL)
+	d.SetSchema("public")
+	schemas, err := Schemas(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var names []string
+	for _, s := range schemas {
+		names = append(names, s.SchemaName)
+	}
+	if exp := []string{"auth", "billing", "public"}; !reflect.DeepEqual(names, exp) {
+		t.Errorf("expected schemas %v, got: %v", exp, names)
+	}
+	for schema, exp := range map[string][]string{
+		"public":  {"users"},
+		"auth":    {"users"},
+		"billing": {"invoices"},
+	} {
+		tables, err := ddlTables(ctx, nil, schema, "table")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		var names []string
+		for _, table := range tables {
+			names = append(names, table.TableName)
+		}
+		if !reflect.DeepEqual(names, exp) {
+			t.Errorf("schema %q expected tables %v, got: %v", schema, exp, names)
+		}
+	}
+	fkeys, err := ddlTableForeignKeys(ctx, nil, "billing", "invoices")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var refs []string
+	for _, fkey := range fkeys {
+		refs = append(refs, fkey.RefSchemaName+"."+fkey.RefTableName+"."+fkey.RefColumnName)
+	}
+	if exp := []string{"public.users.id", "auth.users.id"}; !reflect.DeepEqual(refs, exp) {
+		t.Errorf("expected foreign key references %v, got: %v", exp, refs)
+	}
+}
+
+func TestDDLErrors(t *testing.T) {
+	tests := []struct {
+		dialect string
+		src     string
+	}{
+		{"postgres", "CREATE TABLE a (id int"},
+		{"postgres", "CREATE INDEX a_idx ON a (id)"},
+		{"postgres", "ALTER TABLE a ADD COLUMN b int"},
+		{"postgres", "/* unterminated"},
+		{"mysql", "CREATE TABLE a (b varchar(10) DEFAULT 'x)"},
+	}
+	for i, test := range tests {
+		d, err := NewDDL(test.dialect)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if err := d.Parse(test.src); err == nil {
+			t.Errorf("test %d expected error, got nil", i)
+		}
+	}
+	if _, err := NewDDL("oracle"); err == nil {
+		t.Errorf("expected error for unsupported dialect, got nil")
+	}
+}
+
+func ddlContext(t *testing.T, dialect, src string) context.Context {
+	t.Helper()
+	d, err := NewDDL(dialect)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := d.Parse(src); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), xo.DriverKey, d.Driver())
+	ctx = context.WithValue(ctx, xo.SchemaKey, "public")
+	return context.WithValue(ctx, DDLKey, d)
+}
+
+func checkColumns(t *testing.T, ctx context.Context, table string, exp []string) {
+	t.Helper()
+	columns, err := TableColumns(ctx, table)
+	if err != nil {
+		t.Fatalf("table %q expected no error, got: %v", table, err)
+	}
+	var cols []string
+	for _, c := range columns {
+		s := c.ColumnName + " " + c.DataType
+		if c.IsPrimaryKey {
+			s += " pk"
+		}
+		if c.NotNull {
+			s += " not null"
+		}
+		cols = append(cols, s)
+	}
+	if !reflect.DeepEqual(cols, exp) {
+		t.Errorf("table %q expected columns:\n%q\ngot:\n%q", table, exp, cols)
+	}
+}
+
+func checkIndexes(t *testing.T, ctx context.Context, table string, exp []string) {
+	t.Helper()
+	indexes, err := TableIndexes(ctx, table)
+	if err != nil {
+		t.Fatalf("table %q expected no error, got: %v", table, err)
+	}
+	var names []string
+	for _, index := range indexes {
+		s := index.IndexName
+		switch {
+		case index.IsPrimary:
+			s += " primary"
+		case index.IsUnique:
+			s += " unique"
+		}
+		names = append(names, s)
+	}
+	if !reflect.DeepEqual(names, exp) {
+		t.Errorf("table %q expected indexes %q, got: %q", table, exp, names)
+	}
+}
+
+func checkChecks(t *testing.T, ctx context.Context, table string, exp []string) {
+	t.Helper()
+	checks, err := TableChecks(ctx, table)
+	if err != nil {
+		t.Fatalf("table %q expected no error, got: %v", table, err)
+	}
+	var names []string
+	for _, check := range checks {
+		names = append(names, check.CheckName+" "+check.ColumnName)
+	}
+	if !reflect.DeepEqual(names, exp) {
+		t.Errorf("table %q expected checks %q, got: %q", table, exp, names)
+	}
+}
@@ -0,0 +1,1332 @@
+package loader
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dbtpl/sqllex"
+)
+
+// ddlParser is a ddl statement parser.
+type ddlParser struct {
+	dialect string
+	src     string
+	toks    []sqllex.Token
+	i       int
+}
+
+// peek returns the next token without consuming it.
+func (p *ddlParser) peek() sqllex.Token {
+	if p.i < len(p.toks) {
+		return p.toks[p.i]
+	}
+	return sqllex.Token{Type: sqllex.EOF}
+}
+
+// next consumes and returns the next token.
+func (p *ddlParser) next() sqllex.Token {
+	tok := p.peek()
+	if p.i < len(p.toks) {
+		p.i++
+	}
+	return tok
+}
+
+// done determines if the statement has been consumed.
+func (p *ddlParser) done() bool {
+	return p.i >= len(p.toks)
+}
+
+// is determines if the next tokens are the keywords or punctuation.
+func (p *ddlParser) is(words ...string) bool {
+	for j, word := range words {
+		if p.i+j >= len(p.toks) {
+			return false
+		}
+		if tok := p.toks[p.i+j]; !tok.IsWord(word) && !tok.IsPunct(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes the next tokens when they are the keywords or punctuation.
+func (p *ddlParser) accept(words ...string) bool {
+	if !p.is(words...) {
+		return false
+	}
+	p.i += len(words)
+	return true
+}
+
+// expect consumes the keywords or punctuation, returning an error if not
+// present.
+func (p *ddlParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return p.errorf("expected %s", strings.ToUpper(strings.Join(words, " ")))
+	}
+	return nil
+}
+
+// errorf returns an error for the next token.
+func (p *ddlParser) errorf(s string, v ...any) error {
+	got := "end of statement"
+	if tok := p.peek(); tok.Type != sqllex.EOF {
+		got = strconv.Quote(tok.Text)
+	}
+	return fmt.Errorf(s+", got %s", append(v, got)...)
+}
+
+// isIdent determines if the next token is an identifier.
+func (p *ddlParser) isIdent() bool {
+	tok := p.peek()
+	return tok.Type == sqllex.Word || tok.Type == sqllex.Ident
+}
+
+// ident consumes an identifier. Unquoted postgres identifiers are folded to
+// lower case.
+func (p *ddlParser) ident() (string, error) {
+	if !p.isIdent() {
+		return "", p.errorf("expected identifier")
+	}
+	return p.identValue(p.next()), nil
+}
+
+// identValue returns the identifier value of the token.
+func (p *ddlParser) identValue(tok sqllex.Token) string {
+	if tok.Type == sqllex.Word && p.dialect == "postgres" {
+		return strings.ToLower(tok.Text)
+	}
+	return tok.Text
+}
+
+// names consumes a qualified name, returning its parts.
+func (p *ddlParser) names() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.accept(".") {
+			return names, nil
+		}
+	}
+}
+
+// name consumes a (possibly schema qualified) name.
+func (p *ddlParser) name() (string, string, error) {
+	names, err := p.names()
+	if err != nil {
+		return "", "", err
+	}
+	if len(names) == 1 {
+		return "", names[0], nil
+	}
+	return names[len(names)-2], names[len(names)-1], nil
+}
+
+// skipParens consumes a parenthesized list, returning the enclosed tokens.
+func (p *ddlParser) skipParens() ([]sqllex.Token, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	start := p.i
+	for depth := 1; ; {
+		switch tok := p.next(); {
+		case tok.Type == sqllex.EOF:
+			return nil, p.errorf("expected )")
+		case tok.Type != sqllex.Punct:
+		case tok.Text == "(":
+			depth++
+		case tok.Text == ")":
+			if depth--; depth == 0 {
+				return p.toks[start : p.i-1], nil
+			}
+		}
+	}
+}
+
+// skipUntil consumes tokens until the end of the statement, an unenclosed ","
+// or ")", or a keyword satisfying stop.
+func (p *ddlParser) skipUntil(stop func(sqllex.Token) bool) []sqllex.Token {
+	start := p.i
+	for depth := 0; !p.done(); p.i++ {
+		tok := p.peek()
+		switch {
+		case tok.IsPunct("("):
+			depth++
+		case depth != 0 && tok.IsPunct(")"):
+			depth--
+		case depth != 0:
+		case tok.Type == sqllex.Punct && (tok.Text == "," || tok.Text == ")"),
+			stop != nil && tok.Type == sqllex.Word && stop(tok):
+			return p.toks[start:p.i]
+		}
+	}
+	return p.toks[start:p.i]
+}
+
+// columnList consumes a parenthesized list of columns. Nil is returned when the
+// list contains an expression.
+func (p *ddlParser) columnList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var cols []string
+	expr := false
+	for !p.accept(")") {
+		elem := p.skipUntil(nil)
+		switch {
+		case len(elem) == 0:
+			return nil, p.errorf("expected column")
+		case elem[0].Type != sqllex.Word && elem[0].Type != sqllex.Ident,
+			// function call, but not a mysql prefix length
+			len(elem) > 1 && elem[1].Text == "(" && (len(elem) < 4 || elem[2].Type != sqllex.Number || elem[3].Text != ")"):
+			expr = true
+		default:
+			cols = append(cols, p.identValue(elem[0]))
+		}
+		if !p.accept(",") && !p.is(")") {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+	if expr {
+		return nil, nil
+	}
+	return cols, nil
+}
+
+// text returns the source text of the tokens.
+func (p *ddlParser) text(toks []sqllex.Token) string {
+	if len(toks) == 0 {
+		return ""
+	}
+	return p.src[toks[0].Pos:toks[len(toks)-1].End]
+}
+
+// parse parses a statement.
+func (d *DDL) parse(p *ddlParser) error {
+	switch {
+	case p.accept("create"):
+		return d.create(p)
+	case p.accept("alter", "table"):
+		return d.alterTable(p)
+	case p.accept("alter", "type"):
+		return d.alterType(p)
+	case p.accept("alter", "sequence"):
+		return d.ownedBy(p)
+	case p.accept("comment", "on"):
+		return d.comment(p)
+	case p.accept("drop"):
+		return d.drop(p)
+	}
+	return nil
+}
+
+// create parses a CREATE statement.
+func (d *DDL) create(p *ddlParser) error {
+	p.accept("or", "replace")
+	// modifiers
+	for {
+		switch {
+		case p.accept("temp"), p.accept("temporary"), p.accept("unlogged"),
+			p.accept("global"), p.accept("local"), p.accept("recursive"):
+			continue
+		case p.accept("algorithm", "="), p.accept("sql", "security"):
+			p.next()
+			continue
+		case p.accept("definer", "="):
+			if p.next(); p.accept("@") {
+				p.next()
+			}
+			continue
+		}
+		break
+	}
+	switch {
+	case p.accept("table"):
+		return d.createTable(p)
+	case p.accept("view"):
+		return d.createView(p)
+	case p.accept("type"):
+		return d.createType(p)
+	case p.accept("sequence"):
+		return d.ownedBy(p)
+	case p.accept("unique", "index"):
+		return d.createIndex(p, true)
+	case p.accept("index"), p.accept("fulltext", "index"), p.accept("spatial", "index"):
+		return d.createIndex(p, false)
+	}
+	return nil
+}
+
+// createTable parses a CREATE TABLE statement.
+func (d *DDL) createTable(p *ddlParser) error {
+	ifNotExists := p.accept("if", "not", "exists")
+	schema, name, err := p.name()
+	if err != nil {
+		return err
+	}
+	switch {
+	case !p.is("("):
+		// CREATE TABLE ... AS, LIKE, PARTITION OF are not supported
+		return nil
+	case ifNotExists && d.table(schema, name) != nil:
+		return nil
+	}
+	p.next()
+	t := &ddlTable{
+		typ:    "table",
+		schema: schema,
+		name:   name,
+	}
+	for !p.accept(")") {
+		if err := d.tableElement(p, t); err != nil {
+			return err
+		}
+		if !p.accept(",") && !p.is(")") {
+			return p.errorf("expected , or )")
+		}
+	}
+	d.addTable(t)
+	return nil
+}
+
+// tableElement parses a column or table constraint definition.
+func (d *DDL) tableElement(p *ddlParser, t *ddlTable) error {
+	var constraint string
+	if p.accept("constraint") {
+		var err error
+		if constraint, err = p.ident(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.accept("primary", "key"):
+		_ = p.accept("clustered") || p.accept("nonclustered")
+		d.skipUsing(p)
+		cols, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		d.primaryKey(t, constraint, cols)
+	case p.accept("unique"):
+		_ = p.accept("key") || p.accept("index")
+		_ = p.accept("nulls", "not", "distinct") || p.accept("nulls", "distinct")
+		name, err := d.indexName(p, constraint)
+		if err != nil {
+			return err
+		}
+		cols, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		d.uniqueKey(t, name, cols)
+	case p.accept("foreign", "key"):
+		if !p.is("(") {
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+		}
+		cols, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		if err := d.references(p, t, constraint, cols); err != nil {
+			return err
+		}
+	case constraint == "" && (p.accept("key") || p.accept("index") ||
+		p.accept("fulltext") || p.accept("spatial")):
+		_ = p.accept("key") || p.accept("index")
+		name, err := d.indexName(p, "")
+		if err != nil {
+			return err
+		}
+		cols, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		d.index(t, name, false, cols)
+	case p.accept("check"):
+		check, err := d.check(p, constraint)
+		if err != nil {
+			return err
+		}
+		t.checks = append(t.checks, check)
+	case p.accept("exclude"), constraint == "" && p.accept("like"):
+	case constraint != "":
+		return p.errorf("expected constraint")
+	default:
+		return d.column(p, t)
+	}
+	p.skipUntil(nil)
+	return nil
+}
+
+// check parses a check constraint's parenthesized expression.
+func (d *DDL) check(p *ddlParser, name string) (*ddlCheck, error) {
+	toks, err := p.skipParens()
+	if err != nil {
+		return nil, err
+	}
+	check := &ddlCheck{
+		name: name,
+		expr: p.text(toks),
+	}
+	for _, tok := range toks {
+		if tok.Type == sqllex.Word || tok.Type == sqllex.Ident {
+			check.idents = append(check.idents, p.identValue(tok))
+		}
+	}
+	return check, nil
+}
+
+// indexName consumes an optional mysql index name and index type, returning
+// name when not present.
+func (d *DDL) indexName(p *ddlParser, name string) (string, error) {
+	if p.isIdent() && !p.is("using") {
+		var err error
+		if name, err = p.ident(); err != nil {
+			return "", err
+		}
+	}
+	d.skipUsing(p)
+	return name, nil
+}
+
+// skipUsing consumes an optional USING <method>.
+func (d *DDL) skipUsing(p *ddlParser) {
+	if p.accept("using") {
+		p.next()
+	}
+}
+
+// column parses a column definition.
+func (d *DDL) column(p *ddlParser, t *ddlTable) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	c := &ddlColumn{
+		name: name,
+	}
+	serial, err := d.columnType(p, c)
+	if err != nil {
+		return err
+	}
+	t.columns = append(t.columns, c)
+	var constraint string
+	pk, unique := false, serial && d.dialect == "mysql"
+	for !p.done() && !p.is(",") && !p.is(")") {
+		switch {
+		case p.accept("constraint"):
+			if constraint, err = p.ident(); err != nil {
+				return err
+			}
+		case p.accept("not", "null"):
+			c.notNull = true
+		case p.accept("null"):
+		case p.accept("default"):
+			c.def = d.defaultValue(p.skipUntil(isColumnStop), p)
+		case p.accept("primary", "key"):
+			_ = p.accept("asc") || p.accept("desc")
+			pk = true
+		case p.accept("unique"):
+			p.accept("key")
+			unique = true
+		case p.is("references"):
+			if err := d.references(p, t, constraint, []string{name}); err != nil {
+				return err
+			}
+		case p.accept("generated"):
+			_ = p.accept("always") || p.accept("by", "default", "on", "null") || p.accept("by", "default")
+			switch {
+			case p.accept("as", "identity"):
+				c.notNull, c.sequence = true, true
+				if p.is("(") {
+					if _, err := p.skipParens(); err != nil {
+						return err
+					}
+				}
+			case p.accept("as"):
+				if _, err := p.skipParens(); err != nil {
+					return err
+				}
+			}
+		case p.accept("identity"):
+			c.notNull, c.sequence = true, true
+			if p.is("(") {
+				if _, err := p.skipParens(); err != nil {
+					return err
+				}
+			}
+		case p.accept("auto_increment"), p.accept("autoincrement"):
+			c.sequence = true
+		case p.accept("check"):
+			check, err := d.check(p, constraint)
+			if err != nil {
+				return err
+			}
+			check.columns = []string{name}
+			t.checks = append(t.checks, check)
+		case p.accept("as"):
+			if _, err := p.skipParens(); err != nil {
+				return err
+			}
+		case p.accept("comment"):
+			if tok := p.next(); tok.Type == sqllex.String {
+				c.comment = tok.Text
+			}
+		case p.accept("on", "update"):
+			p.skipUntil(isColumnStop)
+		case p.accept("collate"), p.accept("on", "conflict"), p.accept("character", "set"), p.accept("charset"):
+			p.next()
+		default:
+			p.next()
+		}
+	}
+	if serial {
+		c.notNull, c.sequence = true, true
+	}
+	if pk {
+		d.primaryKey(t, constraint, []string{name})
+	}
+	if unique {
+		var indexName string
+		if !pk {
+			indexName = constraint
+		}
+		d.uniqueKey(t, indexName, []string{name})
+	}
+	return nil
+}
+
+// columnType consumes a column's type, setting the normalized type on the
+// column and returning whether or not the type is a serial type.
+func (d *DDL) columnType(p *ddlParser, c *ddlColumn) (bool, error) {
+	toks := p.skipUntil(isColumnStop)
+	// mysql enum/set
+	if d.dialect == "mysql" && len(toks) != 0 && (toks[0].IsWord("enum") || toks[0].IsWord("set")) {
+		var values []string
+		for _, tok := range toks {
+			if tok.Type == sqllex.String {
+				values = append(values, tok.Text)
+			}
+		}
+		c.typ = "set"
+		if toks[0].IsWord("enum") {
+			c.typ = c.name
+			if !slices.ContainsFunc(d.enums, func(e *ddlEnum) bool { return e.name == c.name }) {
+				d.enums = append(d.enums, &ddlEnum{
+					name:   c.name,
+					values: values,
+				})
+			}
+		}
+		return false, nil
+	}
+	var serial bool
+	c.typ, serial = d.normalizeType(typeText(toks))
+	return serial, nil
+}
+
+// normalizeType normalizes the type as reported by the database's
+// introspection queries, and returns whether the type is a serial type.
+func (d *DDL) normalizeType(typ string) (string, bool) {
+	switch d.dialect {
+	case "postgres":
+		return postgresDDLType(typ)
+	case "mysql":
+		return mysqlDDLType(typ)
+	}
+	return typ, false
+}
+
+// defaultValue returns the default value for the expression.
+func (d *DDL) defaultValue(toks []sqllex.Token, p *ddlParser) sql.NullString {
+	switch {
+	case len(toks) == 0:
+		return sql.NullString{}
+	case d.dialect == "mysql" && len(toks) == 1 && toks[0].IsWord("null"):
+		return sql.NullString{}
+	case d.dialect == "mysql" && len(toks) == 1 && toks[0].Type == sqllex.String:
+		return sql.NullString{String: toks[0].Text, Valid: true}
+	}
+	return sql.NullString{String: p.text(toks), Valid: true}
+}
+
+// references parses a REFERENCES clause, adding the foreign key to the table.
+func (d *DDL) references(p *ddlParser, t *ddlTable, name string, cols []string) error {
+	if err := p.expect("references"); err != nil {
+		return err
+	}
+	refSchema, ref, err := p.name()
+	if err != nil {
+		return err
+	}
+	var refCols []string
+	if p.is("(") {
+		if refCols, err = p.columnList(); err != nil {
+			return err
+		}
+	}
+	// actions
+	for {
+		switch {
+		case p.accept("on", "delete"), p.accept("on", "update"):
+			_ = p.accept("no", "action") || p.accept("set", "null") || p.accept("set", "default") || p.next().Type != sqllex.EOF
+			continue
+		case p.accept("match"), p.accept("initially"):
+			p.next()
+			continue
+		case p.accept("deferrable"), p.accept("not", "deferrable"), p.accept("not", "valid"):
+			continue
+		}
+		break
+	}
+	if name == "" {
+		switch d.dialect {
+		case "postgres":
+			name = t.name + "_" + strings.Join(cols, "_") + "_fkey"
+		case "mysql":
+			name = t.name + "_ibfk_" + strconv.Itoa(len(t.fkeys)+1)
+		}
+	}
+	t.fkeys = append(t.fkeys, &ddlForeignKey{
+		name:       name,
+		columns:    cols,
+		refSchema:  refSchema,
+		refTable:   ref,
+		refColumns: refCols,
+	})
+	return nil
+}
+
+// primaryKey sets the primary key for the table.
+func (d *DDL) primaryKey(t *ddlTable, name string, cols []string) {
+	for _, col := range cols {
+		if c := t.column(col); c != nil {
+			c.primary = true
+			// sqlite only makes an "integer primary key" not null
+			c.notNull = c.notNull || d.dialect != "sqlite3" || len(cols) == 1 && strings.EqualFold(c.typ, "integer")
+		}
+	}
+	switch {
+	case d.dialect == "mysql":
+		// mysql does not report the primary key index
+		return
+	case d.dialect == "sqlite3" && len(cols) == 1 && t.column(cols[0]) != nil && strings.EqualFold(t.column(cols[0]).typ, "integer"):
+		// sqlite uses the rowid for "integer primary key"
+		return
+	case d.dialect == "sqlite3":
+		name = d.autoindexName(t)
+	case name == "":
+		name = t.name + "_pkey"
+	}
+	t.indexes = append(t.indexes, &ddlIndex{
+		name:    name,
+		unique:  true,
+		primary: true,
+		columns: cols,
+	})
+}
+
+// uniqueKey adds a unique index to the table.
+func (d *DDL) uniqueKey(t *ddlTable, name string, cols []string) {
+	switch {
+	case d.dialect == "sqlite3":
+		name = d.autoindexName(t)
+	case name == "" && d.dialect == "postgres":
+		name = t.name + "_" + strings.Join(cols, "_") + "_key"
+	}
+	d.index(t, name, true, cols)
+}
+
+// index adds an index to the table.
+func (d *DDL) index(t *ddlTable, name string, unique bool, cols []string) {
+	if len(cols) == 0 {
+		return
+	}
+	if name == "" {
+		switch d.dialect {
+		case "postgres":
+			name = t.name + "_" + strings.Join(cols, "_") + "_idx"
+		default:
+			// mysql names indexes after the first column
+			name = cols[0]
+			for i := 2; slices.ContainsFunc(t.indexes, func(index *ddlIndex) bool { return index.name == name }); i++ {
+				name = cols[0] + "_" + strconv.Itoa(i)
+			}
+		}
+	}
+	t.indexes = append(t.indexes, &ddlIndex{
+		name:    name,
+		unique:  unique,
+		columns: cols,
+	})
+}
+
+// autoindexName returns the next sqlite automatic index name for the table.
+func (d *DDL) autoindexName(t *ddlTable) string {
+	n := 1
+	for _, index := range t.indexes {
+		if strings.HasPrefix(index.name, "sqlite_autoindex_") {
+			n++
+		}
+	}
+	return "sqlite_autoindex_" + t.name + "_" + strconv.Itoa(n)
+}
+
+// createIndex parses a CREATE INDEX statement.
+func (d *DDL) createIndex(p *ddlParser, unique bool) error {
+	p.accept("concurrently")
+	p.accept("if", "not", "exists")
+	var name string
+	if !p.is("on") {
+		var err error
+		if _, name, err = p.name(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("on"); err != nil {
+		return err
+	}
+	p.accept("only")
+	schema, table, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := d.table(schema, table)
+	if t == nil {
+		return fmt.Errorf("create index: table %q not defined", table)
+	}
+	d.skipUsing(p)
+	cols, err := p.columnList()
+	if err != nil {
+		return err
+	}
+	d.index(t, name, unique, cols)
+	return nil
+}
+
+// createType parses a CREATE TYPE statement. Only enums are supported.
+func (d *DDL) createType(p *ddlParser) error {
+	schema, name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if !p.accept("as", "enum") {
+		return nil
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	e := &ddlEnum{
+		schema: schema,
+		name:   name,
+	}
+	for !p.accept(")") {
+		tok := p.next()
+		if tok.Type != sqllex.String {
+			return fmt.Errorf("create type: enum %q has invalid value %q", name, tok.Text)
+		}
+		e.values = append(e.values, tok.Text)
+		p.accept(",")
+	}
+	d.dropEnum(name)
+	d.enums = append(d.enums, e)
+	return nil
+}
+
+// alterType parses an ALTER TYPE statement. Only adding enum values is
+// supported.
+func (d *DDL) alterType(p *ddlParser) error {
+	_, name, err := p.name()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(d.enums, func(e *ddlEnum) bool { return e.name == name })
+	if i == -1 || !p.accept("add", "value") {
+		return nil
+	}
+	e := d.enums[i]
+	p.accept("if", "not", "exists")
+	tok := p.next()
+	if tok.Type != sqllex.String || slices.Contains(e.values, tok.Text) {
+		return nil
+	}
+	// position
+	n := len(e.values)
+	switch {
+	case p.accept("before"):
+		n = slices.Index(e.values, p.next().Text)
+	case p.accept("after"):
+		if n = slices.Index(e.values, p.next().Text); n != -1 {
+			n++
+		}
+	}
+	if n == -1 {
+		return fmt.Errorf("alter type: enum %q does not have value", name)
+	}
+	e.values = slices.Insert(e.values, n, tok.Text)
+	return nil
+}
+
+// ownedBy parses a CREATE SEQUENCE or ALTER SEQUENCE statement, marking the
+// OWNED BY column as a sequence.
+func (d *DDL) ownedBy(p *ddlParser) error {
+	for !p.done() && !p.is("owned", "by") {
+		p.next()
+	}
+	if !p.accept("owned", "by") || p.is("none") {
+		return nil
+	}
+	names, err := p.names()
+	if err != nil || len(names) < 2 {
+		return err
+	}
+	schema := ""
+	if len(names) > 2 {
+		schema = names[len(names)-3]
+	}
+	if t := d.table(schema, names[len(names)-2]); t != nil {
+		if c := t.column(names[len(names)-1]); c != nil {
+			c.sequence = true
+		}
+	}
+	return nil
+}
+
+// createView parses a CREATE VIEW statement.
+func (d *DDL) createView(p *ddlParser) error {
+	p.accept("if", "not", "exists")
+	schema, name, err := p.name()
+	if err != nil {
+		return err
+	}
+	var names []string
+	if p.is("(") {
+		if names, err = p.columnList(); err != nil {
+			return err
+		}
+	}
+	if p.accept("with") {
+		if _, err := p.skipParens(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("as"); err != nil {
+		return err
+	}
+	query := p.toks[p.i:]
+	// strip WITH [CASCADED|LOCAL] CHECK OPTION
+	for i, tok := range query {
+		if tok.IsWord("with") && i+2 < len(query) && (query[i+1].IsWord("check") || query[i+2].IsWord("check")) {
+			query = query[:i]
+			break
+		}
+	}
+	def := p.text(query)
+	if d.dialect == "sqlite3" {
+		def = p.text(p.toks)
+	}
+	d.addTable(&ddlTable{
+		typ:    "view",
+		schema: schema,
+		name:   name,
+		def:    def,
+		query:  query,
+		names:  names,
+	})
+	return nil
+}
+
+// alterTable parses an ALTER TABLE statement.
+func (d *DDL) alterTable(p *ddlParser) error {
+	p.accept("if", "exists")
+	p.accept("only")
+	schema, name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := d.table(schema, name)
+	if t == nil {
+		return fmt.Errorf("alter table: table %q not defined", name)
+	}
+	for {
+		if err := d.alterAction(p, t); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+// alterAction parses an ALTER TABLE action.
+func (d *DDL) alterAction(p *ddlParser, t *ddlTable) error {
+	switch {
+	case p.accept("add"):
+		if p.is("constraint") || p.is("primary") || p.is("unique") || p.is("foreign") ||
+			p.is("check") || p.is("exclude") || p.is("key") || p.is("index") ||
+			p.is("fulltext") || p.is("spatial") {
+			return d.tableElement(p, t)
+		}
+		p.accept("column")
+		if p.accept("if", "not", "exists") && p.isIdent() && t.column(p.identValue(p.peek())) != nil {
+			break
+		}
+		return d.column(p, t)
+	case d.dialect == "mysql" && p.accept("modify"):
+		p.accept("column")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		p.i--
+		return d.replaceColumn(p, t, name)
+	case d.dialect == "mysql" && p.accept("change"):
+		p.accept("column")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		return d.replaceColumn(p, t, name)
+	case p.accept("alter"):
+		p.accept("column")
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		c := t.column(name)
+		if c == nil {
+			return fmt.Errorf("alter table: table %q column %q not defined", t.name, name)
+		}
+		switch {
+		case p.accept("set", "not", "null"):
+			c.notNull = true
+		case p.accept("drop", "not", "null"):
+			c.notNull = false
+		case p.accept("set", "default"):
+			c.def = d.defaultValue(p.skipUntil(nil), p)
+		case p.accept("drop", "default"):
+			c.def = sql.NullString{}
+		case p.accept("add", "generated"):
+			c.notNull, c.sequence = true, true
+		case p.accept("set", "data", "type"), p.accept("type"):
+			c.typ, _ = d.normalizeType(typeText(p.skipUntil(func(tok sqllex.Token) bool {
+				return tok.IsWord("using") || tok.IsWord("collate")
+			})))
+		}
+	case p.accept("drop"):
+		switch {
+		case p.accept("constraint"), p.accept("index"), p.accept("key"), p.accept("foreign", "key"), p.accept("check"):
+			p.accept("if", "exists")
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			t.dropConstraint(name)
+		case p.accept("primary", "key"):
+			for _, c := range t.columns {
+				c.primary = false
+			}
+			t.indexes = slices.DeleteFunc(t.indexes, func(index *ddlIndex) bool { return index.primary })
+		default:
+			p.accept("column")
+			p.accept("if", "exists")
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			t.dropColumn(name)
+		}
+	case p.accept("rename", "to"), p.accept("rename", "as"):
+		_, name, err := p.name()
+		if err != nil {
+			return err
+		}
+		t.name = name
+	case p.accept("rename"):
+		p.accept("column")
+		from, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("to"); err != nil {
+			return err
+		}
+		to, err := p.ident()
+		if err != nil {
+			return err
+		}
+		t.renameColumn(from, to)
+	}
+	p.skipUntil(nil)
+	return nil
+}
+
+// replaceColumn parses a column definition replacing the named column.
+func (d *DDL) replaceColumn(p *ddlParser, t *ddlTable, name string) error {
+	c := t.column(name)
+	if c == nil {
+		return fmt.Errorf("alter table: table %q column %q not defined", t.name, name)
+	}
+	z := &ddlTable{
+		typ:     t.typ,
+		schema:  t.schema,
+		name:    t.name,
+		indexes: t.indexes,
+	}
+	if err := d.column(p, z); err != nil {
+		return err
+	}
+	t.renameColumn(name, z.columns[0].name)
+	z.columns[0].primary = z.columns[0].primary || c.primary
+	*c = *z.columns[0]
+	t.indexes, t.fkeys = z.indexes, append(t.fkeys, z.fkeys...)
+	return nil
+}
+
+// comment parses a COMMENT ON statement.
+func (d *DDL) comment(p *ddlParser) error {
+	var table bool
+	switch {
+	case p.accept("table"):
+		table = true
+	case p.accept("column"):
+	default:
+		return nil
+	}
+	names, err := p.names()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("is"); err != nil {
+		return err
+	}
+	var comment string
+	if tok := p.next(); tok.Type == sqllex.String {
+		comment = tok.Text
+	}
+	if table {
+		schema, name := "", names[len(names)-1]
+		if len(names) > 1 {
+			schema = names[len(names)-2]
+		}
+		// postgres uses the table comment as its definition
+		if t := d.table(schema, name); t != nil && t.typ == "table" {
+			t.def = comment
+		}
+		return nil
+	}
+	if len(names) < 2 {
+		return fmt.Errorf("comment on column: invalid column name")
+	}
+	schema := ""
+	if len(names) > 2 {
+		schema = names[len(names)-3]
+	}
+	if t := d.table(schema, names[len(names)-2]); t != nil {
+		if c := t.column(names[len(names)-1]); c != nil {
+			c.comment = comment
+		}
+	}
+	return nil
+}
+
+// drop parses a DROP statement.
+func (d *DDL) drop(p *ddlParser) error {
+	var typ string
+	switch {
+	case p.accept("table"):
+		typ = "table"
+	case p.accept("view"):
+		typ = "view"
+	case p.accept("type"):
+		typ = "type"
+	case p.accept("index"):
+		typ = "index"
+		p.accept("concurrently")
+	default:
+		return nil
+	}
+	p.accept("if", "exists")
+	for {
+		schema, name, err := p.name()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case "table", "view":
+			d.tables = slices.DeleteFunc(d.tables, func(t *ddlTable) bool {
+				return t.typ == typ && d.sameName(t.name, name) && (schema == "" || t.schema == "" || t.schema == schema)
+			})
+		case "type":
+			d.dropEnum(name)
+		case "index":
+			for _, t := range d.tables {
+				t.indexes = slices.DeleteFunc(t.indexes, func(index *ddlIndex) bool { return index.name == name })
+			}
+		}
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+// table returns the named table or view.
+func (d *DDL) table(schema, name string) *ddlTable {
+	for _, t := range d.tables {
+		if d.sameName(t.name, name) && (schema == "" || t.schema == "" || t.schema == schema) {
+			return t
+		}
+	}
+	return nil
+}
+
+// addTable adds a table or view, replacing any existing with the same name.
+func (d *DDL) addTable(t *ddlTable) {
+	d.tables = slices.DeleteFunc(d.tables, func(z *ddlTable) bool {
+		return d.sameName(z.name, t.name) && z.schema == t.schema
+	})
+	d.tables = append(d.tables, t)
+}
+
+// dropEnum drops the named enum.
+func (d *DDL) dropEnum(name string) {
+	d.enums = slices.DeleteFunc(d.enums, func(e *ddlEnum) bool { return e.name == name })
+}
+
+// sameName determines if the table names are the same. SQLite table names are
+// case-insensitive.
+func (d *DDL) sameName(a, b string) bool {
+	if d.dialect == "sqlite3" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// column returns the named column.
+func (t *ddlTable) column(name string) *ddlColumn {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// dropColumn drops the named column, and any index or foreign key using it.
+func (t *ddlTable) dropColumn(name string) {
+	t.columns = slices.DeleteFunc(t.columns, func(c *ddlColumn) bool { return c.name == name })
+	t.indexes = slices.DeleteFunc(t.indexes, func(index *ddlIndex) bool { return slices.Contains(index.columns, name) })
+	t.fkeys = slices.DeleteFunc(t.fkeys, func(fkey *ddlForeignKey) bool { return slices.Contains(fkey.columns, name) })
+	t.checks = slices.DeleteFunc(t.checks, func(check *ddlCheck) bool {
+		return slices.Contains(check.columns, name) || slices.Contains(check.idents, name)
+	})
+}
+
+// renameColumn renames a column, and its uses in indexes, foreign keys, and
+// check constraints.
+func (t *ddlTable) renameColumn(from, to string) {
+	if c := t.column(from); c != nil {
+		c.name = to
+	}
+	for _, index := range t.indexes {
+		for i, c := range index.columns {
+			if c == from {
+				index.columns[i] = to
+			}
+		}
+	}
+	for _, fkey := range t.fkeys {
+		for i, c := range fkey.columns {
+			if c == from {
+				fkey.columns[i] = to
+			}
+		}
+	}
+	for _, check := range t.checks {
+		for i, c := range check.columns {
+			if c == from {
+				check.columns[i] = to
+			}
+		}
+		for i, c := range check.idents {
+			if c == from {
+				check.idents[i] = to
+			}
+		}
+	}
+}
+
+// dropConstraint drops the named index, foreign key, or check constraint.
+func (t *ddlTable) dropConstraint(name string) {
+	for _, index := range t.indexes {
+		if index.name == name && index.primary {
+			for _, c := range t.columns {
+				c.primary = false
+			}
+		}
+	}
+	t.indexes = slices.DeleteFunc(t.indexes, func(index *ddlIndex) bool { return index.name == name })
+	t.fkeys = slices.DeleteFunc(t.fkeys, func(fkey *ddlForeignKey) bool { return fkey.name == name })
+	t.checks = slices.DeleteFunc(t.checks, func(check *ddlCheck) bool { return check.name == name })
+}
+
+// isColumnStop determines if the token ends a column type or default value.
+func isColumnStop(tok sqllex.Token) bool {
+	switch strings.ToLower(tok.Text) {
+	case "constraint", "not", "null", "default", "primary", "unique",
+		"references", "check", "collate", "generated", "auto_increment",
+		"autoincrement", "comment", "on", "as", "charset", "identity",
+		"visible", "invisible":
+		return true
+	}
+	return false
+}
+
+// typeText returns the text of the type tokens, with keywords lower cased.
+func typeText(toks []sqllex.Token) string {
+	var sb strings.Builder
+	for i, tok := range toks {
+		s := tok.Text
+		switch tok.Type {
+		case sqllex.Word:
+			s = strings.ToLower(s)
+		case sqllex.String:
+			s = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		}
+		if i != 0 && tok.Type != sqllex.Punct && (toks[i-1].Type != sqllex.Punct || toks[i-1].Text == ")") {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
+
+// postgresDDLType normalizes a postgres type to the format_type name.
+func postgresDDLType(typ string) (string, bool) {
+	// arrays
+	var array string
+	for {
+		switch i := strings.LastIndexByte(typ, '['); {
+		case strings.HasSuffix(typ, "]") && i != -1:
+			typ, array = strings.TrimSpace(typ[:i]), "[]"
+			continue
+		case strings.HasSuffix(typ, " array"):
+			typ, array = strings.TrimSuffix(typ, " array"), "[]"
+			continue
+		}
+		break
+	}
+	// precision
+	var prec string
+	if m := ddlPrecRE.FindStringIndex(typ); m != nil {
+		prec, typ = typ[m[0]:m[1]], strings.TrimSpace(typ[:m[0]]+typ[m[1]:])
+	}
+	var serial bool
+	switch typ {
+	case "int", "int4":
+		typ = "integer"
+	case "serial", "serial4":
+		typ, serial = "integer", true
+	case "int8":
+		typ = "bigint"
+	case "bigserial", "serial8":
+		typ, serial = "bigint", true
+	case "int2":
+		typ = "smallint"
+	case "smallserial", "serial2":
+		typ, serial = "smallint", true
+	case "bool":
+		typ = "boolean"
+	case "varchar":
+		typ = "character varying"
+	case "char", "bpchar":
+		typ = "character"
+	case "float", "float8":
+		typ = "double precision"
+	case "float4":
+		typ = "real"
+	case "decimal":
+		typ = "numeric"
+	case "timestamptz":
+		typ = "timestamp with time zone"
+	case "timestamp":
+		typ = "timestamp without time zone"
+	case "timetz":
+		typ = "time with time zone"
+	case "time":
+		typ = "time without time zone"
+	case "varbit":
+		typ = "bit varying"
+	}
+	// time types report precision before the time zone, which is not handled
+	// by the type parser
+	if strings.HasPrefix(typ, "time") || typ == "interval" {
+		prec = ""
+	}
+	return typ + prec + array, serial
+}
+
+// mysqlDDLType normalizes a mysql type to the column_type reported by
+// information_schema.
+func mysqlDDLType(typ string) (string, bool) {
+	var fields []string
+	var unsigned bool
+	if i := strings.Index(typ, " character set "); i != -1 {
+		typ = typ[:i]
+	}
+	for i, s := range strings.Fields(typ) {
+		switch {
+		case s == "unsigned":
+			unsigned = true
+		case s == "signed", s == "zerofill", s == "binary" && i != 0:
+		default:
+			fields = append(fields, s)
+		}
+	}
+	typ = strings.Join(fields, " ")
+	var prec string
+	if m := ddlPrecRE.FindStringIndex(typ); m != nil {
+		prec, typ = typ[m[0]:m[1]], strings.TrimSpace(typ[:m[0]]+typ[m[1]:])
+	}
+	var serial bool
+	switch typ {
+	case "integer":
+		typ = "int"
+	case "bool", "boolean":
+		typ, prec = "tinyint", "(1)"
+	case "dec", "numeric", "fixed":
+		typ = "decimal"
+	case "double precision", "real":
+		typ = "double"
+	case "character varying", "nvarchar", "national varchar":
+		typ = "varchar"
+	case "character", "nchar", "national char":
+		typ = "char"
+	case "serial":
+		typ, unsigned, serial = "bigint", true, true
+	}
+	switch {
+	case prec == "" && typ == "decimal":
+		prec = "(10,0)"
+	case prec == "" && (typ == "char" || typ == "binary"):
+		prec = "(1)"
+	}
+	if unsigned {
+		prec += " unsigned"
+	}
+	return typ + prec, serial
+}
+
+// ddlPrecRE matches a type's precision and scale.
+var ddlPrecRE = regexp.MustCompile(`\(\s*\d+\s*(,\s*\d+\s*)?\)`)
@@ -0,0 +1,354 @@
+package loader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dbtpl/sqllex"
+)
+
+// ddlSource is a table or view referenced in a view's FROM clause.
+type ddlSource struct {
+	alias string
+	table *ddlTable
+}
+
+// columns returns the columns for the table or view.
+//
+// View columns are determined from the view's select list. Columns referring
+// to a table's column use the column's type, while the types of other
+// expressions are determined from any explicit cast, falling back to the
+// dialect's text type.
+func (d *DDL) columns(t *ddlTable, depth int) ([]*ddlColumn, error) {
+	if t.typ != "view" || t.columns != nil {
+		return t.columns, nil
+	}
+	if depth > 32 {
+		return nil, fmt.Errorf("view %q: recursive view definition", t.name)
+	}
+	p := &ddlParser{
+		dialect: d.dialect,
+		toks:    t.query,
+	}
+	for p.accept("(") {
+	}
+	if !p.accept("select") {
+		return nil, fmt.Errorf("view %q: unable to determine columns from query", t.name)
+	}
+	if p.accept("distinct") {
+		if p.accept("on") {
+			if _, err := p.skipParens(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		p.accept("all")
+	}
+	// select list
+	var items [][]sqllex.Token
+	for !p.done() && !p.is("from") {
+		items = append(items, p.skipUntil(func(tok sqllex.Token) bool {
+			return tok.IsWord("from")
+		}))
+		if !p.accept(",") && !p.done() && !p.is("from") {
+			return nil, fmt.Errorf("view %q: unable to determine columns from query", t.name)
+		}
+	}
+	sources, err := d.sources(p)
+	if err != nil {
+		return nil, err
+	}
+	var columns []*ddlColumn
+	for _, item := range items {
+		cols, err := d.viewColumns(p, item, sources, len(columns), depth)
+		if err != nil {
+			return nil, fmt.Errorf("view %q: %w", t.name, err)
+		}
+		columns = append(columns, cols...)
+	}
+	// explicit column names
+	if len(t.names) != 0 {
+		if len(t.names) != len(columns) {
+			return nil, fmt.Errorf("view %q: has %d column names, but query has %d columns", t.name, len(t.names), len(columns))
+		}
+		for i, name := range t.names {
+			columns[i].name = name
+		}
+	}
+	t.columns = columns
+	return columns, nil
+}
+
+// sources parses the tables and views referenced in a FROM clause.
+func (d *DDL) sources(p *ddlParser) ([]ddlSource, error) {
+	if !p.accept("from") {
+		return nil, nil
+	}
+	var sources []ddlSource
+	for table := true; !p.done(); {
+		switch tok := p.peek(); {
+		case isSourceStop(tok):
+			return sources, nil
+		case p.is("(") && !p.is("(", "select"), p.is(")"):
+			// parenthesized joins
+			p.next()
+		case p.is("("):
+			if _, err := p.skipParens(); err != nil {
+				return nil, err
+			}
+			if table {
+				sources, table = append(sources, ddlSource{alias: p.alias()}), false
+			}
+		case p.accept(","), p.accept("join"):
+			table = true
+		case table && p.isIdent() && !p.is("lateral") && !p.is("only"):
+			schema, name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			alias := p.alias()
+			if alias == "" {
+				alias = name
+			}
+			sources, table = append(sources, ddlSource{alias: alias, table: d.table(schema, name)}), false
+		default:
+			p.next()
+		}
+	}
+	return sources, nil
+}
+
+// alias consumes an optional table alias.
+func (p *ddlParser) alias() string {
+	if p.accept("as") || p.isIdent() && !isSourceStop(p.peek()) && !isJoinWord(p.peek()) {
+		if name, err := p.ident(); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// viewColumns returns the columns for a view's select list item.
+func (d *DDL) viewColumns(p *ddlParser, item []sqllex.Token, sources []ddlSource, n, depth int) ([]*ddlColumn, error) {
+	// alias
+	var alias string
+	if i := len(item) - 1; i > 0 {
+		last, prev := item[i], item[i-1]
+		switch {
+		case prev.IsWord("as"):
+			alias, item = p.identValue(last), item[:i-1]
+		case (last.Type == sqllex.Ident || last.Type == sqllex.Word && !last.IsWord("end") && !last.IsWord("null") &&
+			!last.IsWord("true") && !last.IsWord("false")) &&
+			(prev.Type != sqllex.Punct || prev.Text == ")"):
+			alias, item = p.identValue(last), item[:i]
+		}
+	}
+	switch {
+	case len(item) == 1 && item[0].IsPunct("*"):
+		// all columns
+		var columns []*ddlColumn
+		for _, src := range sources {
+			cols, err := d.sourceColumns(src, depth)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, cols...)
+		}
+		return columns, nil
+	case len(item) >= 3 && item[len(item)-1].Text == "*" && item[len(item)-2].Text == ".":
+		// qualified all columns
+		src, ok := findSource(sources, p.identValue(item[len(item)-3]))
+		if !ok {
+			return nil, fmt.Errorf("unknown table %q", item[len(item)-3].Text)
+		}
+		return d.sourceColumns(src, depth)
+	}
+	// column reference
+	c, err := d.columnRef(p, item, sources, depth)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = &ddlColumn{
+			name: d.exprName(p, item, n),
+			typ:  d.exprType(p, item, sources, depth),
+		}
+	}
+	if alias != "" {
+		c.name = alias
+	}
+	return []*ddlColumn{c}, nil
+}
+
+// columnRef returns a copy of the column referenced by the expression, or nil
+// when the expression is not a column reference.
+func (d *DDL) columnRef(p *ddlParser, item []sqllex.Token, sources []ddlSource, depth int) (*ddlColumn, error) {
+	for i, tok := range item {
+		if i%2 == 0 && tok.Type != sqllex.Word && tok.Type != sqllex.Ident || i%2 == 1 && tok.Text != "." {
+			return nil, nil
+		}
+	}
+	if len(item)%2 == 0 {
+		return nil, nil
+	}
+	name := p.identValue(item[len(item)-1])
+	srcs := sources
+	if len(item) > 1 {
+		src, ok := findSource(sources, p.identValue(item[len(item)-3]))
+		if !ok {
+			return nil, nil
+		}
+		srcs = []ddlSource{src}
+	}
+	for _, src := range srcs {
+		cols, err := d.sourceColumns(src, depth)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cols {
+			if c.name == name {
+				return c, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// sourceColumns returns copies of the columns for the source as used in a
+// view. Only mysql reports the nullability of view columns.
+func (d *DDL) sourceColumns(src ddlSource, depth int) ([]*ddlColumn, error) {
+	if src.table == nil {
+		return nil, nil
+	}
+	cols, err := d.columns(src.table, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	var columns []*ddlColumn
+	for _, c := range cols {
+		columns = append(columns, &ddlColumn{
+			name:    c.name,
+			typ:     c.typ,
+			notNull: c.notNull && d.dialect == "mysql",
+			comment: c.comment,
+		})
+	}
+	return columns, nil
+}
+
+// exprName returns the column name for an expression.
+func (d *DDL) exprName(p *ddlParser, item []sqllex.Token, n int) string {
+	switch {
+	case len(item) > 2 && item[0].Type == sqllex.Word && item[1].Text == "(" && !item[0].IsWord("cast"):
+		return strings.ToLower(item[0].Text)
+	case len(item) > 2 && item[1].Text == "::" && (item[0].Type == sqllex.Word || item[0].Type == sqllex.Ident):
+		return p.identValue(item[0])
+	}
+	return "column" + strconv.Itoa(n+1)
+}
+
+// exprType returns the type of an expression.
+func (d *DDL) exprType(p *ddlParser, item []sqllex.Token, sources []ddlSource, depth int) string {
+	// postgres cast
+	for i, level := len(item)-1, 0; i > 0; i-- {
+		switch {
+		case item[i].Text == ")":
+			level++
+		case item[i].Text == "(":
+			level--
+		case level == 0 && item[i].IsPunct("::"):
+			typ, _ := d.normalizeType(typeText(item[i+1:]))
+			return typ
+		}
+	}
+	last := len(item) - 1
+	switch {
+	case len(item) == 1 && item[0].Type == sqllex.String:
+		return d.basicType("text")
+	case len(item) == 1 && item[0].Type == sqllex.Number && strings.Contains(item[0].Text, "."):
+		return d.basicType("numeric")
+	case len(item) == 1 && item[0].Type == sqllex.Number:
+		return d.basicType("integer")
+	case len(item) == 1 && (item[0].IsWord("true") || item[0].IsWord("false")):
+		return d.basicType("boolean")
+	case len(item) < 4 || item[0].Type != sqllex.Word || item[1].Text != "(" || item[last].Text != ")":
+		return d.basicType("text")
+	}
+	// single function call
+	args := item[2:last]
+	for i, level := 0, 0; i < len(args); i++ {
+		switch {
+		case args[i].Text == "(":
+			level++
+		case args[i].Text == ")":
+			level--
+		case level != 0:
+		case item[0].IsWord("cast") && args[i].IsWord("as"):
+			typ, _ := d.normalizeType(typeText(args[i+1:]))
+			return typ
+		case args[i].Text == ",":
+			args = args[:i]
+		}
+	}
+	switch strings.ToLower(item[0].Text) {
+	case "count":
+		return d.basicType("bigint")
+	case "min", "max", "coalesce", "nullif":
+		if c, _ := d.columnRef(p, args, sources, depth); c != nil {
+			return c.typ
+		}
+	}
+	return d.basicType("text")
+}
+
+// basicType returns the dialect's name for a basic type.
+func (d *DDL) basicType(typ string) string {
+	switch {
+	case d.dialect == "mysql" && typ == "integer":
+		return "int"
+	case d.dialect == "mysql" && typ == "numeric":
+		return "decimal"
+	case d.dialect == "mysql" && typ == "boolean":
+		return "tinyint(1)"
+	case d.dialect == "sqlite3" && typ == "bigint":
+		return "integer"
+	}
+	return typ
+}
+
+// findSource finds the source with the alias.
+func findSource(sources []ddlSource, alias string) (ddlSource, bool) {
+	for _, src := range sources {
+		if src.alias == alias {
+			return src, true
+		}
+	}
+	return ddlSource{}, false
+}
+
+// isSourceStop determines if the token ends a FROM clause.
+func isSourceStop(tok sqllex.Token) bool {
+	if tok.Type != sqllex.Word {
+		return false
+	}
+	switch strings.ToLower(tok.Text) {
+	case "where", "group", "order", "limit", "having", "union", "intersect",
+		"except", "window", "offset", "fetch", "for":
+		return true
+	}
+	return false
+}
+
+// isJoinWord determines if the token is part of a join.
+func isJoinWord(tok sqllex.Token) bool {
+	if tok.Type != sqllex.Word {
+		return false
+	}
+	switch strings.ToLower(tok.Text) {
+	case "join", "left", "right", "inner", "outer", "full", "cross", "natural",
+		"on", "using", "lateral":
+		return true
+	}
+	return false
+}
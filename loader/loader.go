@@ -1,15 +1,17 @@
 // Package loader loads query and schema information from mysql, oracle,
-// postgres, sqlite3 and sqlserver databases.
+// postgres, sqlite3 and sqlserver databases, or from SQL DDL.
 package loader
 
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"maps"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/kenshaw/snaker"
 	"github.com/xo/dbtpl/models"
@@ -52,6 +54,7 @@ type Loader struct {
 	Mask             string
 	Flags            func() []xo.Flag
 	Schema           func(context.Context, models.DB) (string, error)
+	Schemas          func(context.Context, models.DB) ([]*models.Schema, error)
 	Enums            func(context.Context, models.DB, string) ([]*models.Enum, error)
 	EnumValues       func(context.Context, models.DB, string, string) ([]*models.EnumValue, error)
 	Procs            func(context.Context, models.DB, string) ([]*models.Proc, error)
@@ -62,11 +65,20 @@ type Loader struct {
 	TableForeignKeys func(context.Context, models.DB, string, string) ([]*models.ForeignKey, error)
 	TableIndexes     func(context.Context, models.DB, string, string) ([]*models.Index, error)
 	IndexColumns     func(context.Context, models.DB, string, string, string) ([]*models.IndexColumn, error)
+	TableChecks      func(context.Context, models.DB, string, string) ([]*models.Check, error)
+	AllSequences     func(context.Context, models.DB, string) ([]*models.Sequence, error)
+	AllColumns       func(context.Context, models.DB, string) ([]*models.Column, error)
+	AllForeignKeys   func(context.Context, models.DB, string) ([]*models.ForeignKey, error)
+	AllIndexes       func(context.Context, models.DB, string) ([]*models.Index, error)
+	AllIndexColumns  func(context.Context, models.DB, string) ([]*models.IndexColumn, error)
 	ViewCreate       func(context.Context, models.DB, string, string, []string) (sql.Result, error)
 	ViewSchema       func(context.Context, models.DB, string) (string, error)
+	ViewDependencies func(context.Context, models.DB, string, string) ([]*models.ViewDependency, error)
 	ViewTruncate     func(context.Context, models.DB, string, string) (sql.Result, error)
 	ViewDrop         func(context.Context, models.DB, string, string) (sql.Result, error)
 	ViewStrip        func([]string, []string) ([]string, []string, []string, error)
+	QueryColumns     func(context.Context, models.DB, string, string, []string) ([]*models.Column, error)
+	QueryParams      func(context.Context, models.DB, string, string, []string) ([]*models.ProcParam, error)
 }
 
 // get retrieves the database connection, loader, and schema name from the
@@ -77,11 +89,55 @@ func get(ctx context.Context) (*sql.DB, *Loader, string, error) {
 	if !ok {
 		return nil, nil, "", fmt.Errorf("no database loader available for %q", typ)
 	}
+	// use the ddl loader, keeping the driver's placeholder mask
+	if _, ok := ctx.Value(DDLKey).(*DDL); ok {
+		mask := l.Mask
+		l = loaders["ddl"]
+		l.Mask = mask
+	}
 	db, _ := ctx.Value(xo.DbKey).(*sql.DB)
 	schema, _ := ctx.Value(xo.SchemaKey).(string)
 	return db, &l, schema, nil
 }
 
+// loadKey is the load state context key.
+const loadKey xo.ContextKey = "load"
+
+// loadState is the state of a load.
+type loadState struct {
+	mu   sync.Mutex
+	caps map[string]bool
+}
+
+// WithLoad returns a context for a load, caching the database capabilities
+// queried by the loaders (such as the availability of check constraints with
+// MySQL) until the load is complete.
+func WithLoad(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadKey, &loadState{
+		caps: make(map[string]bool),
+	})
+}
+
+// capability returns the named database capability, using f to query the
+// capability only once for the load in the context.
+func capability(ctx context.Context, name string, f func() (bool, error)) (bool, error) {
+	s, ok := ctx.Value(loadKey).(*loadState)
+	if !ok {
+		return f()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.caps[name]; ok {
+		return v, nil
+	}
+	v, err := f()
+	if err != nil {
+		return false, err
+	}
+	s.caps[name] = v
+	return v, nil
+}
+
 // NthParam returns a 0-based func to generate the nth param placeholder for
 // database queries.
 func NthParam(ctx context.Context) (func(int) string, error) {
@@ -112,6 +168,19 @@ func Schema(ctx context.Context) (string, error) {
 	return l.Schema(ctx, db)
 }
 
+// Schemas returns the database schemas. When the loader is not able to list
+// the database schemas, only the active schema is returned.
+func Schemas(ctx context.Context) ([]*models.Schema, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.Schemas != nil {
+		return l.Schemas(ctx, db)
+	}
+	return []*models.Schema{{SchemaName: schema}}, nil
+}
+
 // Enums returns the database enums.
 func Enums(ctx context.Context) ([]*models.Enum, error) {
 	db, l, schema, err := get(ctx)
@@ -211,6 +280,103 @@ func IndexColumns(ctx context.Context, table, index string) ([]*models.IndexColu
 	return l.IndexColumns(ctx, db, schema, table, index)
 }
 
+// TableChecks returns the database table check constraints.
+func TableChecks(ctx context.Context, table string) ([]*models.Check, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.TableChecks != nil {
+		return l.TableChecks(ctx, db, schema, table)
+	}
+	return nil, nil
+}
+
+// AllSequences returns the sequences for all tables in the database schema,
+// keyed by table name. Returns nil when the loader is not able to load the
+// sequences in bulk.
+func AllSequences(ctx context.Context) (map[string][]*models.Sequence, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil || l.AllSequences == nil {
+		return nil, err
+	}
+	sequences, err := l.AllSequences(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+	return byTable(sequences, func(s *models.Sequence) string { return s.TableName }), nil
+}
+
+// AllColumns returns the columns for all tables and views in the database
+// schema, keyed by table name. Returns nil when the loader is not able to
+// load the columns in bulk.
+func AllColumns(ctx context.Context) (map[string][]*models.Column, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil || l.AllColumns == nil {
+		return nil, err
+	}
+	columns, err := l.AllColumns(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+	return byTable(columns, func(c *models.Column) string { return c.TableName }), nil
+}
+
+// AllForeignKeys returns the foreign keys for all tables in the database
+// schema, keyed by table name. Returns nil when the loader is not able to
+// load the foreign keys in bulk.
+func AllForeignKeys(ctx context.Context) (map[string][]*models.ForeignKey, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil || l.AllForeignKeys == nil {
+		return nil, err
+	}
+	foreignKeys, err := l.AllForeignKeys(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+	return byTable(foreignKeys, func(fk *models.ForeignKey) string { return fk.TableName }), nil
+}
+
+// AllIndexes returns the indexes for all tables in the database schema, keyed
+// by table name. Returns nil when the loader is not able to load the indexes
+// in bulk.
+func AllIndexes(ctx context.Context) (map[string][]*models.Index, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil || l.AllIndexes == nil {
+		return nil, err
+	}
+	indexes, err := l.AllIndexes(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+	return byTable(indexes, func(i *models.Index) string { return i.TableName }), nil
+}
+
+// AllIndexColumns returns the index columns for all indexes in the database
+// schema, keyed by table name. Returns nil when the loader is not able to
+// load the index columns in bulk.
+func AllIndexColumns(ctx context.Context) (map[string][]*models.IndexColumn, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil || l.AllIndexColumns == nil {
+		return nil, err
+	}
+	cols, err := l.AllIndexColumns(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+	return byTable(cols, func(ic *models.IndexColumn) string { return ic.TableName }), nil
+}
+
+// byTable groups rows by their table name, retaining the order of the rows.
+func byTable[T any](rows []T, table func(T) string) map[string][]T {
+	m := make(map[string][]T)
+	for _, row := range rows {
+		name := table(row)
+		m[name] = append(m[name], row)
+	}
+	return m
+}
+
 // ViewCreate creates a introspection view of a query.
 func ViewCreate(ctx context.Context, id string, query []string) (sql.Result, error) {
 	db, l, schema, err := get(ctx)
@@ -232,6 +398,20 @@ func ViewSchema(ctx context.Context, id string) (string, error) {
 	return "", nil
 }
 
+// ViewDependencies returns the table columns the introspection view depends
+// on. Returns nil when the loader is not able to list the view's
+// dependencies.
+func ViewDependencies(ctx context.Context, id string) ([]*models.ViewDependency, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.ViewDependencies != nil {
+		return l.ViewDependencies(ctx, db, schema, id)
+	}
+	return nil, nil
+}
+
 // ViewTruncate truncates the introspection view.
 func ViewTruncate(ctx context.Context, id string) (sql.Result, error) {
 	db, l, schema, err := get(ctx)
@@ -266,6 +446,61 @@ func ViewStrip(ctx context.Context, query, inspect []string) ([]string, []string
 	return query, inspect, make([]string, len(query)), nil
 }
 
+// QueryColumns returns the result columns of a query using the database's
+// result metadata, without creating an introspection view. Returns an error
+// wrapping [errors.ErrUnsupported] when the loader is not able to describe
+// queries.
+func QueryColumns(ctx context.Context, id string, query []string) ([]*models.Column, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.QueryColumns == nil {
+		return nil, fmt.Errorf("query introspection without views: %w", errors.ErrUnsupported)
+	}
+	return l.QueryColumns(ctx, db, schema, id, query)
+}
+
+// QueryParams returns the types of a query's params, in placeholder order,
+// as determined by the database. Returns an error wrapping
+// [errors.ErrUnsupported] when the loader is not able to infer param types.
+func QueryParams(ctx context.Context, id string, query []string) ([]*models.ProcParam, error) {
+	db, l, schema, err := get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.QueryParams == nil {
+		return nil, fmt.Errorf("query param type inference: %w", errors.ErrUnsupported)
+	}
+	return l.QueryParams(ctx, db, schema, id, query)
+}
+
+// describeColumns returns the result columns of a query from the driver's
+// result metadata, using typ to build the column data types. The query
+// should not return any rows.
+func describeColumns(ctx context.Context, db models.DB, sqlstr string, typ func(*sql.ColumnType) string) ([]*models.Column, error) {
+	rows, err := db.QueryContext(ctx, sqlstr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var cols []*models.Column
+	for i, ct := range colTypes {
+		nullable, ok := ct.Nullable()
+		cols = append(cols, &models.Column{
+			FieldOrdinal: i + 1,
+			ColumnName:   ct.Name(),
+			DataType:     typ(ct),
+			NotNull:      ok && !nullable,
+		})
+	}
+	return cols, rows.Close()
+}
+
 // schemaType returns Go type and zero for a type, removing a "<schema>."
 // prefix when the type is determined to be in the same package.
 func schemaType(typ string, nullable bool, schema string) (string, string) {
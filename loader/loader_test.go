@@ -0,0 +1,45 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCapability(t *testing.T) {
+	var n int
+	f := func() (bool, error) {
+		n++
+		return n != 0, nil
+	}
+	// without a load, the capability is queried every time
+	for range 2 {
+		if ok, err := capability(context.Background(), "test", f); err != nil || !ok {
+			t.Fatalf("expected true, got: %t %v", ok, err)
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected 2 queries, got: %d", n)
+	}
+	// with a load, the capability is queried once
+	n = 0
+	ctx := WithLoad(context.Background())
+	if _, err := capability(ctx, "test", func() (bool, error) { return false, errors.New("failed") }); err == nil {
+		t.Fatalf("expected error")
+	}
+	for range 2 {
+		if ok, err := capability(ctx, "test", f); err != nil || !ok {
+			t.Fatalf("expected true, got: %t %v", ok, err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("expected 1 query, got: %d", n)
+	}
+	// each load queries the capability again
+	if _, err := capability(WithLoad(context.Background()), "test", f); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 queries, got: %d", n)
+	}
+}
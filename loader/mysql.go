@@ -2,6 +2,8 @@ package loader
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -13,6 +15,7 @@ func init() {
 	Register("mysql", Loader{
 		Mask:             "?",
 		Schema:           models.MysqlSchema,
+		Schemas:          models.MysqlSchemas,
 		Enums:            models.MysqlEnums,
 		EnumValues:       MysqlEnumValues,
 		Procs:            models.MysqlProcs,
@@ -23,8 +26,53 @@ func init() {
 		TableForeignKeys: models.MysqlTableForeignKeys,
 		TableIndexes:     models.MysqlTableIndexes,
 		IndexColumns:     models.MysqlIndexColumns,
+		TableChecks:      MysqlTableChecks,
+		AllSequences:     models.MysqlAllSequences,
+		AllColumns:       models.MysqlAllColumns,
+		AllForeignKeys:   models.MysqlAllForeignKeys,
+		AllIndexes:       models.MysqlAllIndexes,
+		AllIndexColumns:  models.MysqlAllIndexColumns,
 		ViewCreate:       models.MysqlViewCreate,
 		ViewDrop:         models.MysqlViewDrop,
+		QueryColumns:     MysqlQueryColumns,
+	})
+}
+
+// MysqlQueryColumns returns the result columns for a query, using the result
+// metadata of the query.
+func MysqlQueryColumns(ctx context.Context, db models.DB, _, id string, query []string) ([]*models.Column, error) {
+	return describeColumns(ctx, db, `SELECT * FROM (`+strings.Join(query, "\n")+`) AS `+id+` LIMIT 0`, func(ct *sql.ColumnType) string {
+		typ := strings.ToLower(ct.DatabaseTypeName())
+		if t, ok := strings.CutPrefix(typ, "unsigned "); ok {
+			typ = t + " unsigned"
+		}
+		if prec, scale, ok := ct.DecimalSize(); ok && typ == "decimal" {
+			typ = fmt.Sprintf("decimal(%d,%d)", prec, scale)
+		}
+		return typ
+	})
+}
+
+// MysqlTableChecks returns the check constraints for a table. Check
+// constraints are not loaded prior to MySQL 8.0.16 and MariaDB 10.2, as they
+// are not available in the information schema.
+func MysqlTableChecks(ctx context.Context, db models.DB, schema, table string) ([]*models.Check, error) {
+	ok, err := mysqlHasChecks(ctx, db)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return models.MysqlTableChecks(ctx, db, schema, table)
+}
+
+// mysqlHasChecks returns true when the information schema of the database has
+// check constraints, querying the information schema only once for each load.
+func mysqlHasChecks(ctx context.Context, db models.DB) (bool, error) {
+	return capability(ctx, "mysql-checks", func() (bool, error) {
+		var n int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'information_schema' AND table_name = 'CHECK_CONSTRAINTS'`).Scan(&n); err != nil {
+			return false, err
+		}
+		return n != 0, nil
 	})
 }
 
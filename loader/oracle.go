@@ -1,7 +1,11 @@
 package loader
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/xo/dbtpl/models"
 	xo "github.com/xo/dbtpl/types"
@@ -19,12 +23,39 @@ func init() {
 		TableForeignKeys: models.OracleTableForeignKeys,
 		TableIndexes:     models.OracleTableIndexes,
 		IndexColumns:     models.OracleIndexColumns,
+		TableChecks:      models.OracleTableChecks,
 		ViewCreate:       models.OracleViewCreate,
 		ViewTruncate:     models.OracleViewTruncate,
 		ViewDrop:         models.OracleViewDrop,
+		QueryColumns:     OracleQueryColumns,
 	})
 }
 
+// OracleQueryColumns returns the result columns for a query, using the result
+// metadata of the query.
+func OracleQueryColumns(ctx context.Context, db models.DB, _, _ string, query []string) ([]*models.Column, error) {
+	cols, err := describeColumns(ctx, db, `SELECT * FROM (`+strings.Join(query, "\n")+`) WHERE 1 = 0`, func(ct *sql.ColumnType) string {
+		typ := strings.ToLower(ct.DatabaseTypeName())
+		switch typ {
+		case "number":
+			prec, scale, _ := ct.DecimalSize()
+			return fmt.Sprintf("number(%d,%d)", prec, scale)
+		case "char", "nchar", "varchar2", "nvarchar2", "raw":
+			if n, ok := ct.Length(); ok {
+				return fmt.Sprintf("%s(%d)", typ, n)
+			}
+		}
+		return typ
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range cols {
+		col.ColumnName = strings.ToLower(col.ColumnName)
+	}
+	return cols, nil
+}
+
 // OracleGoType parse a oracle type into a Go type based on the column
 // definition.
 func OracleGoType(d xo.Type, schema, itype, utype string) (string, string, error) {
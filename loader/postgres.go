@@ -2,6 +2,7 @@ package loader
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -16,6 +17,7 @@ func init() {
 		Mask:             "$%d",
 		Flags:            PostgresFlags,
 		Schema:           models.PostgresSchema,
+		Schemas:          models.PostgresSchemas,
 		Enums:            models.PostgresEnums,
 		EnumValues:       models.PostgresEnumValues,
 		Procs:            models.PostgresProcs,
@@ -26,10 +28,19 @@ func init() {
 		TableForeignKeys: models.PostgresTableForeignKeys,
 		TableIndexes:     models.PostgresTableIndexes,
 		IndexColumns:     PostgresIndexColumns,
+		TableChecks:      models.PostgresTableChecks,
+		AllSequences:     models.PostgresAllSequences,
+		AllColumns:       PostgresAllColumns,
+		AllForeignKeys:   models.PostgresAllForeignKeys,
+		AllIndexes:       models.PostgresAllIndexes,
+		AllIndexColumns:  models.PostgresAllIndexColumns,
 		ViewCreate:       models.PostgresViewCreate,
 		ViewSchema:       models.PostgresViewSchema,
+		ViewDependencies: models.PostgresViewDependencies,
 		ViewDrop:         models.PostgresViewDrop,
 		ViewStrip:        PostgresViewStrip,
+		QueryColumns:     PostgresQueryColumns,
+		QueryParams:      PostgresQueryParams,
 	})
 }
 
@@ -178,6 +189,79 @@ func PostgresTableColumns(ctx context.Context, db models.DB, schema string, tabl
 	return models.PostgresTableColumns(ctx, db, schema, table, enableOids(ctx))
 }
 
+// PostgresAllColumns returns the columns for all tables in a schema.
+func PostgresAllColumns(ctx context.Context, db models.DB, schema string) ([]*models.Column, error) {
+	return models.PostgresAllColumns(ctx, db, schema, enableOids(ctx))
+}
+
+// PostgresQueryColumns returns the result columns for a query, using the
+// result types of the query when prepared.
+//
+// Requires PostgreSQL 14 or later.
+func PostgresQueryColumns(ctx context.Context, db models.DB, schema, id string, query []string) ([]*models.Column, error) {
+	// prepared statements are per connection
+	sqldb, ok := db.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("unable to prepare query on %T", db)
+	}
+	conn, err := sqldb.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// load column names
+	cols, err := describeColumns(ctx, conn, `SELECT * FROM (`+strings.Join(query, "\n")+`) AS `+id+` LIMIT 0`, func(ct *sql.ColumnType) string {
+		return strings.ToLower(ct.DatabaseTypeName())
+	})
+	if err != nil {
+		return nil, err
+	}
+	// load column types
+	if _, err := models.PostgresQueryPrepare(ctx, conn, schema, id, query); err != nil {
+		return nil, err
+	}
+	types, err := models.PostgresQueryResultTypes(ctx, conn, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := models.PostgresQueryDeallocate(ctx, conn, schema, id); err != nil {
+		return nil, err
+	}
+	if len(types) != len(cols) {
+		return nil, fmt.Errorf("query %s has %d result types, expected %d", id, len(types), len(cols))
+	}
+	for i, typ := range types {
+		cols[i].DataType = typ.DataType
+	}
+	return cols, nil
+}
+
+// PostgresQueryParams returns the param types for a query, preparing the
+// query and retrieving the param types from pg_prepared_statements.
+func PostgresQueryParams(ctx context.Context, db models.DB, schema, id string, query []string) ([]*models.ProcParam, error) {
+	// prepared statements are per connection
+	sqldb, ok := db.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("unable to prepare query on %T", db)
+	}
+	conn, err := sqldb.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := models.PostgresQueryPrepare(ctx, conn, schema, id, query); err != nil {
+		return nil, err
+	}
+	params, err := models.PostgresQueryParamTypes(ctx, conn, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := models.PostgresQueryDeallocate(ctx, conn, schema, id); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
 // PostgresIndexColumns returns the column list for an index.
 //
 // FIXME: rewrite this using SQL exclusively using OVER
@@ -1,6 +1,10 @@
 package loader
 
 import (
+	"context"
+	"database/sql"
+	"strings"
+
 	"github.com/xo/dbtpl/models"
 	xo "github.com/xo/dbtpl/types"
 )
@@ -15,11 +19,48 @@ func init() {
 		TableForeignKeys: models.Sqlite3TableForeignKeys,
 		TableIndexes:     models.Sqlite3TableIndexes,
 		IndexColumns:     models.Sqlite3IndexColumns,
+		TableChecks:      Sqlite3TableChecks,
 		ViewCreate:       models.Sqlite3ViewCreate,
 		ViewDrop:         models.Sqlite3ViewDrop,
+		QueryColumns:     Sqlite3QueryColumns,
+	})
+}
+
+// Sqlite3QueryColumns returns the result columns for a query, using the
+// declared types of the query's result columns.
+func Sqlite3QueryColumns(ctx context.Context, db models.DB, _, _ string, query []string) ([]*models.Column, error) {
+	return describeColumns(ctx, db, `SELECT * FROM (`+strings.Join(query, "\n")+`) LIMIT 0`, func(ct *sql.ColumnType) string {
+		return ct.DatabaseTypeName()
 	})
 }
 
+// Sqlite3TableChecks returns the check constraints for a table, parsing the
+// table's CREATE TABLE statement.
+func Sqlite3TableChecks(ctx context.Context, db models.DB, _, table string) ([]*models.Check, error) {
+	var sqlstr string
+	if err := db.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = $1`, table).Scan(&sqlstr); err != nil {
+		return nil, err
+	}
+	return sqlite3Checks(sqlstr, table), nil
+}
+
+// sqlite3Checks parses the check constraints of a table's CREATE TABLE
+// statement. A statement not understood by the ddl parser has no checks.
+func sqlite3Checks(sqlstr, table string) []*models.Check {
+	d := &DDL{
+		dialect: "sqlite3",
+	}
+	if err := d.Parse(sqlstr); err != nil {
+		return nil
+	}
+	for _, t := range d.tables {
+		if t.name == table {
+			return d.checks(t)
+		}
+	}
+	return nil
+}
+
 // Sqlite3GoType parse a sqlite3 type into a Go type based on the column
 // definition.
 func Sqlite3GoType(d xo.Type, schema, itype, utype string) (string, string, error) {
@@ -0,0 +1,49 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSqlite3TableChecks(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE books (book_id INTEGER PRIMARY KEY, pages INTEGER CHECK (pages > 0)) STRICT, WITHOUT ROWID`); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	checks, err := Sqlite3TableChecks(context.Background(), db, "", "books")
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(checks) != 1 || checks[0].CheckName != "books_pages_check" || checks[0].ColumnName != "pages":
+		t.Errorf("expected books_pages_check check, got: %v", checks)
+	}
+}
+
+func TestSqlite3Checks(t *testing.T) {
+	tests := []struct {
+		sqlstr string
+		exp    []string
+	}{
+		{`CREATE TABLE t (a INTEGER, b TEXT, CONSTRAINT ab CHECK (a > 0 OR b <> ''))`, []string{"ab a", "ab b"}},
+		{`CREATE TABLE t (a INTEGER)`, nil},
+		{`CREATE TABLE t (a INTEGER, CHECK a > 0)`, nil},
+		{`CREATE TABLE t (a INTEGER CHECK (a > 0)`, nil},
+	}
+	for i, test := range tests {
+		var checks []string
+		for _, c := range sqlite3Checks(test.sqlstr, "t") {
+			checks = append(checks, c.CheckName+" "+c.ColumnName)
+		}
+		if !reflect.DeepEqual(checks, test.exp) {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, checks)
+		}
+	}
+}
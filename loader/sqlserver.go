@@ -1,7 +1,10 @@
 package loader
 
 import (
+	"context"
+	"database/sql"
 	"regexp"
+	"strings"
 
 	"github.com/xo/dbtpl/models"
 	xo "github.com/xo/dbtpl/types"
@@ -11,6 +14,7 @@ func init() {
 	Register("sqlserver", Loader{
 		Mask:             "@p%d",
 		Schema:           models.SqlserverSchema,
+		Schemas:          models.SqlserverSchemas,
 		Procs:            models.SqlserverProcs,
 		ProcParams:       models.SqlserverProcParams,
 		Tables:           models.SqlserverTables,
@@ -19,12 +23,63 @@ func init() {
 		TableForeignKeys: models.SqlserverTableForeignKeys,
 		TableIndexes:     models.SqlserverTableIndexes,
 		IndexColumns:     models.SqlserverIndexColumns,
+		TableChecks:      models.SqlserverTableChecks,
 		ViewCreate:       models.SqlserverViewCreate,
+		ViewDependencies: models.SqlserverViewDependencies,
 		ViewDrop:         models.SqlserverViewDrop,
 		ViewStrip:        SqlserverViewStrip,
+		QueryColumns:     SqlserverQueryColumns,
+		QueryParams:      SqlserverQueryParams,
 	})
 }
 
+// SqlserverQueryColumns returns the result columns for a query, using
+// sys.dm_exec_describe_first_result_set.
+func SqlserverQueryColumns(ctx context.Context, db models.DB, _, _ string, query []string) ([]*models.Column, error) {
+	return models.SqlserverQueryColumns(ctx, db, strings.Join(query, "\n"))
+}
+
+// SqlserverQueryParams returns the param types for a query, using
+// sp_describe_undeclared_parameters.
+func SqlserverQueryParams(ctx context.Context, db models.DB, _, _ string, query []string) ([]*models.ProcParam, error) {
+	rows, err := db.QueryContext(ctx, `EXEC sp_describe_undeclared_parameters @tsql = @p1`, strings.Join(query, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// the result set has a large number of columns, so only retain the
+	// param name and type
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var params []*models.ProcParam
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		dest := make([]any, len(cols))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		param := new(models.ProcParam)
+		for i, col := range cols {
+			switch col {
+			case "name":
+				param.ParamName = vals[i].String
+			case "suggested_system_type_name":
+				param.ParamType = vals[i].String
+			}
+		}
+		params = append(params, param)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
 // SqlserverGoType parse a mssql type into a Go type based on the column
 // definition.
 func SqlserverGoType(d xo.Type, schema, itype, utype string) (string, string, error) {
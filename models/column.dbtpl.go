@@ -9,6 +9,7 @@ import (
 
 // Column is a column.
 type Column struct {
+	TableName    string         `json:"table_name"`     // table_name
 	FieldOrdinal int            `json:"field_ordinal"`  // field_ordinal
 	ColumnName   string         `json:"column_name"`    // column_name
 	DataType     string         `json:"data_type"`      // data_type
@@ -18,6 +19,89 @@ type Column struct {
 	Comment      sql.NullString `json:"comment"`        // comment
 }
 
+// PostgresAllColumns runs a custom query, returning results as [Column].
+func PostgresAllColumns(ctx context.Context, db DB, schema string, sys bool) ([]*Column, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`c.relname, ` + // ::varchar AS table_name
+		`a.attnum, ` + // ::integer AS field_ordinal
+		`a.attname, ` + // ::varchar AS column_name
+		`format_type(a.atttypid, a.atttypmod), ` + // ::varchar AS data_type
+		`a.attnotnull, ` + // ::boolean AS not_null
+		`COALESCE(pg_get_expr(ad.adbin, ad.adrelid), ''), ` + // ::varchar AS default_value
+		`COALESCE(ct.contype = 'p', false), ` + // ::boolean AS is_primary_key
+		`d.description ` + // ::varchar as comment
+		`FROM pg_attribute a ` +
+		`JOIN ONLY pg_class c ON c.oid = a.attrelid ` +
+		`JOIN ONLY pg_namespace n ON n.oid = c.relnamespace ` +
+		`LEFT JOIN pg_constraint ct ON ct.conrelid = c.oid ` +
+		`AND a.attnum = ANY(ct.conkey) ` +
+		`AND ct.contype = 'p' ` +
+		`LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid ` +
+		`AND ad.adnum = a.attnum ` +
+		`LEFT JOIN pg_description d on d.objoid = c.oid ` +
+		`AND d.objsubid = a.attnum ` +
+		`WHERE a.attisdropped = false ` +
+		`AND c.relkind IN ('r', 'v') ` +
+		`AND n.nspname = $1 ` +
+		`AND ($2 OR a.attnum > 0) ` +
+		`ORDER BY c.relname, a.attnum`
+	// run
+	logf(sqlstr, schema, sys)
+	rows, err := db.QueryContext(ctx, sqlstr, schema, sys)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Column
+	for rows.Next() {
+		var c Column
+		// scan
+		if err := rows.Scan(&c.TableName, &c.FieldOrdinal, &c.ColumnName, &c.DataType, &c.NotNull, &c.DefaultValue, &c.IsPrimaryKey, &c.Comment); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
+// PostgresQueryResultTypes runs a custom query, returning results as [Column].
+func PostgresQueryResultTypes(ctx context.Context, db DB, id string) ([]*Column, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`t.ord, ` + // ::integer AS field_ordinal
+		`format_type(t.typ, NULL) ` + // ::varchar AS data_type
+		`FROM pg_prepared_statements p ` +
+		`CROSS JOIN LATERAL unnest(p.result_types) WITH ORDINALITY AS t(typ, ord) ` +
+		`WHERE p.name = $1 ` +
+		`ORDER BY t.ord`
+	// run
+	logf(sqlstr, id)
+	rows, err := db.QueryContext(ctx, sqlstr, id)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Column
+	for rows.Next() {
+		var c Column
+		// scan
+		if err := rows.Scan(&c.FieldOrdinal, &c.DataType); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // PostgresTableColumns runs a custom query, returning results as [Column].
 func PostgresTableColumns(ctx context.Context, db DB, schema, table string, sys bool) ([]*Column, error) {
 	// query
@@ -67,6 +151,44 @@ func PostgresTableColumns(ctx context.Context, db DB, schema, table string, sys
 	return res, nil
 }
 
+// MysqlAllColumns runs a custom query, returning results as [Column].
+func MysqlAllColumns(ctx context.Context, db DB, schema string) ([]*Column, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`table_name, ` +
+		`ordinal_position AS field_ordinal, ` +
+		`column_name, ` +
+		`IF(data_type = 'enum', column_name, column_type) AS data_type, ` +
+		`IF(is_nullable = 'YES', false, true) AS not_null, ` +
+		`column_default AS default_value, ` +
+		`IF(column_key = 'PRI', true, false) AS is_primary_key, ` +
+		`column_comment AS comment ` +
+		`FROM information_schema.columns ` +
+		`WHERE table_schema = ? ` +
+		`ORDER BY table_name, ordinal_position`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Column
+	for rows.Next() {
+		var c Column
+		// scan
+		if err := rows.Scan(&c.TableName, &c.FieldOrdinal, &c.ColumnName, &c.DataType, &c.NotNull, &c.DefaultValue, &c.IsPrimaryKey, &c.Comment); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // MysqlTableColumns runs a custom query, returning results as [Column].
 func MysqlTableColumns(ctx context.Context, db DB, schema, table string) ([]*Column, error) {
 	// query
@@ -163,7 +285,7 @@ func SqlserverTableColumns(ctx context.Context, db DB, schema, table string) ([]
 		`LEFT JOIN sysobjects k ON k.xtype = 'PK' ` +
 		`AND k.parent_obj = o.id ` +
 		`LEFT JOIN syscomments x ON x.id = c.cdefault ` +
-		`WHERE o.type IN('U', 'V') ` +
+		`WHERE o.type IN('U', 'V', 'IF', 'TF') ` +
 		`AND SCHEMA_NAME(o.uid) = @p1 ` +
 		`AND o.name = @p2 ` +
 		`ORDER BY c.colid`
@@ -190,6 +312,40 @@ func SqlserverTableColumns(ctx context.Context, db DB, schema, table string) ([]
 	return res, nil
 }
 
+// SqlserverQueryColumns runs a custom query, returning results as [Column].
+func SqlserverQueryColumns(ctx context.Context, db DB, query string) ([]*Column, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`column_ordinal AS field_ordinal, ` +
+		`COALESCE(name, '') AS column_name, ` +
+		`system_type_name AS data_type, ` +
+		`IIF(is_nullable = 1, 0, 1) AS not_null ` +
+		`FROM sys.dm_exec_describe_first_result_set(@p1, NULL, 0) ` +
+		`WHERE is_hidden = 0 ` +
+		`ORDER BY column_ordinal`
+	// run
+	logf(sqlstr, query)
+	rows, err := db.QueryContext(ctx, sqlstr, query)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Column
+	for rows.Next() {
+		var c Column
+		// scan
+		if err := rows.Scan(&c.FieldOrdinal, &c.ColumnName, &c.DataType, &c.NotNull); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // OracleTableColumns runs a custom query, returning results as [Column].
 func OracleTableColumns(ctx context.Context, db DB, schema, table string) ([]*Column, error) {
 	// query
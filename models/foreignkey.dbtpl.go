@@ -8,19 +8,91 @@ import (
 
 // ForeignKey is a foreign key.
 type ForeignKey struct {
+	TableName      string `json:"table_name"`       // table_name
 	ForeignKeyName string `json:"foreign_key_name"` // foreign_key_name
 	ColumnName     string `json:"column_name"`      // column_name
+	RefSchemaName  string `json:"ref_schema_name"`  // ref_schema_name
 	RefTableName   string `json:"ref_table_name"`   // ref_table_name
 	RefColumnName  string `json:"ref_column_name"`  // ref_column_name
 	KeyID          int    `json:"key_id"`           // key_id
 }
 
+// PostgresAllForeignKeys runs a custom query, returning results as [ForeignKey].
+func PostgresAllForeignKeys(ctx context.Context, db DB, schema string) ([]*ForeignKey, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`tc.table_name, ` + // ::varchar AS table_name
+		`tc.constraint_name, ` + // ::varchar AS foreign_key_name
+		`kcu.column_name, ` + // ::varchar AS column_name
+		`ccu.table_schema, ` + // ::varchar AS ref_schema_name
+		`ccu.table_name, ` + // ::varchar AS ref_table_name
+		`ccu.column_name, ` + // ::varchar AS ref_column_name
+		`0 ` + // ::integer AS key_id
+		`FROM information_schema.table_constraints tc ` +
+		`JOIN information_schema.key_column_usage AS kcu ON tc.constraint_name = kcu.constraint_name ` +
+		`AND tc.table_schema = kcu.table_schema ` +
+		`AND tc.table_name = kcu.table_name ` +
+		`JOIN ( ` +
+		`SELECT ` +
+		`ROW_NUMBER() OVER ( ` +
+		`PARTITION BY ` +
+		`constraint_schema, ` +
+		`table_schema, ` +
+		`table_name, ` +
+		`constraint_name ` +
+		`ORDER BY row_num ` +
+		`) AS ordinal_position, ` +
+		`constraint_schema, ` +
+		`table_schema, ` +
+		`table_name, ` +
+		`column_name, ` +
+		`constraint_name ` +
+		`FROM ( ` +
+		`SELECT ` +
+		`ROW_NUMBER() OVER (ORDER BY 1) AS row_num, ` +
+		`constraint_schema, ` +
+		`table_schema, ` +
+		`table_name, ` +
+		`column_name, ` +
+		`constraint_name ` +
+		`FROM information_schema.constraint_column_usage ` +
+		`) t ` +
+		`) AS ccu ON ccu.constraint_name = tc.constraint_name ` +
+		`AND ccu.constraint_schema = tc.constraint_schema ` +
+		`AND ccu.ordinal_position = kcu.ordinal_position ` +
+		`WHERE tc.constraint_type = 'FOREIGN KEY' ` +
+		`AND tc.table_schema = $1 ` +
+		`ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*ForeignKey
+	for rows.Next() {
+		var fk ForeignKey
+		// scan
+		if err := rows.Scan(&fk.TableName, &fk.ForeignKeyName, &fk.ColumnName, &fk.RefSchemaName, &fk.RefTableName, &fk.RefColumnName, &fk.KeyID); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &fk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // PostgresTableForeignKeys runs a custom query, returning results as [ForeignKey].
 func PostgresTableForeignKeys(ctx context.Context, db DB, schema, table string) ([]*ForeignKey, error) {
 	// query
 	const sqlstr = `SELECT ` +
 		`tc.constraint_name, ` + // ::varchar AS foreign_key_name
 		`kcu.column_name, ` + // ::varchar AS column_name
+		`ccu.table_schema, ` + // ::varchar AS ref_schema_name
 		`ccu.table_name, ` + // ::varchar AS ref_table_name
 		`ccu.column_name, ` + // ::varchar AS ref_column_name
 		`0 ` + // ::integer AS key_id
@@ -32,11 +104,13 @@ func PostgresTableForeignKeys(ctx context.Context, db DB, schema, table string)
 		`SELECT ` +
 		`ROW_NUMBER() OVER ( ` +
 		`PARTITION BY ` +
+		`constraint_schema, ` +
 		`table_schema, ` +
 		`table_name, ` +
 		`constraint_name ` +
 		`ORDER BY row_num ` +
 		`) AS ordinal_position, ` +
+		`constraint_schema, ` +
 		`table_schema, ` +
 		`table_name, ` +
 		`column_name, ` +
@@ -44,6 +118,7 @@ func PostgresTableForeignKeys(ctx context.Context, db DB, schema, table string)
 		`FROM ( ` +
 		`SELECT ` +
 		`ROW_NUMBER() OVER (ORDER BY 1) AS row_num, ` +
+		`constraint_schema, ` +
 		`table_schema, ` +
 		`table_name, ` +
 		`column_name, ` +
@@ -51,7 +126,7 @@ func PostgresTableForeignKeys(ctx context.Context, db DB, schema, table string)
 		`FROM information_schema.constraint_column_usage ` +
 		`) t ` +
 		`) AS ccu ON ccu.constraint_name = tc.constraint_name ` +
-		`AND ccu.table_schema = tc.table_schema ` +
+		`AND ccu.constraint_schema = tc.constraint_schema ` +
 		`AND ccu.ordinal_position = kcu.ordinal_position ` +
 		`WHERE tc.constraint_type = 'FOREIGN KEY' ` +
 		`AND tc.table_schema = $1 ` +
@@ -68,7 +143,44 @@ func PostgresTableForeignKeys(ctx context.Context, db DB, schema, table string)
 	for rows.Next() {
 		var fk ForeignKey
 		// scan
-		if err := rows.Scan(&fk.ForeignKeyName, &fk.ColumnName, &fk.RefTableName, &fk.RefColumnName, &fk.KeyID); err != nil {
+		if err := rows.Scan(&fk.ForeignKeyName, &fk.ColumnName, &fk.RefSchemaName, &fk.RefTableName, &fk.RefColumnName, &fk.KeyID); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &fk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
+// MysqlAllForeignKeys runs a custom query, returning results as [ForeignKey].
+func MysqlAllForeignKeys(ctx context.Context, db DB, schema string) ([]*ForeignKey, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`table_name, ` +
+		`constraint_name AS foreign_key_name, ` +
+		`column_name AS column_name, ` +
+		`referenced_table_schema AS ref_schema_name, ` +
+		`referenced_table_name AS ref_table_name, ` +
+		`referenced_column_name AS ref_column_name ` +
+		`FROM information_schema.key_column_usage ` +
+		`WHERE referenced_table_name IS NOT NULL ` +
+		`AND table_schema = ? ` +
+		`ORDER BY table_name, constraint_name, ordinal_position`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*ForeignKey
+	for rows.Next() {
+		var fk ForeignKey
+		// scan
+		if err := rows.Scan(&fk.TableName, &fk.ForeignKeyName, &fk.ColumnName, &fk.RefSchemaName, &fk.RefTableName, &fk.RefColumnName); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &fk)
@@ -85,6 +197,7 @@ func MysqlTableForeignKeys(ctx context.Context, db DB, schema, table string) ([]
 	const sqlstr = `SELECT ` +
 		`constraint_name AS foreign_key_name, ` +
 		`column_name AS column_name, ` +
+		`referenced_table_schema AS ref_schema_name, ` +
 		`referenced_table_name AS ref_table_name, ` +
 		`referenced_column_name AS ref_column_name ` +
 		`FROM information_schema.key_column_usage ` +
@@ -103,7 +216,7 @@ func MysqlTableForeignKeys(ctx context.Context, db DB, schema, table string) ([]
 	for rows.Next() {
 		var fk ForeignKey
 		// scan
-		if err := rows.Scan(&fk.ForeignKeyName, &fk.ColumnName, &fk.RefTableName, &fk.RefColumnName); err != nil {
+		if err := rows.Scan(&fk.ForeignKeyName, &fk.ColumnName, &fk.RefSchemaName, &fk.RefTableName, &fk.RefColumnName); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &fk)
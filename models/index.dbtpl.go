@@ -8,11 +8,50 @@ import (
 
 // Index is a index.
 type Index struct {
+	TableName string `json:"table_name"` // table_name
 	IndexName string `json:"index_name"` // index_name
 	IsUnique  bool   `json:"is_unique"`  // is_unique
 	IsPrimary bool   `json:"is_primary"` // is_primary
 }
 
+// PostgresAllIndexes runs a custom query, returning results as [Index].
+func PostgresAllIndexes(ctx context.Context, db DB, schema string) ([]*Index, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`DISTINCT c.relname, ` + // ::varchar AS table_name
+		`ic.relname, ` + // ::varchar AS index_name
+		`i.indisunique, ` + // ::boolean AS is_unique
+		`i.indisprimary ` + // ::boolean AS is_primary
+		`FROM pg_index i ` +
+		`JOIN ONLY pg_class c ON c.oid = i.indrelid ` +
+		`JOIN ONLY pg_namespace n ON n.oid = c.relnamespace ` +
+		`JOIN ONLY pg_class ic ON ic.oid = i.indexrelid ` +
+		`WHERE i.indkey <> '0' ` +
+		`AND n.nspname = $1 ` +
+		`ORDER BY c.relname, ic.relname`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Index
+	for rows.Next() {
+		var i Index
+		// scan
+		if err := rows.Scan(&i.TableName, &i.IndexName, &i.IsUnique, &i.IsPrimary); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // PostgresTableIndexes runs a custom query, returning results as [Index].
 func PostgresTableIndexes(ctx context.Context, db DB, schema, table string) ([]*Index, error) {
 	// query
@@ -50,6 +89,40 @@ func PostgresTableIndexes(ctx context.Context, db DB, schema, table string) ([]*
 	return res, nil
 }
 
+// MysqlAllIndexes runs a custom query, returning results as [Index].
+func MysqlAllIndexes(ctx context.Context, db DB, schema string) ([]*Index, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`DISTINCT table_name, ` +
+		`index_name, ` +
+		`NOT non_unique AS is_unique ` +
+		`FROM information_schema.statistics ` +
+		`WHERE index_name <> 'PRIMARY' ` +
+		`AND index_schema = ? ` +
+		`ORDER BY table_name, index_name`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Index
+	for rows.Next() {
+		var i Index
+		// scan
+		if err := rows.Scan(&i.TableName, &i.IndexName, &i.IsUnique); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // MysqlTableIndexes runs a custom query, returning results as [Index].
 func MysqlTableIndexes(ctx context.Context, db DB, schema, table string) ([]*Index, error) {
 	// query
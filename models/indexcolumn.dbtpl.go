@@ -8,11 +8,56 @@ import (
 
 // IndexColumn is a index column.
 type IndexColumn struct {
+	TableName  string `json:"table_name"`  // table_name
+	IndexName  string `json:"index_name"`  // index_name
 	SeqNo      int    `json:"seq_no"`      // seq_no
 	Cid        int    `json:"cid"`         // cid
 	ColumnName string `json:"column_name"` // column_name
 }
 
+// PostgresAllIndexColumns runs a custom query, returning results as [IndexColumn].
+func PostgresAllIndexColumns(ctx context.Context, db DB, schema string) ([]*IndexColumn, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`c.relname, ` + // ::varchar AS table_name
+		`ic.relname, ` + // ::varchar AS index_name
+		`k.seq_no, ` + // ::integer AS seq_no
+		`a.attnum, ` + // ::integer AS cid
+		`a.attname ` + // ::varchar AS column_name
+		`FROM pg_index i ` +
+		`JOIN ONLY pg_class c ON c.oid = i.indrelid ` +
+		`JOIN ONLY pg_namespace n ON n.oid = c.relnamespace ` +
+		`JOIN ONLY pg_class ic ON ic.oid = i.indexrelid ` +
+		`CROSS JOIN LATERAL unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, seq_no) ` +
+		`JOIN pg_attribute a ON i.indrelid = a.attrelid ` +
+		`AND a.attnum = k.attnum ` +
+		`AND a.attisdropped = false ` +
+		`WHERE i.indkey <> '0' ` +
+		`AND n.nspname = $1 ` +
+		`ORDER BY c.relname, ic.relname, k.seq_no`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*IndexColumn
+	for rows.Next() {
+		var ic IndexColumn
+		// scan
+		if err := rows.Scan(&ic.TableName, &ic.IndexName, &ic.SeqNo, &ic.Cid, &ic.ColumnName); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &ic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // PostgresIndexColumns runs a custom query, returning results as [IndexColumn].
 func PostgresIndexColumns(ctx context.Context, db DB, schema, index string) ([]*IndexColumn, error) {
 	// query
@@ -53,6 +98,40 @@ func PostgresIndexColumns(ctx context.Context, db DB, schema, index string) ([]*
 	return res, nil
 }
 
+// MysqlAllIndexColumns runs a custom query, returning results as [IndexColumn].
+func MysqlAllIndexColumns(ctx context.Context, db DB, schema string) ([]*IndexColumn, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`table_name, ` +
+		`index_name, ` +
+		`seq_in_index AS seq_no, ` +
+		`column_name ` +
+		`FROM information_schema.statistics ` +
+		`WHERE index_schema = ? ` +
+		`ORDER BY table_name, index_name, seq_in_index`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*IndexColumn
+	for rows.Next() {
+		var ic IndexColumn
+		// scan
+		if err := rows.Scan(&ic.TableName, &ic.IndexName, &ic.SeqNo, &ic.ColumnName); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &ic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // MysqlIndexColumns runs a custom query, returning results as [IndexColumn].
 func MysqlIndexColumns(ctx context.Context, db DB, schema, table, index string) ([]*IndexColumn, error) {
 	// query
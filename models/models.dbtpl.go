@@ -95,6 +95,8 @@ const (
 	ErrDoesNotExist Error = "does not exist"
 	// ErrMarkedForDeletion is the marked for deletion error.
 	ErrMarkedForDeletion Error = "marked for deletion"
+	// ErrMissingResultSet is the missing result set error.
+	ErrMissingResultSet Error = "missing result set"
 )
 
 // ErrInsertFailed is the insert failed error.
@@ -142,6 +144,27 @@ func (err *ErrUpsertFailed) Unwrap() error {
 	return err.Err
 }
 
+// ErrUnexpectedRows is the unexpected rows affected error.
+type ErrUnexpectedRows struct {
+	Expected int64
+	Affected int64
+}
+
+// Error satisfies the error interface.
+func (err *ErrUnexpectedRows) Error() string {
+	return fmt.Sprintf("expected %d rows affected, got: %d", err.Expected, err.Affected)
+}
+
+// ErrCheckFailed is the check constraint failed error.
+type ErrCheckFailed struct {
+	Name string
+}
+
+// Error satisfies the error interface.
+func (err *ErrCheckFailed) Error() string {
+	return fmt.Sprintf("check constraint %s failed", err.Name)
+}
+
 // PostgresViewCreate creates a view for introspection.
 func PostgresViewCreate(ctx context.Context, db DB, schema, id string, query []string) (sql.Result, error) {
 	// query
@@ -180,6 +203,26 @@ func PostgresViewDrop(ctx context.Context, db DB, schema, id string) (sql.Result
 	return db.ExecContext(ctx, sqlstr)
 }
 
+// PostgresQueryPrepare prepares a query for introspection.
+func PostgresQueryPrepare(ctx context.Context, db DB, schema, id string, query []string) (sql.Result, error) {
+	// query
+	sqlstr := `/* ` + schema + ` */ ` +
+		`PREPARE ` + id + ` AS ` + strings.Join(query, "\n")
+	// run
+	logf(sqlstr)
+	return db.ExecContext(ctx, sqlstr)
+}
+
+// PostgresQueryDeallocate deallocates a query prepared for introspection.
+func PostgresQueryDeallocate(ctx context.Context, db DB, schema, id string) (sql.Result, error) {
+	// query
+	sqlstr := `/* ` + schema + ` */ ` +
+		`DEALLOCATE ` + id
+	// run
+	logf(sqlstr)
+	return db.ExecContext(ctx, sqlstr)
+}
+
 // PostgresSchema retrieves the schema.
 func PostgresSchema(ctx context.Context, db DB) (string, error) {
 	// query
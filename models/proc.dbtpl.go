@@ -14,6 +14,7 @@ type Proc struct {
 	ReturnType string `json:"return_type"` // return_type
 	ReturnName string `json:"return_name"` // return_name
 	ProcDef    string `json:"proc_def"`    // proc_def
+	ReturnsSet bool   `json:"returns_set"` // returns_set
 }
 
 // PostgresProcs runs a custom query, returning results as [Proc].
@@ -25,7 +26,8 @@ func PostgresProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 		`pp.proc_type, ` + // ::varchar AS proc_type
 		`format_type(pp.return_type, NULL), ` + // ::varchar AS return_type
 		`pp.return_name, ` + // ::varchar AS return_name
-		`p.prosrc ` + // ::varchar AS proc_def
+		`p.prosrc, ` + // ::varchar AS proc_def
+		`p.proretset ` + // ::boolean AS returns_set
 		`FROM pg_catalog.pg_proc p ` +
 		`JOIN pg_catalog.pg_namespace n ON (p.pronamespace = n.oid) ` +
 		`JOIN ( ` +
@@ -57,7 +59,7 @@ func PostgresProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 		`OR p.proargtypes[0] <> 'pg_catalog.cstring'::pg_catalog.regtype) ` +
 		`AND (pp.proc_type = 'function' ` +
 		`OR pp.proc_type = 'procedure') ` +
-		`AND pp.param_type = 'o' ` +
+		`AND pp.param_type IN ('o', 'b', 't') ` +
 		`AND n.nspname = $1`
 	// run
 	logf(sqlstr, schema)
@@ -71,7 +73,7 @@ func PostgresProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 	for rows.Next() {
 		var p Proc
 		// scan
-		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef); err != nil {
+		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef, &p.ReturnsSet); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &p)
@@ -91,11 +93,12 @@ func MysqlProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 		`LOWER(r.routine_type) AS proc_type, ` +
 		`COALESCE(p.dtd_identifier, 'void') AS return_type, ` +
 		`COALESCE(p.parameter_name, '') AS return_name, ` +
-		`r.routine_definition AS proc_def ` +
+		`r.routine_definition AS proc_def, ` +
+		`false AS returns_set ` +
 		`FROM information_schema.routines r ` +
 		`LEFT JOIN information_schema.parameters p ON p.specific_schema = r.routine_schema ` +
 		`AND p.specific_name = r.routine_name ` +
-		`AND (p.parameter_mode = 'OUT' OR p.ordinal_position = 0) ` +
+		`AND (p.parameter_mode IN ('OUT', 'INOUT') OR p.ordinal_position = 0) ` +
 		`WHERE r.routine_schema = ? ` +
 		`ORDER BY r.routine_name, p.ordinal_position`
 	// run
@@ -110,7 +113,7 @@ func MysqlProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 	for rows.Next() {
 		var p Proc
 		// scan
-		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef); err != nil {
+		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef, &p.ReturnsSet); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &p)
@@ -130,6 +133,8 @@ func SqlserverProcs(ctx context.Context, db DB, schema string) ([]*Proc, error)
 		`(CASE o.type ` +
 		`WHEN 'P' THEN 'procedure' ` +
 		`WHEN 'FN' THEN 'function' ` +
+		`WHEN 'IF' THEN 'function' ` +
+		`WHEN 'TF' THEN 'function' ` +
 		`END) AS proc_type, ` +
 		`CASE ` +
 		`WHEN p.object_id IS NOT NULL ` +
@@ -141,12 +146,12 @@ func SqlserverProcs(ctx context.Context, db DB, schema string) ([]*Proc, error)
 		`THEN SUBSTRING(p.name, 2, LEN(p.name)-1) ` +
 		`ELSE '' ` +
 		`END AS return_name, ` +
-		`OBJECT_DEFINITION(o.object_id) AS proc_def ` +
+		`OBJECT_DEFINITION(o.object_id) AS proc_def, ` +
+		`IIF(o.type IN ('IF', 'TF'), 1, 0) AS returns_set ` +
 		`FROM sys.objects o ` +
 		`LEFT JOIN sys.parameters p ON o.object_id = p.object_id ` +
 		`AND (p.object_id IS NULL OR p.is_output = 'true') ` +
-		`WHERE o.type = 'P' ` +
-		`OR o.type = 'FN' ` +
+		`WHERE o.type IN ('P', 'FN', 'IF', 'TF') ` +
 		`AND SCHEMA_NAME(o.schema_id) = @p1 ` +
 		`ORDER BY o.object_id`
 	// run
@@ -161,7 +166,7 @@ func SqlserverProcs(ctx context.Context, db DB, schema string) ([]*Proc, error)
 	for rows.Next() {
 		var p Proc
 		// scan
-		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef); err != nil {
+		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef, &p.ReturnsSet); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &p)
@@ -188,10 +193,11 @@ func OracleProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 		`LOWER(CASE ` +
 		`WHEN a.argument_name IS NULL THEN '-' ` +
 		`ELSE a.argument_name END) AS return_name, ` +
-		`s.src AS proc_def ` +
+		`s.src AS proc_def, ` +
+		`'0' AS returns_set ` +
 		`FROM all_objects o ` +
 		`LEFT JOIN sys.all_arguments a ON a.object_id = o.object_id ` +
-		`AND a.in_out = 'OUT' ` +
+		`AND a.in_out IN ('OUT', 'IN/OUT') ` +
 		`JOIN ( ` +
 		`SELECT ` +
 		`s.owner, ` +
@@ -216,7 +222,7 @@ func OracleProcs(ctx context.Context, db DB, schema string) ([]*Proc, error) {
 	for rows.Next() {
 		var p Proc
 		// scan
-		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef); err != nil {
+		if err := rows.Scan(&p.ProcID, &p.ProcName, &p.ProcType, &p.ReturnType, &p.ReturnName, &p.ProcDef, &p.ReturnsSet); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &p)
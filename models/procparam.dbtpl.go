@@ -10,26 +10,27 @@ import (
 type ProcParam struct {
 	ParamName string `json:"param_name"` // param_name
 	ParamType string `json:"param_type"` // param_type
+	ParamMode string `json:"param_mode"` // param_mode
 }
 
 // PostgresProcParams runs a custom query, returning results as [ProcParam].
 func PostgresProcParams(ctx context.Context, db DB, schema, id string) ([]*ProcParam, error) {
 	// query
 	const sqlstr = `SELECT ` +
-		`COALESCE(pp.param_name, ''), ` + // ::varchar AS param_name
-		`pp.param_type ` + // ::varchar AS param_type
+		`COALESCE(p.proargnames[a.ord], ''), ` + // ::varchar AS param_name
+		`format_type(a.typ, NULL), ` + // ::varchar AS param_type
+		`(CASE COALESCE(p.proargmodes[a.ord], 'i') ` +
+		`WHEN 'o' THEN 'OUT' ` +
+		`WHEN 'b' THEN 'INOUT' ` +
+		`ELSE 'IN' ` +
+		`END) ` + // ::varchar AS param_mode
 		`FROM pg_proc p ` +
 		`JOIN ONLY pg_namespace n ON p.pronamespace = n.oid ` +
-		`JOIN ( ` +
-		`SELECT ` +
-		`p.oid, ` +
-		`UNNEST(p.proargnames) AS param_name, ` +
-		`format_type(UNNEST(p.proargtypes), NULL) AS param_type ` +
-		`FROM pg_proc p ` +
-		`) AS pp ON p.oid = pp.oid ` +
+		`CROSS JOIN LATERAL unnest(COALESCE(p.proallargtypes, p.proargtypes::oid[])) WITH ORDINALITY AS a(typ, ord) ` +
 		`WHERE n.nspname = $1 ` +
 		`AND p.oid::varchar = $2 ` +
-		`AND pp.param_type IS NOT NULL`
+		`AND COALESCE(p.proargmodes[a.ord], 'i') <> 't' ` +
+		`ORDER BY a.ord`
 	// run
 	logf(sqlstr, schema, id)
 	rows, err := db.QueryContext(ctx, sqlstr, schema, id)
@@ -39,6 +40,39 @@ func PostgresProcParams(ctx context.Context, db DB, schema, id string) ([]*ProcP
 	defer rows.Close()
 	// load results
 	var res []*ProcParam
+	for rows.Next() {
+		var pp ProcParam
+		// scan
+		if err := rows.Scan(&pp.ParamName, &pp.ParamType, &pp.ParamMode); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &pp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
+// PostgresQueryParamTypes runs a custom query, returning results as [ProcParam].
+func PostgresQueryParamTypes(ctx context.Context, db DB, id string) ([]*ProcParam, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`('$' || t.ord), ` + // ::varchar AS param_name
+		`format_type(t.typ, NULL) ` + // ::varchar AS param_type
+		`FROM pg_prepared_statements p ` +
+		`CROSS JOIN LATERAL unnest(p.parameter_types) WITH ORDINALITY AS t(typ, ord) ` +
+		`WHERE p.name = $1 ` +
+		`ORDER BY t.ord`
+	// run
+	logf(sqlstr, id)
+	rows, err := db.QueryContext(ctx, sqlstr, id)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*ProcParam
 	for rows.Next() {
 		var pp ProcParam
 		// scan
@@ -58,11 +92,12 @@ func MysqlProcParams(ctx context.Context, db DB, schema, id string) ([]*ProcPara
 	// query
 	const sqlstr = `SELECT ` +
 		`p.parameter_name AS param_name, ` +
-		`p.dtd_identifier AS param_type ` +
+		`p.dtd_identifier AS param_type, ` +
+		`p.parameter_mode AS param_mode ` +
 		`FROM information_schema.routines r ` +
 		`INNER JOIN information_schema.parameters p ON p.specific_schema = r.routine_schema ` +
 		`AND p.specific_name = r.routine_name ` +
-		`AND p.parameter_mode = 'IN' ` +
+		`AND p.ordinal_position > 0 ` +
 		`WHERE r.routine_schema = ? ` +
 		`AND r.routine_name = ? ` +
 		`ORDER BY p.ordinal_position`
@@ -78,7 +113,7 @@ func MysqlProcParams(ctx context.Context, db DB, schema, id string) ([]*ProcPara
 	for rows.Next() {
 		var pp ProcParam
 		// scan
-		if err := rows.Scan(&pp.ParamName, &pp.ParamType); err != nil {
+		if err := rows.Scan(&pp.ParamName, &pp.ParamType, &pp.ParamMode); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &pp)
@@ -94,12 +129,13 @@ func SqlserverProcParams(ctx context.Context, db DB, schema, id string) ([]*Proc
 	// query
 	const sqlstr = `SELECT ` +
 		`SUBSTRING(p.name, 2, LEN(p.name)-1) AS param_name, ` +
-		`TYPE_NAME(p.user_type_id) AS param_type ` +
+		`TYPE_NAME(p.user_type_id) AS param_type, ` +
+		`IIF(p.is_output = 'true', 'INOUT', 'IN') AS param_mode ` +
 		`FROM sys.objects o ` +
 		`INNER JOIN sys.parameters p ON o.object_id = p.object_id ` +
 		`WHERE SCHEMA_NAME(schema_id) = @p1 ` +
 		`AND STR(o.object_id) = @p2 ` +
-		`AND p.is_output = 'false' ` +
+		`AND p.parameter_id > 0 ` +
 		`ORDER BY p.parameter_id`
 	// run
 	logf(sqlstr, schema, id)
@@ -113,7 +149,7 @@ func SqlserverProcParams(ctx context.Context, db DB, schema, id string) ([]*Proc
 	for rows.Next() {
 		var pp ProcParam
 		// scan
-		if err := rows.Scan(&pp.ParamName, &pp.ParamType); err != nil {
+		if err := rows.Scan(&pp.ParamName, &pp.ParamType, &pp.ParamMode); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &pp)
@@ -133,10 +169,11 @@ func OracleProcParams(ctx context.Context, db DB, schema, id string) ([]*ProcPar
 		`WHEN 'CHAR' THEN 'CHAR(' || a.data_length || ')' ` +
 		`WHEN 'VARCHAR2' THEN 'VARCHAR2(' || a.data_length || ')' ` +
 		`WHEN 'NUMBER' THEN 'NUMBER(' || NVL(a.data_precision, 0) || ',' || NVL(a.data_scale, 0) || ')' ` +
-		`ELSE a.data_type END) AS param_type ` +
+		`ELSE a.data_type END) AS param_type, ` +
+		`REPLACE(a.in_out, '/', '') AS param_mode ` +
 		`FROM all_objects o ` +
 		`JOIN sys.all_arguments a ON a.object_id = o.object_id ` +
-		`AND a.in_out = 'IN' ` +
+		`AND a.position > 0 ` +
 		`WHERE o.object_type IN ('FUNCTION','PROCEDURE') ` +
 		`AND o.owner = UPPER(:1) ` +
 		`AND CAST(o.object_id AS NVARCHAR2(255)) = UPPER(:2) ` +
@@ -153,7 +190,7 @@ func OracleProcParams(ctx context.Context, db DB, schema, id string) ([]*ProcPar
 	for rows.Next() {
 		var pp ProcParam
 		// scan
-		if err := rows.Scan(&pp.ParamName, &pp.ParamType); err != nil {
+		if err := rows.Scan(&pp.ParamName, &pp.ParamType, &pp.ParamMode); err != nil {
 			return nil, logerror(err)
 		}
 		res = append(res, &pp)
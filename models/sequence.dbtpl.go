@@ -8,9 +8,47 @@ import (
 
 // Sequence is a sequence.
 type Sequence struct {
+	TableName  string `json:"table_name"`  // table_name
 	ColumnName string `json:"column_name"` // column_name
 }
 
+// PostgresAllSequences runs a custom query, returning results as [Sequence].
+func PostgresAllSequences(ctx context.Context, db DB, schema string) ([]*Sequence, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`t.relname, ` + // ::varchar AS table_name
+		`a.attname ` + // ::varchar as column_name
+		`FROM pg_class s ` +
+		`JOIN pg_depend d ON d.objid = s.oid ` +
+		`JOIN pg_class t ON d.objid = s.oid AND d.refobjid = t.oid ` +
+		`JOIN pg_attribute a ON (d.refobjid, d.refobjsubid) = (a.attrelid, a.attnum) ` +
+		`JOIN pg_namespace n ON n.oid = s.relnamespace ` +
+		`WHERE s.relkind = 'S' ` +
+		`AND n.nspname = $1 ` +
+		`ORDER BY t.relname`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Sequence
+	for rows.Next() {
+		var s Sequence
+		// scan
+		if err := rows.Scan(&s.TableName, &s.ColumnName); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // PostgresTableSequences runs a custom query, returning results as [Sequence].
 func PostgresTableSequences(ctx context.Context, db DB, schema, table string) ([]*Sequence, error) {
 	// query
@@ -47,6 +85,39 @@ func PostgresTableSequences(ctx context.Context, db DB, schema, table string) ([
 	return res, nil
 }
 
+// MysqlAllSequences runs a custom query, returning results as [Sequence].
+func MysqlAllSequences(ctx context.Context, db DB, schema string) ([]*Sequence, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`table_name, ` +
+		`column_name ` +
+		`FROM information_schema.columns c ` +
+		`WHERE c.extra = 'auto_increment' ` +
+		`AND c.table_schema = ? ` +
+		`ORDER BY table_name`
+	// run
+	logf(sqlstr, schema)
+	rows, err := db.QueryContext(ctx, sqlstr, schema)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*Sequence
+	for rows.Next() {
+		var s Sequence
+		// scan
+		if err := rows.Scan(&s.TableName, &s.ColumnName); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
 // MysqlTableSequences runs a custom query, returning results as [Sequence].
 func MysqlTableSequences(ctx context.Context, db DB, schema, table string) ([]*Sequence, error) {
 	// query
@@ -0,0 +1,295 @@
+// Package sqllex splits SQL statements and expressions into tokens.
+//
+// The lexer is shared by the DDL loader, the query result introspection, and
+// the check constraint parser, and handles the quoting and comments of the
+// mysql, oracle, postgres, sqlite3 and sqlserver dialects.
+package sqllex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Type is a token type.
+type Type int
+
+// Token types.
+const (
+	EOF Type = iota
+	// Word is a keyword or unquoted identifier.
+	Word
+	// Ident is a quoted identifier.
+	Ident
+	// String is a string literal.
+	String
+	// Number is a numeric literal.
+	Number
+	// Punct is punctuation or an operator.
+	Punct
+)
+
+// Token is a SQL token.
+type Token struct {
+	Type Type
+	// Text is the token's text. Quoted identifiers and strings are unquoted.
+	Text string
+	// Pos and End are the token's offsets in the source.
+	Pos, End int
+}
+
+// IsWord determines if the token is the (case-insensitive) keyword.
+func (tok Token) IsWord(word string) bool {
+	return tok.Type == Word && strings.EqualFold(tok.Text, word)
+}
+
+// IsPunct determines if the token is the punctuation or operator.
+func (tok Token) IsPunct(s string) bool {
+	return tok.Type == Punct && tok.Text == s
+}
+
+// IsName determines if the token is an unquoted or quoted identifier.
+func (tok Token) IsName() bool {
+	return tok.Type == Word || tok.Type == Ident
+}
+
+// Split splits src into statements of tokens.
+//
+// Comments are discarded, and quoted identifiers and strings are unquoted.
+// MySQL DELIMITER commands and executable comments (/*!NNNNN ... */) are
+// handled for the mysql dialect. Semicolons ending a trigger's BEGIN ... END
+// body do not end the statement.
+func Split(dialect, src string) ([][]Token, error) {
+	return lex(dialect, src, true)
+}
+
+// Lex splits src, a single statement or expression, into tokens. Semicolons
+// are returned as punctuation.
+func Lex(dialect, src string) ([]Token, error) {
+	stmts, err := lex(dialect, src, false)
+	if err != nil || len(stmts) == 0 {
+		return nil, err
+	}
+	return stmts[0], nil
+}
+
+// Depth returns the parenthesis depth of each token.
+func Depth(toks []Token) []int {
+	depth := make([]int, len(toks))
+	d := 0
+	for i, tok := range toks {
+		if tok.IsPunct(")") {
+			d--
+		}
+		depth[i] = d
+		if tok.IsPunct("(") {
+			d++
+		}
+	}
+	return depth
+}
+
+// lex splits src into statements of tokens, splitting statements when split
+// is true.
+func lex(dialect, src string, split bool) ([][]Token, error) {
+	var stmts [][]Token
+	var stmt []Token
+	// flush adds the current statement
+	flush := func() {
+		if len(stmt) != 0 {
+			stmts = append(stmts, stmt)
+		}
+		stmt = nil
+	}
+	brackets := dialect == "sqlite3" || dialect == "sqlserver"
+	delim, exec := ";", 0
+	for i := 0; i < len(src); {
+		c, next := src[i], byte(0)
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+		start := i
+		switch {
+		case split && dialect == "mysql" && atLineStart(src, i) && hasPrefixFold(src[i:], "delimiter "):
+			end := strings.IndexByte(src[i:], '\n')
+			if end == -1 {
+				end = len(src) - i
+			}
+			delim, i = strings.TrimSpace(src[i+len("delimiter "):i+end]), i+end
+			if delim == "" {
+				return nil, fmt.Errorf("line %d: invalid delimiter", lineNumber(src, start))
+			}
+			flush()
+		case split && delim != ";" && strings.HasPrefix(src[i:], delim):
+			flush()
+			i += len(delim)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && next == '-', c == '#' && dialect == "mysql":
+			if end := strings.IndexByte(src[i:], '\n'); end != -1 {
+				i += end + 1
+			} else {
+				i = len(src)
+			}
+		case c == '/' && next == '*' && dialect == "mysql" && strings.HasPrefix(src[i:], "/*!"):
+			for i += 3; i < len(src) && isDigit(src[i]); i++ {
+			}
+			exec++
+		case c == '*' && next == '/' && exec != 0:
+			i, exec = i+2, exec-1
+		case c == '/' && next == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated comment", lineNumber(src, start))
+			}
+			i += end + 4
+		case c == '\'', c == '"' && dialect == "mysql":
+			s, end, err := lexQuoted(src, i, c, dialect == "mysql")
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber(src, start), err)
+			}
+			stmt, i = append(stmt, Token{Type: String, Text: s, Pos: start, End: end}), end
+		case c == '"', c == '`':
+			s, end, err := lexQuoted(src, i, c, false)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber(src, start), err)
+			}
+			stmt, i = append(stmt, Token{Type: Ident, Text: s, Pos: start, End: end}), end
+		case c == '[' && brackets:
+			end := strings.IndexByte(src[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated identifier", lineNumber(src, start))
+			}
+			stmt, i = append(stmt, Token{Type: Ident, Text: src[i+1 : i+end], Pos: start, End: i + end + 1}), i+end+1
+		case c == '$' && dialect == "postgres" && dollarRE.MatchString(src[i:]):
+			tag := dollarRE.FindString(src[i:])
+			end := strings.Index(src[i+len(tag):], tag)
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated dollar quoted string", lineNumber(src, start))
+			}
+			s := src[i+len(tag) : i+len(tag)+end]
+			i += len(tag) + end + len(tag)
+			stmt = append(stmt, Token{Type: String, Text: s, Pos: start, End: i})
+		case isDigit(c), c == '.' && isDigit(next):
+			for i++; i < len(src) && (isDigit(src[i]) || src[i] == '.' || isIdentStart(src[i])); i++ {
+			}
+			stmt = append(stmt, Token{Type: Number, Text: src[start:i], Pos: start, End: i})
+		case isIdentStart(c):
+			for i++; i < len(src) && (isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '$'); i++ {
+				if split && delim != ";" && strings.HasPrefix(src[i:], delim) {
+					break
+				}
+			}
+			stmt = append(stmt, Token{Type: Word, Text: src[start:i], Pos: start, End: i})
+		case split && c == ';' && delim == ";" && !inTriggerBody(stmt):
+			flush()
+			i++
+		default:
+			n := 1
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					n = len(op)
+					break
+				}
+			}
+			stmt, i = append(stmt, Token{Type: Punct, Text: src[i : i+n], Pos: start, End: i + n}), i+n
+		}
+	}
+	if exec != 0 {
+		return nil, fmt.Errorf("unterminated executable comment")
+	}
+	flush()
+	return stmts, nil
+}
+
+// operators are the multi-character operators, longest first. Any other
+// character is a single punctuation token.
+var operators = []string{
+	"->>", "#>>", "!~*",
+	"::", ":=", "=>", "<=", ">=", "<>", "!=", "||", "&&", "<<", ">>",
+	"->", "#>", "@>", "<@", "~*", "!~",
+}
+
+// lexQuoted reads the quoted string starting at src[i], returning the unquoted
+// string and the end position. A doubled quote is an escaped quote, as is a
+// backslash escaped quote when backslash is true.
+func lexQuoted(src string, i int, quote byte, backslash bool) (string, int, error) {
+	var sb strings.Builder
+	for i++; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && backslash && i+1 < len(src):
+			i++
+			sb.WriteByte(unescape(src[i]))
+		case c == quote && i+1 < len(src) && src[i+1] == quote:
+			i++
+			sb.WriteByte(quote)
+		case c == quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string")
+}
+
+// unescape returns the character for a backslash escape.
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case '0':
+		return 0
+	}
+	return c
+}
+
+// inTriggerBody determines if the statement is a trigger with an unterminated
+// BEGIN ... END body.
+func inTriggerBody(stmt []Token) bool {
+	if len(stmt) < 2 || !stmt[0].IsWord("create") {
+		return false
+	}
+	var trigger, begin bool
+	for _, tok := range stmt[1:] {
+		switch {
+		case tok.IsWord("trigger"):
+			trigger = true
+		case trigger && tok.IsWord("begin"):
+			begin = true
+		}
+	}
+	return begin && !stmt[len(stmt)-1].IsWord("end")
+}
+
+// atLineStart determines if i is the first non-space position on a line.
+func atLineStart(src string, i int) bool {
+	j := strings.LastIndexByte(src[:i], '\n')
+	return strings.TrimSpace(src[j+1:i]) == ""
+}
+
+// hasPrefixFold is a case-insensitive strings.HasPrefix.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// lineNumber returns the line number of i in src.
+func lineNumber(src string, i int) int {
+	return strings.Count(src[:i], "\n") + 1
+}
+
+// isDigit determines if c is a digit.
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isIdentStart determines if c can start an identifier.
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+// dollarRE matches the opening tag of a postgres dollar quoted string.
+var dollarRE = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
@@ -0,0 +1,87 @@
+package sqllex
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		dialect string
+		src     string
+		exp     []string
+	}{
+		{"postgres", `SELECT a.b::text[], 'it''s' -- comment`, []string{"w:SELECT", "w:a", "p:.", "w:b", "p:::", "w:text", "p:[", "p:]", "p:,", "s:it's"}},
+		{"postgres", `x->>'a' <= $1 || $$b;$$;`, []string{"w:x", "p:->>", "s:a", "p:<=", "p:$", "n:1", "p:||", "s:b;", "p:;"}},
+		{"postgres", `"Quoted" /* c */ 1.5e3 .5`, []string{"i:Quoted", "n:1.5e3", "n:.5"}},
+		{"mysql", "`a` = \"b\\\"\" # comment\n/*!50001 AND c */", []string{"i:a", "p:=", `s:b"`, "w:AND", "w:c"}},
+		{"mysql", `_utf8mb4'a' <> N'b'`, []string{"w:_utf8mb4", "s:a", "p:<>", "w:N", "s:b"}},
+		{"sqlserver", `[a b] >= -1`, []string{"i:a b", "p:>=", "p:-", "n:1"}},
+		{"sqlite3", `[a] = 1`, []string{"i:a", "p:=", "n:1"}},
+		{"oracle", `a = -1`, []string{"w:a", "p:=", "p:-", "n:1"}},
+	}
+	for i, test := range tests {
+		toks, err := Lex(test.dialect, test.src)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if s := tokenStrings(toks); !reflect.DeepEqual(s, test.exp) {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+		for _, tok := range toks {
+			if tok.Pos >= tok.End || tok.End > len(test.src) {
+				t.Errorf("test %d token %q has invalid position %d:%d", i, tok.Text, tok.Pos, tok.End)
+			}
+		}
+	}
+	for _, src := range []string{`'a`, `"a`, `/* a`, `$$a`} {
+		if _, err := Lex("postgres", src); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		dialect string
+		src     string
+		exp     []string
+	}{
+		{"postgres", "CREATE TABLE a (b int); ; DROP TABLE a", []string{"w:CREATE w:TABLE w:a p:( w:b w:int p:)", "w:DROP w:TABLE w:a"}},
+		{"mysql", "DELIMITER $$\nCREATE PROCEDURE p() BEGIN SELECT 1; END$$\nDELIMITER ;\nSELECT 2;", []string{"w:CREATE w:PROCEDURE w:p p:( p:) w:BEGIN w:SELECT n:1 p:; w:END", "w:SELECT n:2"}},
+		{"sqlite3", "CREATE TRIGGER t AFTER INSERT ON a BEGIN SELECT 1; END; SELECT 2", []string{"w:CREATE w:TRIGGER w:t w:AFTER w:INSERT w:ON w:a w:BEGIN w:SELECT n:1 p:; w:END", "w:SELECT n:2"}},
+	}
+	for i, test := range tests {
+		stmts, err := Split(test.dialect, test.src)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		var s []string
+		for _, stmt := range stmts {
+			s = append(s, strings.Join(tokenStrings(stmt), " "))
+		}
+		if !reflect.DeepEqual(s, test.exp) {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	toks, err := Lex("postgres", `a(b, (c)) d`)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp, depth := []int{0, 0, 1, 1, 1, 2, 1, 0, 0}, Depth(toks); !reflect.DeepEqual(depth, exp) {
+		t.Errorf("expected %v, got: %v", exp, depth)
+	}
+}
+
+// tokenStrings returns the tokens as "<type>:<text>" strings.
+func tokenStrings(toks []Token) []string {
+	var s []string
+	for _, tok := range toks {
+		s = append(s, string("?wisnp"[tok.Type])+":"+tok.Text)
+	}
+	return s
+}
@@ -11,11 +11,10 @@ import (
 	"io/fs"
 	"os"
 	"os/exec"
-	"regexp"
 	"strings"
 	"text/template"
-	"unicode"
 
+	"github.com/xo/dbtpl/ddl"
 	xo "github.com/xo/dbtpl/types"
 )
 
@@ -42,24 +41,24 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				Default:    fmtOpts,
 			},
 			{
-				ContextKey: ConstraintKey,
+				ContextKey: ddl.ConstraintKey,
 				Type:       "bool",
 				Desc:       "enable constraint name in output",
 			},
 			{
-				ContextKey: EscKey,
+				ContextKey: ddl.EscKey,
 				Type:       "string",
 				Desc:       "escape mode",
 				Enums:      []string{"none", "types", "all"},
 			},
 			{
-				ContextKey: EngineKey,
+				ContextKey: ddl.EngineKey,
 				Type:       "string",
 				Desc:       "mysql table engine",
 				Default:    "InnoDB",
 			},
 			{
-				ContextKey: TrimCommentKey,
+				ContextKey: ddl.TrimCommentKey,
 				Type:       "bool",
 				Desc:       "trim leading comment from views and procs",
 				Default:    true,
@@ -149,7 +148,7 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 			for file, content := range files {
 				// skip
 				if fmtPath == "" {
-					emit(file, cleanEnd(cleanRE.ReplaceAll(content, []byte("$1\n\n--"))))
+					emit(file, ddl.Clean(content))
 					continue
 				}
 				// execute
@@ -159,7 +158,7 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				if err := cmd.Run(); err != nil {
 					return fmt.Errorf("unable to execute %s: %v: %s", fmtPath, err, stderr.String())
 				}
-				emit(file, cleanEnd(stdout.Bytes()))
+				emit(file, ddl.CleanEnd(stdout.Bytes()))
 			}
 			return nil
 		},
@@ -167,15 +166,6 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 	return nil
 }
 
-// cleanRE matches empty lines.
-var cleanRE = regexp.MustCompile(`([\.;])\n{2,}--`)
-
-// cleanEnd trims the end of any spaces, ensuring it ends with exactly one
-// newline.
-func cleanEnd(buf []byte) []byte {
-	return append(bytes.TrimRightFunc(buf, unicode.IsSpace), '\n')
-}
-
 // sortTables sorts tables.
 func sortTables(tables []xo.Table) []xo.Table {
 	m := make(map[string]xo.Table)
@@ -214,153 +204,36 @@ func sortAppendTable(m map[string]xo.Table, seen map[string]bool, sorted []xo.Ta
 
 // Funcs is a set of template funcs.
 type Funcs struct {
-	driver      string
-	constraint  bool
-	escCols     bool
-	escTypes    bool
-	engine      string
-	trimComment bool
+	d *ddl.Funcs
 }
 
 // NewFuncs creates custom template funcs for the context.
 func NewFuncs(ctx context.Context, _ string) (template.FuncMap, error) {
-	driver, _, _ := xo.DriverDbSchema(ctx)
+	d := ddl.NewFuncs(ctx)
 	funcs := &Funcs{
-		driver:      driver,
-		constraint:  Constraint(ctx),
-		escCols:     Esc(ctx, "columns"),
-		escTypes:    Esc(ctx, "types"),
-		engine:      Engine(ctx),
-		trimComment: TrimComment(ctx),
+		d: d,
 	}
 	return template.FuncMap{
-		"coldef":          funcs.coldef,
-		"viewdef":         funcs.viewdef,
+		"coldef":          d.Coldef,
+		"viewdef":         d.Viewdef,
 		"procdef":         funcs.procdef,
-		"driver":          funcs.driverfn,
-		"constraint":      funcs.constraintfn,
-		"esc":             funcs.escType,
-		"fields":          funcs.fields,
-		"engine":          funcs.enginefn,
-		"literal":         funcs.literal,
-		"isEndConstraint": funcs.isEndConstraint,
-		"comma":           comma,
+		"checkdef":        d.Checkdef,
+		"driver":          d.IsDriver,
+		"constraint":      d.ConstraintName,
+		"esc":             d.EscType,
+		"fields":          d.Fields,
+		"engine":          d.EngineDef,
+		"literal":         d.Literal,
+		"isEndConstraint": d.IsEndConstraint,
+		"comma":           ddl.Comma,
 	}, nil
 }
 
-// coldef generates a column definition.
-func (f *Funcs) coldef(table xo.Table, field xo.Field) string {
-	// normalize type
-	typ := f.normalize(field.Type)
-	// add sequence definition
-	if field.IsSequence {
-		typ = f.resolveSequence(typ, field)
-	}
-	// column def
-	def := []string{f.escCol(field.Name), typ}
-	// add default value
-	if field.Default != "" && !field.IsSequence {
-		def = append(def, "DEFAULT", f.alterDefault(field.Default))
-	}
-	if !field.Type.Nullable && !field.IsSequence {
-		def = append(def, "NOT NULL")
-	}
-	// add constraints
-	if fk := f.colFKey(table, field); fk != "" {
-		def = append(def, fk)
-	}
-	return strings.Join(def, " ")
-}
-
-// alterDefault parses and alters default column values based on the driver.
-func (f *Funcs) alterDefault(s string) string {
-	switch f.driver {
-	case "postgres":
-		if m := postgresDefaultCastRE.FindStringSubmatch(s); m != nil {
-			return m[1]
-		}
-	case "mysql":
-		if v := strings.ToUpper(s); v == "CURRENT_TIMESTAMP()" {
-			return "CURRENT_TIMESTAMP"
-		}
-	case "sqlite3":
-		if !sqliteDefaultNeedsParenRE.MatchString(s) {
-			return "(" + s + ")"
-		}
-	}
-	return s
-}
-
-// postgresDefaultCastRE is the regexp to strip the datatype cast from the
-// postgres default value.
-var postgresDefaultCastRE = regexp.MustCompile(`(.*)::[a-zA-Z_ ]*(\[\])?$`)
-
-// sqliteDefaultNeedsParen is the regexp to test whether the given value is
-// correctly surrounded with parenthesis
-//
-// If it starts and ends with a parenthesis or a single or double quote, it
-// does not need to be quoted with parenthesis.
-var sqliteDefaultNeedsParenRE = regexp.MustCompile(`^([\('"].*[\)'"]|\d+)$`)
-
-// resolveSequence resolves a sequence name.
-func (f *Funcs) resolveSequence(typ string, field xo.Field) string {
-	switch f.driver {
-	case "postgres":
-		switch typ {
-		case "SMALLINT":
-			return "SMALLSERIAL"
-		case "INTEGER":
-			return "SERIAL"
-		case "BIGINT":
-			return "BIGSERIAL"
-		}
-	case "mysql":
-		return typ + " AUTO_INCREMENT"
-	case "sqlite3":
-		ext := " PRIMARY KEY AUTOINCREMENT"
-		if !field.Type.Nullable {
-			ext = " NOT NULL" + ext
-		}
-		return typ + ext
-	case "sqlserver":
-		return typ + " IDENTITY(1, 1)"
-	case "oracle":
-		return typ + " GENERATED ALWAYS AS IDENTITY"
-	}
-	return ""
-}
-
-// colFKey
-func (f *Funcs) colFKey(table xo.Table, field xo.Field) string {
-	for _, fk := range table.ForeignKeys {
-		if len(fk.Fields) == 1 && fk.Fields[0] == field {
-			tblName, fieldName := f.escType(fk.RefTable), fk.RefFields[0].Name
-			return fmt.Sprintf("%sREFERENCES %s (%s)", f.constraintfn(fk.Name), tblName, fieldName)
-		}
-	}
-	return ""
-}
-
-// viewdef generates a view definition.
-func (f *Funcs) viewdef(view xo.Table) string {
-	def := view.Definition
-	switch f.driver {
-	case "postgres", "mysql", "oracle":
-		def = fmt.Sprintf("CREATE VIEW %s AS\n%s", f.escType(view.Name), view.Definition)
-	}
-	if f.trimComment {
-		if strings.HasPrefix(def, "--") {
-			def = def[strings.Index(def, "\n")+1:]
-		}
-	}
-	return strings.TrimSuffix(def, ";")
-}
-
 // procdef generates a proc definition.
 func (f *Funcs) procdef(proc xo.Proc) string {
 	def := f.cleanProcDef(proc.Definition)
 	// prepend signature definition
-	if f.driver == "postgres" || f.driver == "mysql" {
+	if f.d.Driver == "postgres" || f.d.Driver == "mysql" {
 		def = f.procSignature(proc) + "\n" + def
 	}
 	return def
@@ -368,7 +241,7 @@ func (f *Funcs) procdef(proc xo.Proc) string {
 
 // celanProcDef cleans a proc definition.
 func (f *Funcs) cleanProcDef(def string) string {
-	switch f.driver {
+	switch f.d.Driver {
 	// nothing needs to be done for postgres
 	// only add the query language suffix
 	case "postgres":
@@ -380,7 +253,7 @@ func (f *Funcs) cleanProcDef(def string) string {
 	case "oracle":
 		def = "CREATE " + def
 	}
-	if f.trimComment {
+	if f.d.TrimComment {
 		if strings.HasPrefix(def, "--") {
 			def = def[strings.Index(def, "\n")+1:]
 		}
@@ -398,193 +271,45 @@ func (f *Funcs) procSignature(proc xo.Proc) string {
 	var params []string
 	var end string
 	// add params
+	outs := make(map[string]bool)
 	for _, field := range proc.Params {
-		params = append(params, fmt.Sprintf("%s %s", f.escCol(field.Name), f.normalize(field.Type)))
-	}
-	// add return values
-	if len(proc.Returns) == 1 && proc.Returns[0].Name == "r0" {
-		end += " RETURNS " + f.normalize(proc.Returns[0].Type)
-	} else {
+		var dir string
+		if field.Direction != "" {
+			dir, outs[field.Name] = strings.ToUpper(field.Direction)+" ", true
+		}
+		params = append(params, fmt.Sprintf("%s%s %s", dir, f.d.EscCol(field.Name), f.d.Normalize(field.Type)))
+	}
+	// add return values, skipping out params already added
+	switch {
+	case proc.Set && len(proc.Returns) == 1 && proc.Returns[0].Name == "r0":
+		end += " RETURNS SETOF " + f.d.Normalize(proc.Returns[0].Type)
+	case proc.Set:
+		var cols []string
 		for _, field := range proc.Returns {
-			params = append(params, fmt.Sprintf("OUT %s %s", f.escCol(field.Name), f.normalize(field.Type)))
+			cols = append(cols, fmt.Sprintf("%s %s", f.d.EscCol(field.Name), f.d.Normalize(field.Type)))
+		}
+		end += " RETURNS TABLE(" + strings.Join(cols, ", ") + ")"
+	case len(proc.Returns) == 1 && proc.Returns[0].Name == "r0":
+		end += " RETURNS " + f.d.Normalize(proc.Returns[0].Type)
+	default:
+		for _, field := range proc.Returns {
+			if outs[field.Name] {
+				continue
+			}
+			params = append(params, fmt.Sprintf("OUT %s %s", f.d.EscCol(field.Name), f.d.Normalize(field.Type)))
 		}
 	}
-	signature := fmt.Sprintf("CREATE %s %s(%s)%s", typ, f.escType(proc.Name), strings.Join(params, ", "), end)
-	if f.driver == "postgres" {
+	signature := fmt.Sprintf("CREATE %s %s(%s)%s", typ, f.d.EscType(proc.Name), strings.Join(params, ", "), end)
+	if f.d.Driver == "postgres" {
 		signature += " AS $$"
 	}
 	return signature
 }
 
-// driverfn determines if a driver is allowed.
-func (f *Funcs) driverfn(allowed ...string) bool {
-	for _, d := range allowed {
-		if f.driver == d {
-			return true
-		}
-	}
-	return false
-}
-
-// contstraintfn
-func (f *Funcs) constraintfn(name string) string {
-	if f.constraint || f.driver == "sqlserver" || f.driver == "oracle" {
-		return fmt.Sprintf("CONSTRAINT %s ", f.escType(name))
-	}
-	return ""
-}
-
-// fields
-func (f *Funcs) fields(v any) string {
-	switch x := v.(type) {
-	case []xo.Field:
-		var fs []string
-		for _, field := range x {
-			fs = append(fs, f.escCol(field.Name))
-		}
-		return strings.Join(fs, ", ")
-	}
-	return fmt.Sprintf("[[ UNKNOWN TYPE %T ]]", v)
-}
-
-// esc escapes s.
-func (f *Funcs) esc(s string, esc bool) string {
-	if !esc {
-		return s
-	}
-	var start, end string
-	switch f.driver {
-	case "postgres", "sqlite3", "oracle":
-		start, end = `"`, `"`
-	case "mysql":
-		start, end = "`", "`"
-	case "sqlserver":
-		start, end = "[", "]"
-	}
-	return start + s + end
-}
-
-// escType
-func (f *Funcs) escType(value string) string {
-	return f.esc(value, f.escTypes)
-}
-
-// escCol
-func (f *Funcs) escCol(value string) string {
-	return f.esc(value, f.escCols)
-}
-
-// enginefn returns the engine for the database (mysql).
-func (f *Funcs) enginefn() string {
-	if f.driver != "mysql" || f.engine == "" {
-		return ""
-	}
-	return fmt.Sprintf(" ENGINE=%s", f.engine)
-}
-
-// normalize normalizes a datatype.
-func (f *Funcs) normalize(datatype xo.Type) string {
-	typ := f.convert(datatype)
-	if datatype.Scale > 0 && !omitPrecision[f.driver][typ] {
-		typ += fmt.Sprintf("(%d, %d)", datatype.Prec, datatype.Scale)
-	} else if datatype.Prec > 0 && !omitPrecision[f.driver][typ] {
-		typ += fmt.Sprintf("(%d)", datatype.Prec)
-	}
-	if datatype.Unsigned {
-		typ += " UNSIGNED"
-	}
-	if datatype.IsArray {
-		typ += "[]"
-	}
-	return typ
-}
-
-// convert converts
-func (f *Funcs) convert(datatype xo.Type) string {
-	// mysql enums
-	if f.driver == "mysql" && datatype.Enum != nil {
-		var enums []string
-		for _, v := range datatype.Enum.Values {
-			enums = append(enums, fmt.Sprintf("'%s'", v.Name))
-		}
-		return fmt.Sprintf("ENUM(%s)", strings.Join(enums, ", "))
-	}
-	// check aliases
-	typ := datatype.Type
-	if alias, ok := typeAliases[f.driver][typ]; ok {
-		typ = alias
-	}
-	return strings.ToUpper(typ)
-}
-
-// literal properly escapes string literals within single quotes
-// (Used for enum values in postgres)
-func (f *Funcs) literal(literal string) string {
-	return fmt.Sprint("'", strings.ReplaceAll(literal, "'", "''"), "'")
-}
-
-func (f *Funcs) isEndConstraint(idx xo.Index) bool {
-	if f.driver == "sqlite3" && idx.Fields[0].IsSequence {
-		return false
-	}
-	return idx.IsPrimary || idx.IsUnique
-}
-
-var typeAliases = map[string]map[string]string{
-	"postgres": {
-		"character varying":           "varchar",
-		"character":                   "char",
-		"time without time zone":      "time",
-		"timestamp without time zone": "timestamp",
-		"time with time zone":         "timetz",
-		"timestamp with time zone":    "timestamptz",
-	},
-}
-
-var omitPrecision = map[string]map[string]bool{
-	"sqlserver": {
-		"TINYINT":        true,
-		"SMALLINT":       true,
-		"INT":            true,
-		"BIGINT":         true,
-		"REAL":           true,
-		"SMALLMONEY":     true,
-		"MONEY":          true,
-		"BIT":            true,
-		"DATE":           true,
-		"TIME":           true,
-		"DATETIME":       true,
-		"DATETIME2":      true,
-		"SMALLDATETIME":  true,
-		"DATETIMEOFFSET": true,
-	},
-	"oracle": {
-		"TIMESTAMP":                      true,
-		"TIMESTAMP WITH TIME ZONE":       true,
-		"TIMESTAMP WITH LOCAL TIME ZONE": true,
-	},
-}
-
-func comma(i int, v any) string {
-	var l int
-	switch x := v.(type) {
-	case []xo.Field:
-		l = len(x)
-	}
-	if i+1 < l {
-		return ","
-	}
-	return ""
-}
-
 // Context keys.
 var (
-	FmtKey         xo.ContextKey = "fmt"
-	FmtOptsKey     xo.ContextKey = "fmt-opts"
-	ConstraintKey  xo.ContextKey = "constraint"
-	EscKey         xo.ContextKey = "escape"
-	EngineKey      xo.ContextKey = "engine"
-	TrimCommentKey xo.ContextKey = "trim-comment"
+	FmtKey     xo.ContextKey = "fmt"
+	FmtOptsKey xo.ContextKey = "fmt-opts"
 )
 
 // Append returns append from the context.
@@ -605,30 +330,6 @@ func FmtOpts(ctx context.Context) []string {
 	return v
 }
 
-// Constraint returns constraint from the context.
-func Constraint(ctx context.Context) bool {
-	b, _ := ctx.Value(ConstraintKey).(bool)
-	return b
-}
-
-// Esc returns esc from the context.
-func Esc(ctx context.Context, esc string) bool {
-	v, _ := ctx.Value(EscKey).(string)
-	return v == "all" || v == esc
-}
-
-// Engine returns engine from the context.
-func Engine(ctx context.Context) string {
-	s, _ := ctx.Value(EngineKey).(string)
-	return s
-}
-
-// TrimComment returns trim-comments from the context.
-func TrimComment(ctx context.Context) bool {
-	b, _ := ctx.Value(TrimCommentKey).(bool)
-	return b
-}
-
 // Lang returns the sql-formatter language to use from the context based on the
 // context driver.
 func Lang(ctx context.Context) string {
@@ -27,7 +27,10 @@ CREATE TABLE {{ esc $t.Name }} (
 {{- end -}}{{- end -}}
 {{- range $fk := $t.ForeignKeys -}}{{- if gt (len $fk.Fields) 1 }},
   {{ constraint $fk.Name -}} FOREIGN KEY ({{ fields $fk.Fields }}) REFERENCES {{ esc $fk.RefTable }} ({{ fields $fk.RefFields }})
-{{- end -}}{{- end }}
+{{- end -}}{{- end -}}
+{{- range $c := $t.Checks }},
+  {{ checkdef $c }}
+{{- end }}
 ){{ engine }};
 {{- if $t.Indexes }}
 {{ range $idx := $t.Indexes }}{{ if not (or $idx.IsPrimary $idx.IsUnique) }}
@@ -91,6 +91,8 @@ const (
 	ErrDoesNotExist Error = "does not exist"
 	// ErrMarkedForDeletion is the marked for deletion error.
 	ErrMarkedForDeletion Error = "marked for deletion"
+	// ErrMissingResultSet is the missing result set error.
+	ErrMissingResultSet Error = "missing result set"
 )
 
 // ErrInsertFailed is the insert failed error.
@@ -138,6 +140,27 @@ func (err *ErrUpsertFailed) Unwrap() error {
 	return err.Err
 }
 
+// ErrUnexpectedRows is the unexpected rows affected error.
+type ErrUnexpectedRows struct {
+	Expected int64
+	Affected int64
+}
+
+// Error satisfies the error interface.
+func (err *ErrUnexpectedRows) Error() string {
+	return fmt.Sprintf("expected %d rows affected, got: %d", err.Expected, err.Affected)
+}
+
+// ErrCheckFailed is the check constraint failed error.
+type ErrCheckFailed struct {
+	Name string
+}
+
+// Error satisfies the error interface.
+func (err *ErrCheckFailed) Error() string {
+	return fmt.Sprintf("check constraint %s failed", err.Name)
+}
+
 {{ if driver "sqlite3" -}}
 // ErrInvalidTime is the invalid Time error.
 type ErrInvalidTime string
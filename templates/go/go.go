@@ -279,8 +279,11 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 		},
 		Process: func(ctx context.Context, mode string, set *xo.Set, emit func(xo.Template)) error {
 			if mode == "query" {
+				// types tracks emitted type definitions, as multiple queries
+				// can share the same type
+				types := make(map[string]bool)
 				for _, query := range set.Queries {
-					if err := emitQuery(ctx, query, emit); err != nil {
+					if err := emitQuery(ctx, types, query, emit); err != nil {
 						return err
 					}
 				}
@@ -336,10 +339,10 @@ func fileNames(ctx context.Context, mode string, set *xo.Set) (map[string]bool,
 	case "schema":
 		for _, schema := range set.Schemas {
 			for _, e := range schema.Enums {
-				addFile(camelExport(e.Name))
+				addFile(schemaGoName(ctx, schema.Name, e.Name))
 			}
 			for _, p := range schema.Procs {
-				goName := camelExport(p.Name)
+				goName := schemaGoName(ctx, schema.Name, p.Name)
 				if p.Type == "function" {
 					addFile("sf_" + goName)
 				} else {
@@ -347,10 +350,10 @@ func fileNames(ctx context.Context, mode string, set *xo.Set) (map[string]bool,
 				}
 			}
 			for _, t := range schema.Tables {
-				addFile(camelExport(singularize(t.Name)))
+				addFile(schemaGoName(ctx, schema.Name, singularize(t.Name)))
 			}
 			for _, v := range schema.Views {
-				addFile(camelExport(singularize(v.Name)))
+				addFile(schemaGoName(ctx, schema.Name, singularize(v.Name)))
 			}
 		}
 	case "query":
@@ -369,7 +372,7 @@ func fileNames(ctx context.Context, mode string, set *xo.Set) (map[string]bool,
 }
 
 // emitQuery emits the query.
-func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) error {
+func emitQuery(ctx context.Context, types map[string]bool, query xo.Query, emit func(xo.Template)) error {
 	var table Table
 	// build type if needed
 	if !query.Exec {
@@ -378,11 +381,46 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 			return err
 		}
 	}
-	// emit type definition
-	if !query.Exec && !query.Flat && !Append(ctx) {
+	// build result set types
+	var results []Table
+	for _, r := range query.Results {
+		fields, err := buildQueryFields(ctx, r.Fields, false, r.ManualFields)
+		if err != nil {
+			return err
+		}
+		name := query.Type + camelExport(r.Name)
+		results = append(results, Table{
+			GoName:  name,
+			SQLName: snake(name),
+			Fields:  fields,
+		})
+		table.Fields = append(table.Fields, Field{
+			GoName:  camelExport(r.Name),
+			SQLName: snake(r.Name),
+			Type:    "[]*" + name,
+		})
+	}
+	if len(results) != 0 && table.Comment == "" {
+		table.Comment = "{{ . }} represents the result sets returned by the " + buildQueryName(query) + " query."
+	}
+	for _, t := range results {
+		if !Append(ctx) && !types[t.GoName] {
+			types[t.GoName] = true
+			emit(xo.Template{
+				Partial:  "typedef",
+				Dest:     strings.ToLower(query.Type) + ext,
+				SortType: query.Type,
+				SortName: query.Name,
+				Data:     t,
+			})
+		}
+	}
+	// emit type definition, unless reusing a table type
+	if !query.Exec && !query.Flat && !Append(ctx) && !types[table.GoName] && len(query.Tables) != 1 {
+		types[table.GoName] = true
 		emit(xo.Template{
 			Partial:  "typedef",
-			Dest:     strings.ToLower(table.GoName) + ext,
+			Dest:     strings.ToLower(query.Type) + ext,
 			SortType: query.Type,
 			SortName: query.Name,
 			Data:     table,
@@ -391,17 +429,41 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 	// build query params
 	var params []QueryParam
 	for _, param := range query.Params {
+		typ := param.Type.Type
+		// inferred params have the database type
+		if param.Inferred {
+			var err error
+			if typ, _, err = goType(ctx, param.Type); err != nil {
+				return err
+			}
+			// inferred expanded params have the element type
+			if param.Expand {
+				typ = "[]" + typ
+			}
+		}
 		params = append(params, QueryParam{
 			Name:        param.Name,
-			Type:        param.Type.Type,
+			Type:        typ,
 			Interpolate: param.Interpolate,
 			Join:        param.Join,
+			Expand:      param.Expand,
 		})
 	}
+	// mark reused table types with primary keys as existing
+	var exists []string
+	for i, t := range query.Tables {
+		switch {
+		case len(t.PrimaryKeys) == 0:
+		case len(query.Tables) == 1:
+			exists = append(exists, "_exists")
+		default:
+			exists = append(exists, table.Embeds[i]+"._exists")
+		}
+	}
 	// emit query
 	emit(xo.Template{
 		Partial:  "query",
-		Dest:     strings.ToLower(table.GoName) + ext,
+		Dest:     strings.ToLower(query.Type) + ext,
 		SortType: query.Type,
 		SortName: query.Name,
 		Data: Query{
@@ -409,11 +471,15 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 			Query:       query.Query,
 			Comments:    query.Comments,
 			Params:      params,
-			One:         query.Exec || query.Flat || query.One,
+			One:         query.Exec || query.Flat || query.One || len(results) != 0,
 			Flat:        query.Flat,
 			Exec:        query.Exec,
+			ExecRows:    query.ExecRows,
+			ExpectRows:  query.ExpectRows,
 			Interpolate: query.Interpolate,
 			Type:        table,
+			Results:     results,
+			Exists:      exists,
 			Comment:     query.Comment,
 		},
 	})
@@ -421,33 +487,87 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 }
 
 func buildQueryType(ctx context.Context, query xo.Query) (Table, error) {
+	// reuse the table types matching the results, embedding multiple tables
+	switch len(query.Tables) {
+	case 0:
+	case 1:
+		return convertTable(ctx, "", query.Tables[0])
+	default:
+		table := Table{
+			GoName:  query.Type,
+			SQLName: snake(query.Type),
+			Comment: query.TypeComment,
+		}
+		for _, t := range query.Tables {
+			embed, err := convertTable(ctx, "", t)
+			if err != nil {
+				return Table{}, err
+			}
+			// scan to the embedded table's fields
+			for _, f := range embed.Fields {
+				f.GoName = embed.GoName + "." + f.GoName
+				table.Fields = append(table.Fields, f)
+			}
+			table.Embeds = append(table.Embeds, embed.GoName)
+		}
+		return table, nil
+	}
+	fields, err := buildQueryFields(ctx, query.Fields, query.Flat, query.ManualFields)
+	if err != nil {
+		return Table{}, err
+	}
+	sqlName := snake(query.Type)
+	return Table{
+		GoName:  query.Type,
+		SQLName: sqlName,
+		Fields:  fields,
+		Comment: query.TypeComment,
+	}, nil
+}
+
+// buildQueryFields builds the fields of a query type. Flat query fields are
+// unexported, and manual fields are used as provided by the user.
+func buildQueryFields(ctx context.Context, fields []xo.Field, flat, manual bool) ([]Field, error) {
 	tf := camelExport
-	if query.Flat {
+	if flat {
 		tf = camel
 	}
-	var fields []Field
-	for _, z := range query.Fields {
+	var v []Field
+	for _, z := range fields {
 		f, err := convertField(ctx, tf, z)
 		if err != nil {
-			return Table{}, err
+			return nil, err
 		}
 		// dont use convertField; the types are already provided by the user
-		if query.ManualFields {
+		if manual {
 			f = Field{
 				GoName:  z.Name,
 				SQLName: snake(z.Name),
 				Type:    z.Type.Type,
+				Zero:    goZero(z.Type.Type),
 			}
 		}
-		fields = append(fields, f)
+		v = append(v, f)
 	}
-	sqlName := snake(query.Type)
-	return Table{
-		GoName:  query.Type,
-		SQLName: sqlName,
-		Fields:  fields,
-		Comment: query.TypeComment,
-	}, nil
+	return v, nil
+}
+
+// goZero returns the zero value of a Go type provided by the user.
+func goZero(typ string) string {
+	switch typ {
+	case "bool":
+		return "false"
+	case "string":
+		return `""`
+	case "byte", "rune", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return "0"
+	case "any", "interface{}", "pq.BoolArray", "pq.ByteArray", "pq.Float64Array", "pq.Float32Array", "pq.Int64Array", "pq.Int32Array", "pq.StringArray":
+		return "nil"
+	}
+	if strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "map[") {
+		return "nil"
+	}
+	return typ + "{}"
 }
 
 // buildQueryName builds a name for the query.
@@ -476,7 +596,7 @@ func buildQueryName(query xo.Query) string {
 func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) error {
 	// emit enums
 	for _, e := range schema.Enums {
-		enum := convertEnum(e)
+		enum := convertEnum(ctx, schema.Name, e)
 		emit(xo.Template{
 			Partial:  "enum",
 			Dest:     strings.ToLower(enum.GoName) + ext,
@@ -491,7 +611,7 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 	var procOrder []string
 	for _, p := range schema.Procs {
 		var err error
-		if procOrder, err = convertProc(ctx, overloadMap, procOrder, p); err != nil {
+		if procOrder, err = convertProc(ctx, schema.Name, overloadMap, procOrder, p); err != nil {
 			return err
 		}
 	}
@@ -505,6 +625,13 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 		// Set flag to change name to their overloaded versions if needed.
 		for i := range procs {
 			procs[i].Overloaded = len(procs) > 1
+			// set-returning functions return rows of their own type
+			if procs[i].Set {
+				procs[i].Row = procs[i].GoName + "Row"
+				if procs[i].Overloaded {
+					procs[i].Row = procs[i].OverloadedName + "Row"
+				}
+			}
 		}
 		emit(xo.Template{
 			Dest:     prefix + strings.ToLower(name) + ext,
@@ -515,7 +642,7 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 	}
 	// emit tables
 	for _, t := range append(schema.Tables, schema.Views...) {
-		table, err := convertTable(ctx, t)
+		table, err := convertTable(ctx, schema.Name, t)
 		if err != nil {
 			return err
 		}
@@ -559,7 +686,7 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 }
 
 // convertEnum converts a xo.Enum.
-func convertEnum(e xo.Enum) Enum {
+func convertEnum(ctx context.Context, schema string, e xo.Enum) Enum {
 	var vals []EnumValue
 	goName := camelExport(e.Name)
 	for _, v := range e.Values {
@@ -574,29 +701,35 @@ func convertEnum(e xo.Enum) Enum {
 		})
 	}
 	return Enum{
-		GoName:  goName,
+		GoName:  schemaGoName(ctx, schema, e.Name),
 		SQLName: e.Name,
+		Schema:  schema,
 		Values:  vals,
 	}
 }
 
 // convertProc converts a xo.Proc.
-func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []string, p xo.Proc) ([]string, error) {
-	_, _, schema := xo.DriverDbSchema(ctx)
+func convertProc(ctx context.Context, schema string, overloadMap map[string][]Proc, order []string, p xo.Proc) ([]string, error) {
 	proc := Proc{
 		Type:      p.Type,
-		GoName:    camelExport(p.Name),
+		GoName:    schemaGoName(ctx, schema, p.Name),
 		SQLName:   p.Name,
+		Schema:    schema,
 		Signature: fmt.Sprintf("%s.%s", schema, p.Name),
 		Void:      p.Void,
+		Set:       p.Set,
 	}
-	// proc params
+	// proc params, excluding out params
 	var types []string
 	for _, z := range p.Params {
 		f, err := convertField(ctx, camel, z)
 		if err != nil {
 			return nil, err
 		}
+		proc.Args = append(proc.Args, f)
+		if z.Direction == "out" {
+			continue
+		}
 		proc.Params = append(proc.Params, f)
 		types = append(types, z.Type.Type)
 	}
@@ -604,21 +737,50 @@ func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []str
 	proc.Signature += "(" + strings.Join(types, ", ") + ")"
 	proc.OverloadedName = overloadedName(types, proc)
 	types = nil
-	// proc return
+	// proc return, as fields of the row type for set-returning functions
+	nameFunc := camel
+	if p.Set {
+		nameFunc = camelExport
+	}
 	for _, z := range p.Returns {
-		f, err := convertField(ctx, camel, z)
+		f, err := convertField(ctx, nameFunc, z)
 		if err != nil {
 			return nil, err
 		}
 		proc.Returns = append(proc.Returns, f)
 		types = append(types, z.Type.Type)
 	}
+	// result sets returned by the procedure
+	for _, r := range p.Results {
+		fields, err := buildQueryFields(ctx, r.Fields, false, r.ManualFields)
+		if err != nil {
+			return nil, err
+		}
+		name := proc.GoName + camelExport(r.Name)
+		proc.Results = append(proc.Results, Table{
+			GoName:  name,
+			SQLName: snake(name),
+			Fields:  fields,
+		})
+		proc.Result.Fields = append(proc.Result.Fields, Field{
+			GoName:  camelExport(r.Name),
+			SQLName: snake(r.Name),
+			Type:    "[]*" + name,
+		})
+	}
+	if len(proc.Results) != 0 {
+		proc.Result.GoName = proc.GoName + "Result"
+		proc.Result.SQLName = snake(proc.Result.GoName)
+	}
 	// append signature
 	if !p.Void {
 		format := " (%s)"
 		if len(p.Returns) == 1 {
 			format = " %s"
 		}
+		if p.Set {
+			format = " SETOF" + format
+		}
 		proc.Signature += fmt.Sprintf(format, strings.Join(types, ", "))
 	}
 	// add proc
@@ -631,7 +793,7 @@ func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []str
 }
 
 // convertTable converts a xo.Table to a Table.
-func convertTable(ctx context.Context, t xo.Table) (Table, error) {
+func convertTable(ctx context.Context, schema string, t xo.Table) (Table, error) {
 	var cols, pkCols []Field
 	for _, z := range t.Columns {
 		f, err := convertField(ctx, camelExport, z)
@@ -643,16 +805,164 @@ func convertTable(ctx context.Context, t xo.Table) (Table, error) {
 			pkCols = append(pkCols, f)
 		}
 	}
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	var checks []Check
+	for _, c := range t.Checks {
+		if check, ok := convertCheck(driver, c, cols); ok {
+			checks = append(checks, check)
+		}
+	}
 	return Table{
-		GoName:      camelExport(singularize(t.Name)),
+		GoName:      schemaGoName(ctx, schema, singularize(t.Name)),
 		SQLName:     t.Name,
+		Schema:      schema,
 		Fields:      cols,
 		PrimaryKeys: pkCols,
+		Checks:      checks,
 		Manual:      t.Manual,
 		Comment:     t.Definition,
 	}, nil
 }
 
+// convertCheck converts a check constraint, returning false when the check
+// constraint is not made of simple rules that can be validated in Go.
+func convertCheck(driver string, c xo.Check, fields []Field) (Check, bool) {
+	rules, ok := xo.ParseCheck(driver, c.Expr)
+	if !ok {
+		return Check{}, false
+	}
+	var res []CheckRule
+	for _, r := range rules {
+		rule, ok := convertCheckRule(driver, r, fields)
+		if !ok {
+			return Check{}, false
+		}
+		res = append(res, rule)
+	}
+	return Check{
+		SQLName: c.Name,
+		Expr:    strings.Join(strings.Fields(c.Expr), " "),
+		Rules:   res,
+	}, true
+}
+
+// convertCheckRule converts a check constraint rule for the matching field,
+// returning false when the field's type cannot be compared with the rule's
+// values in the same way as the database.
+func convertCheckRule(driver string, r xo.Rule, fields []Field) (CheckRule, bool) {
+	var field Field
+	var found bool
+	for _, f := range fields {
+		if strings.EqualFold(f.SQLName, r.Column) {
+			field, found = f, true
+			break
+		}
+	}
+	if !found {
+		return CheckRule{}, false
+	}
+	// unwrap nullable types
+	typ, value := field.Type, ""
+	switch field.Type {
+	case "sql.NullString":
+		typ, value = "string", "String"
+	case "sql.NullInt64":
+		typ, value = "int64", "Int64"
+	case "sql.NullInt32":
+		typ, value = "int32", "Int32"
+	case "sql.NullInt16":
+		typ, value = "int16", "Int16"
+	case "sql.NullByte":
+		typ, value = "byte", "Byte"
+	case "sql.NullFloat64":
+		typ, value = "float64", "Float64"
+	}
+	switch {
+	case r.Length != "":
+		if typ != "string" || !slices.Contains(checkLengthFuncs[driver], r.Length) {
+			return CheckRule{}, false
+		}
+		typ = "int"
+	case typ == "string" && checkCaseInsensitive[driver]:
+		return CheckRule{}, false
+	}
+	var values []string
+	for _, v := range r.Values {
+		lit, ok := checkLiteral(typ, r.Op, v)
+		if !ok {
+			return CheckRule{}, false
+		}
+		values = append(values, lit)
+	}
+	return CheckRule{
+		Field:  field,
+		Value:  value,
+		Length: r.Length != "",
+		Op:     r.Op,
+		Values: values,
+	}, true
+}
+
+// checkLiteral returns the go literal for a check constraint value compared
+// with the op to a value of the go type.
+func checkLiteral(typ, op, v string) (string, bool) {
+	switch typ {
+	case "string":
+		switch op {
+		case "=", "<>", "in", "not in":
+			return strconv.Quote(v), true
+		}
+	case "int", "int8", "int16", "int32", "int64":
+		if i, err := strconv.ParseInt(v, 10, intBitSize(typ)); err == nil {
+			return strconv.FormatInt(i, 10), true
+		}
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		if i, err := strconv.ParseUint(v, 10, intBitSize(typ)); err == nil {
+			return strconv.FormatUint(i, 10), true
+		}
+	case "float32", "float64":
+		if n := strings.TrimPrefix(v, "-"); n == "" || !strings.ContainsRune("0123456789.", rune(n[0])) {
+			return "", false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// checkLengthFuncs are the length funcs of each driver counting the characters
+// of a string, as with utf8.RuneCountInString. MySQL's length counts bytes,
+// and SQL Server's len ignores trailing spaces.
+var checkLengthFuncs = map[string][]string{
+	"postgres": {"length", "char_length", "character_length"},
+	"mysql":    {"char_length", "character_length"},
+	"oracle":   {"length"},
+	"sqlite3":  {"length"},
+}
+
+// checkCaseInsensitive are the drivers whose default collations compare
+// strings case-insensitively.
+var checkCaseInsensitive = map[string]bool{
+	"mysql":     true,
+	"sqlserver": true,
+}
+
+// intBitSize returns the bit size of a go integer type.
+func intBitSize(typ string) int {
+	switch typ {
+	case "int8", "uint8", "byte":
+		return 8
+	case "int16", "uint16":
+		return 16
+	case "int32", "uint32":
+		return 32
+	case "int64", "uint64":
+		return 64
+	}
+	return 0
+}
+
 func convertIndex(ctx context.Context, t Table, i xo.Index) (Index, error) {
 	var fields []Field
 	for _, z := range i.Fields {
@@ -664,7 +974,7 @@ func convertIndex(ctx context.Context, t Table, i xo.Index) (Index, error) {
 	}
 	return Index{
 		SQLName:   i.Name,
-		Func:      camelExport(i.Func),
+		Func:      schemaGoName(ctx, t.Schema, i.Func),
 		Table:     t,
 		Fields:    fields,
 		IsUnique:  i.IsUnique,
@@ -690,14 +1000,18 @@ func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey) (ForeignKey, er
 		}
 		refFields = append(refFields, refField)
 	}
+	refSchema := t.Schema
+	if fk.RefSchema != "" {
+		refSchema = fk.RefSchema
+	}
 	return ForeignKey{
 		GoName:    camelExport(fk.Func),
 		SQLName:   fk.Name,
 		Table:     t,
 		Fields:    fields,
-		RefTable:  camelExport(singularize(fk.RefTable)),
+		RefTable:  schemaGoName(ctx, refSchema, singularize(fk.RefTable)),
 		RefFields: refFields,
-		RefFunc:   camelExport(fk.RefFunc),
+		RefFunc:   schemaGoName(ctx, refSchema, fk.RefFunc),
 	}, nil
 }
 
@@ -734,6 +1048,7 @@ func convertField(ctx context.Context, tf transformFunc, f xo.Field) (Field, err
 		Zero:       zero,
 		IsPrimary:  f.IsPrimary,
 		IsSequence: f.IsSequence,
+		Direction:  f.Direction,
 		Comment:    f.Comment,
 	}, nil
 }
@@ -779,6 +1094,16 @@ func camelExport(names ...string) string {
 	return snaker.ForceCamelIdentifier(strings.Join(names, "_"))
 }
 
+// schemaGoName returns the Go name for name, prefixed with the schema name
+// when the schema is not the active schema (ie, when generating code for
+// multiple schemas).
+func schemaGoName(ctx context.Context, schema, name string) string {
+	if _, _, active := xo.DriverDbSchema(ctx); schema != "" && schema != active {
+		return camelExport(schema, name)
+	}
+	return camelExport(name)
+}
+
 const ext = ".dbtpl.go"
 
 // Funcs is a set of template funcs.
@@ -858,6 +1183,7 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"first":   f.firstfn,
 		"driver":  f.driverfn,
 		"schema":  f.schemafn,
+		"qualify": f.qualify,
 		"pkg":     f.pkgfn,
 		"tags":    f.tagsfn,
 		"imports": f.importsfn,
@@ -879,6 +1205,8 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"db_prefix":           f.db_prefix,
 		"db_update":           f.db_update,
 		"db_named":            f.db_named,
+		"db_query":            f.db_query,
+		"proc_args":           f.proc_args,
 		"named":               f.named,
 		"logf":                f.logf,
 		"logf_pkeys":          f.logf_pkeys,
@@ -892,6 +1220,7 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"type":         f.typefn,
 		"field":        f.field,
 		"short":        f.short,
+		"check":        f.check,
 		// sqlstr funcs
 		"querystr": f.querystr,
 		"sqlstr":   f.sqlstr,
@@ -921,7 +1250,16 @@ func (f *Funcs) driverfn(drivers ...string) bool {
 
 // schemafn takes a series of names and joins them with the schema name.
 func (f *Funcs) schemafn(names ...string) string {
-	s := f.schema
+	return f.qualify(f.schema, names...)
+}
+
+// qualify takes a series of names and joins them with the schema name, or
+// with the active schema name when the schema name is empty.
+func (f *Funcs) qualify(schema string, names ...string) string {
+	s := schema
+	if s == "" {
+		s = f.schema
+	}
 	// escape table names
 	if f.escTable {
 		for i, name := range names {
@@ -933,7 +1271,7 @@ func (f *Funcs) schemafn(names ...string) string {
 	case s == "" && n == "":
 		return ""
 	case f.driver == "sqlite3" && n == "":
-		return f.schema
+		return s
 	case f.driver == "sqlite3":
 		return n
 	case s != "" && n != "":
@@ -1052,6 +1390,8 @@ func (f *Funcs) funcfn(name string, context bool, v any) string {
 		}
 		// returns
 		switch {
+		case x.ExecRows:
+			r = append(r, "int64")
 		case x.Exec:
 			r = append(r, "sql.Result")
 		case x.Flat:
@@ -1067,7 +1407,13 @@ func (f *Funcs) funcfn(name string, context bool, v any) string {
 		// params
 		p = append(p, f.params(x.Params, true))
 		// returns
-		if !x.Void {
+		switch {
+		case x.Set:
+			r = append(r, "[]*"+x.Row)
+		default:
+			if len(x.Results) != 0 {
+				r = append(r, "*"+x.Result.GoName)
+			}
 			for _, ret := range x.Returns {
 				r = append(r, f.typefn(ret.Type))
 			}
@@ -1170,16 +1516,33 @@ func (f *Funcs) foreign_key_none(v any) string {
 
 // db generates a db.<name>Context(ctx, sqlstr, ...)
 func (f *Funcs) db(name string, v ...any) string {
+	return f.db_query(name, "sqlstr", v...)
+}
+
+// db_query generates a db.<name>Context(ctx, <query>, ...) for the query.
+func (f *Funcs) db_query(name, query string, v ...any) string {
 	// params
 	var p []any
 	if f.contextfn() {
 		name += "Context"
 		p = append(p, "ctx")
 	}
-	p = append(p, "sqlstr")
+	p = append(p, query)
 	return fmt.Sprintf("db.%s(%s)", name, f.names("", append(p, v...)...))
 }
 
+// proc_args returns the call params of the proc with the direction (empty for
+// in params).
+func (f *Funcs) proc_args(p Proc, dir string) []Field {
+	var fields []Field
+	for _, z := range p.Args {
+		if z.Direction == dir {
+			fields = append(fields, z)
+		}
+	}
+	return fields
+}
+
 // db_prefix generates a db.<name>Context(ctx, sqlstr, <prefix>.param, ...).
 //
 // Will skip the specific parameters based on the type provided.
@@ -1236,11 +1599,13 @@ func (f *Funcs) db_named(name string, v any) string {
 	var p []string
 	switch x := v.(type) {
 	case Proc:
-		for _, z := range x.Params {
-			p = append(p, f.named(z.SQLName, z.GoName, false))
-		}
-		for _, z := range x.Returns {
-			p = append(p, f.named(z.SQLName, "&"+z.GoName, true))
+		for _, z := range f.procArgs(x) {
+			switch z.Direction {
+			case "out", "inout":
+				p = append(p, f.named_out(z.SQLName, "&"+z.GoName, z.Direction == "inout"))
+			default:
+				p = append(p, f.named(z.SQLName, z.GoName, false))
+			}
 		}
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 10: %T ]]", v)
@@ -1249,15 +1614,47 @@ func (f *Funcs) db_named(name string, v any) string {
 }
 
 func (f *Funcs) named(name, value string, out bool) string {
-	switch {
-	case out && f.driver == "oracle" && f.oracleType == "ora":
-		return fmt.Sprintf("sql.Out{Dest: %s}", value)
-	case out:
-		return fmt.Sprintf("sql.Named(%q, sql.Out{Dest: %s})", name, value)
+	if out {
+		return f.named_out(name, value, false)
 	}
 	return fmt.Sprintf("sql.Named(%q, %s)", name, value)
 }
 
+// named_out generates a named out param, that is also passed as an input
+// when in is true.
+func (f *Funcs) named_out(name, value string, in bool) string {
+	s := "sql.Out{Dest: " + value
+	if in {
+		s += ", In: true"
+	}
+	s += "}"
+	if f.driver == "oracle" && f.oracleType == "ora" {
+		return s
+	}
+	return fmt.Sprintf("sql.Named(%q, %s)", name, s)
+}
+
+// procArgs returns the call params of a proc in order. Except for postgres,
+// return values not loaded as out params (ie, from a snapshot without param
+// directions) are added as out params.
+func (f *Funcs) procArgs(x Proc) []Field {
+	l := append([]Field(nil), x.Args...)
+	if f.driver == "postgres" {
+		return l
+	}
+	for _, z := range x.Returns {
+		found := false
+		for _, arg := range l {
+			found = found || arg.SQLName == z.SQLName
+		}
+		if !found {
+			z.Direction = "out"
+			l = append(l, z)
+		}
+	}
+	return l
+}
+
 func (f *Funcs) logf_pkeys(v any) string {
 	p := []string{"sqlstr"}
 	switch x := v.(type) {
@@ -1319,6 +1716,11 @@ func (f *Funcs) namesfn(all bool, prefix string, z ...any) string {
 		case string:
 			names = append(names, x)
 		case Query:
+			// queries built at runtime pass their built args
+			if !all && runtimeQuery(x.Query) {
+				names = append(names, "args...")
+				continue
+			}
 			for _, p := range x.Params {
 				if !all && p.Interpolate {
 					continue
@@ -1392,6 +1794,9 @@ func (f *Funcs) querystr(v any) string {
 	var query, comments []string
 	switch x := v.(type) {
 	case Query:
+		if runtimeQuery(x.Query) {
+			return f.querybuilder(x.Query, x.Comments, x.Params)
+		}
 		interpolate, query, comments = x.Interpolate, x.Query, x.Comments
 	default:
 		return fmt.Sprintf("const sqlstr = [[ UNSUPPORTED TYPE 16: %T ]]", v)
@@ -1415,6 +1820,139 @@ func (f *Funcs) querystr(v any) string {
 	return fmt.Sprintf("%s sqlstr = %s", typ, sqlstr)
 }
 
+// querybuilder generates code building the sqlstr and args for a query at
+// runtime, appending the args in the order their placeholders appear in the
+// query.
+func (f *Funcs) querybuilder(query, comments []string, params []QueryParam) string {
+	lines := []string{"var args []any"}
+	var declared bool
+	// texts and their comments not yet added to sqlstr
+	var texts, notes []string
+	flush := func() {
+		if len(texts) == 0 {
+			return
+		}
+		var exprs []string
+		for i, text := range texts {
+			expr := "`" + text + "`"
+			if i != len(texts)-1 {
+				expr += " + "
+			}
+			if notes[i] != "" {
+				expr += "// " + notes[i]
+			}
+			exprs = append(exprs, expr)
+		}
+		op := " += "
+		if !declared {
+			op, declared = " := ", true
+		}
+		lines = append(lines, "sqlstr"+op+stripRE.ReplaceAllString(strings.Join(exprs, "\n"), " "))
+		texts, notes = texts[:0], notes[:0]
+	}
+	for i, line := range query {
+		last := 0
+		for _, m := range markerRE.FindAllStringSubmatchIndex(line, -1) {
+			if s := line[last:m[0]]; s != "" {
+				texts, notes = append(texts, s), append(notes, "")
+			}
+			flush()
+			if !declared {
+				lines, declared = append(lines, "var sqlstr string"), true
+			}
+			name := line[m[4]:m[5]]
+			p, ok := queryParam(params, name)
+			switch marker := line[m[2]:m[3]]; {
+			case marker == "end":
+				lines = append(lines, "}")
+			case !ok:
+				lines = append(lines, fmt.Sprintf("/* UNSUPPORTED QUERY PARAM: %q */", name))
+			case marker == "if":
+				lines = append(lines, "if "+f.querycond(p)+" {")
+			case p.Expand:
+				lines = append(lines,
+					"for i, v := range "+name+" {",
+					"if i != 0 {",
+					`sqlstr += ", "`,
+					"}",
+					"args = append(args, v)",
+					"sqlstr += "+f.placeholder(),
+					"}",
+					"if len("+name+") == 0 {",
+					`sqlstr += "NULL"`,
+					"}",
+				)
+			default:
+				lines = append(lines,
+					"args = append(args, "+name+")",
+					"sqlstr += "+f.placeholder(),
+				)
+			}
+			last = m[1]
+		}
+		switch s, note := line[last:], strings.TrimSpace(comments[i]); {
+		case s != "":
+			texts, notes = append(texts, s), append(notes, note)
+		case note != "":
+			lines = append(lines, "// "+note)
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// querycond generates the condition for a conditional query block, checking
+// that the param is not the zero value for its type.
+func (f *Funcs) querycond(p QueryParam) string {
+	switch typ := p.Type; {
+	case strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["):
+		return "len(" + p.Name + ") != 0"
+	case strings.HasPrefix(typ, "*"), typ == "any", strings.HasPrefix(typ, "interface"):
+		return p.Name + " != nil"
+	case typ == "bool":
+		return p.Name
+	case typ == "string":
+		return p.Name + ` != ""`
+	case strings.HasPrefix(typ, "int"), strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "float"), typ == "byte", typ == "rune":
+		return p.Name + " != 0"
+	}
+	return p.Name + " != (" + f.typefn(p.Type) + "{})"
+}
+
+// queryParam returns the named query param.
+func queryParam(params []QueryParam, name string) (QueryParam, bool) {
+	for _, p := range params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return QueryParam{}, false
+}
+
+// runtimeQuery returns true when the query is built at runtime (ie, it has
+// expanded params or conditional blocks).
+func runtimeQuery(query []string) bool {
+	for _, line := range query {
+		if markerRE.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// markerRE matches the param and conditional block markers of a query built
+// at runtime.
+var markerRE = regexp.MustCompile("` \\+ (param|if|end)\\((\\w*)\\) \\+ `")
+
+// placeholder generates the placeholder expression for the last value
+// appended to args.
+func (f *Funcs) placeholder() string {
+	if s := f.nth(0); s != f.nth(1) {
+		return strconv.Quote(strings.TrimSuffix(s, "1")) + " + strconv.Itoa(len(args))"
+	}
+	return strconv.Quote(f.nth(0))
+}
+
 var stripRE = regexp.MustCompile(`\s+\+\s+` + "``")
 
 func (f *Funcs) sqlstr(typ string, v any) string {
@@ -1456,7 +1994,7 @@ func (f *Funcs) sqlstr_insert_base(all bool, v any) []string {
 			n++
 		}
 		return []string{
-			"INSERT INTO " + f.schemafn(x.SQLName) + " (",
+			"INSERT INTO " + f.qualify(x.Schema, x.SQLName) + " (",
 			strings.Join(fields, ", "),
 			") VALUES (",
 			strings.Join(vals, ", "),
@@ -1534,7 +2072,7 @@ func (f *Funcs) sqlstr_update_base(prefix string, v any) (int, []string) {
 		}
 		name := ""
 		if prefix == "" {
-			name = f.schemafn(x.SQLName) + " "
+			name = f.qualify(x.Schema, x.SQLName) + " "
 		}
 		return n, []string{
 			"UPDATE " + name + "SET ",
@@ -1627,9 +2165,9 @@ func (f *Funcs) sqlstr_upsert_sqlserver_oracle(v any) []string {
 		// merge [table]...
 		switch f.driver {
 		case "sqlserver":
-			lines = []string{"MERGE " + f.schemafn(x.SQLName) + " AS t "}
+			lines = []string{"MERGE " + f.qualify(x.Schema, x.SQLName) + " AS t "}
 		case "oracle":
-			lines = []string{"MERGE " + f.schemafn(x.SQLName) + "t "}
+			lines = []string{"MERGE " + f.qualify(x.Schema, x.SQLName) + "t "}
 		}
 		// using (select ..)
 		var fields, predicate []string
@@ -1691,7 +2229,7 @@ func (f *Funcs) sqlstr_delete(v any) []string {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
 		}
 		return []string{
-			"DELETE FROM " + f.schemafn(x.SQLName) + " ",
+			"DELETE FROM " + f.qualify(x.Schema, x.SQLName) + " ",
 			"WHERE " + strings.Join(list, " AND "),
 		}
 	}
@@ -1715,7 +2253,7 @@ func (f *Funcs) sqlstr_index(v any) []string {
 		return []string{
 			"SELECT ",
 			strings.Join(fields, ", ") + " ",
-			"FROM " + f.schemafn(x.Table.SQLName) + " ",
+			"FROM " + f.qualify(x.Table.Schema, x.Table.SQLName) + " ",
 			"WHERE " + strings.Join(list, " AND "),
 		}
 	}
@@ -1739,21 +2277,27 @@ func (f *Funcs) sqlstr_proc(v any) []string {
 		case "oracle":
 			format = "BEGIN %s(%s); END;"
 		}
-		// build params list; add return fields for orcle
-		l := x.Params
-		if f.driver == "oracle" {
-			l = append(l, x.Returns...)
-		}
+		// build params list; out params are named for oracle, returned in
+		// session variables for mysql, and passed as null for postgres
 		var list []string
-		for i, field := range l {
-			s := f.nth(i)
-			if f.driver == "oracle" {
+		var n int
+		for _, field := range f.procArgs(x) {
+			var s string
+			switch {
+			case f.driver == "oracle":
 				s = ":" + field.SQLName
+			case f.driver == "mysql" && field.Direction != "":
+				s = "@" + field.SQLName
+			case field.Direction == "out":
+				s = "NULL"
+			default:
+				s = f.nth(n)
+				n++
 			}
 			list = append(list, s)
 		}
 		// dont prefix with schema for oracle
-		name := f.schemafn(x.SQLName)
+		name := f.qualify(x.Schema, x.SQLName)
 		if f.driver == "oracle" {
 			name = x.SQLName
 		}
@@ -1775,6 +2319,9 @@ func (f *Funcs) sqlstr_func(v any) []string {
 			format = "SELECT %s(%s)"
 		case "sqlserver":
 			format = "SELECT %s(%s) AS OUT"
+			if x.Set {
+				format = "SELECT * FROM %s(%s)"
+			}
 		case "oracle":
 			format = "SELECT %s(%s) FROM dual"
 		}
@@ -1784,7 +2331,7 @@ func (f *Funcs) sqlstr_func(v any) []string {
 			list = append(list, f.nth(i))
 		}
 		return []string{
-			fmt.Sprintf(format, f.schemafn(x.SQLName), strings.Join(list, ", ")),
+			fmt.Sprintf(format, f.qualify(x.Schema, x.SQLName), strings.Join(list, ", ")),
 		}
 	}
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 28: %T ]]", v)}
@@ -1888,9 +2435,12 @@ func (f *Funcs) typefn(typ string) string {
 		return typ
 	}
 	var prefix string
-	for strings.HasPrefix(typ, "[]") {
-		typ = typ[2:]
-		prefix += "[]"
+	for strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "*") {
+		n := 1
+		if typ[0] == '[' {
+			n = 2
+		}
+		typ, prefix = typ[n:], prefix+typ[:n]
 	}
 	if _, ok := f.knownTypes[typ]; ok || f.custom == "" {
 		return prefix + typ
@@ -1996,6 +2546,58 @@ func eval(v any, s string) (string, error) {
 	return buf.String(), nil
 }
 
+// check generates the condition for a check constraint of a table not being
+// satisfied.
+func (f *Funcs) check(t Table, c Check) string {
+	short := f.short(t)
+	var conds []string
+	for _, r := range c.Rules {
+		name := short + "." + r.Field.GoName
+		if r.Value != "" {
+			name += "." + r.Value
+		}
+		if r.Length {
+			name = "utf8.RuneCountInString(" + name + ")"
+		}
+		var cond string
+		switch r.Op {
+		case "in", "not in":
+			op, join := "!=", " && "
+			if r.Op == "not in" {
+				op, join = "==", " || "
+			}
+			var v []string
+			for _, z := range r.Values {
+				v = append(v, name+" "+op+" "+z)
+			}
+			cond = strings.Join(v, join)
+			if len(v) > 1 && (len(c.Rules) > 1 || r.Value != "") {
+				cond = "(" + cond + ")"
+			}
+		default:
+			cond = name + " " + checkNegatedOps[r.Op] + " " + r.Values[0]
+		}
+		if r.Value != "" {
+			cond = short + "." + r.Field.GoName + ".Valid && " + cond
+			if len(c.Rules) > 1 {
+				cond = "(" + cond + ")"
+			}
+		}
+		conds = append(conds, cond)
+	}
+	return strings.Join(conds, " || ")
+}
+
+// checkNegatedOps are the negated check constraint comparison ops.
+var checkNegatedOps = map[string]string{
+	"=":  "!=",
+	"<>": "==",
+	"<":  ">=",
+	"<=": ">",
+	">":  "<=",
+	">=": "<",
+}
+
 // templateReservedNames are the template reserved names.
 var templateReservedNames = map[string]bool{
 	// variables
@@ -2269,6 +2871,7 @@ type EnumValue struct {
 type Enum struct {
 	GoName  string
 	SQLName string
+	Schema  string
 	Values  []EnumValue
 	Comment string
 }
@@ -2279,10 +2882,16 @@ type Proc struct {
 	GoName         string
 	OverloadedName string
 	SQLName        string
+	Schema         string
 	Signature      string
 	Params         []Field
+	Args           []Field // call params in order, including out params
 	Returns        []Field
 	Void           bool
+	Set            bool    // set-returning or table-valued function
+	Row            string  // row type returned by a set-returning function
+	Result         Table   // type of the result sets returned by a procedure
+	Results        []Table // result set types returned by a procedure
 	Overloaded     bool
 	Comment        string
 }
@@ -2292,12 +2901,31 @@ type Table struct {
 	Type        string
 	GoName      string
 	SQLName     string
+	Schema      string
 	PrimaryKeys []Field
 	Fields      []Field
+	Embeds      []string // embedded table types (query types only)
+	Checks      []Check  // check constraints validated in Go
 	Manual      bool
 	Comment     string
 }
 
+// Check is a check constraint template.
+type Check struct {
+	SQLName string
+	Expr    string
+	Rules   []CheckRule
+}
+
+// CheckRule is a check constraint rule template.
+type CheckRule struct {
+	Field  Field
+	Value  string // value field of a sql.Null* type
+	Length bool
+	Op     string
+	Values []string // go literals
+}
+
 // ForeignKey is a foreign key template.
 type ForeignKey struct {
 	GoName    string
@@ -2329,6 +2957,7 @@ type Field struct {
 	Zero       string
 	IsPrimary  bool
 	IsSequence bool
+	Direction  string
 	Comment    string
 }
 
@@ -2338,6 +2967,7 @@ type QueryParam struct {
 	Type        string
 	Interpolate bool
 	Join        bool
+	Expand      bool
 }
 
 // Query is a custom query template.
@@ -2349,8 +2979,12 @@ type Query struct {
 	One         bool
 	Flat        bool
 	Exec        bool
+	ExecRows    bool
+	ExpectRows  int
 	Interpolate bool
 	Type        Table
+	Results     []Table  // result set types, when returning multiple result sets
+	Exists      []string // fields of Type to mark as existing
 	Comment     string
 }
 
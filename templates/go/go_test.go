@@ -0,0 +1,240 @@
+//go:build dbtpl
+
+package gotpl
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+
+	xo "github.com/xo/dbtpl/types"
+	"golang.org/x/tools/imports"
+)
+
+func TestProcs(t *testing.T) {
+	authorID := xo.Field{Name: "author_id", Type: xo.Type{Type: "int"}}
+	total := xo.Field{Name: "total", Type: xo.Type{Type: "int"}, Direction: "out"}
+	running := xo.Field{Name: "running", Type: xo.Type{Type: "int"}, Direction: "inout"}
+	// sqlserver OUTPUT params are loaded as inout
+	output := xo.Field{Name: "total", Type: xo.Type{Type: "int"}, Direction: "inout"}
+	books := xo.Result{Name: "Books", Fields: []xo.Field{{Name: "BookID", Type: xo.Type{Type: "int"}}, {Name: "Title", Type: xo.Type{Type: "string"}}}, ManualFields: true}
+	authors := xo.Result{Name: "Authors", Fields: []xo.Field{{Name: "Name", Type: xo.Type{Type: "string"}}}, ManualFields: true}
+	tests := []struct {
+		name   string
+		driver string
+		proc   xo.Proc
+		exp    []string
+	}{
+		{
+			"mysql out inout",
+			"mysql",
+			xo.Proc{Type: "procedure", Name: "book_counts", Params: []xo.Field{authorID, total, running}, Returns: []xo.Field{total, running}},
+			[]string{
+				"func BookCounts(ctx context.Context, db DB, authorID int, running int) (int, int, error) {",
+				"const sqlstr = `CALL booktest.book_counts(?, @total, @running)`",
+				"if _, err := db.ExecContext(ctx, `SET @running = ?`, running); err != nil {",
+				"if _, err := db.ExecContext(ctx, sqlstr, authorID); err != nil {",
+				"const outstr = `SELECT @total, @running`",
+				"if err := db.QueryRowContext(ctx, outstr).Scan(&total, &running); err != nil {",
+				"return total, running, nil",
+			},
+		},
+		{
+			"sqlserver output",
+			"sqlserver",
+			xo.Proc{Type: "procedure", Name: "book_counts", Params: []xo.Field{authorID, output, running}, Returns: []xo.Field{output, running}},
+			[]string{
+				"func BookCounts(ctx context.Context, db DB, authorID int, total int, running int) (int, int, error) {",
+				"if _, err := db.ExecContext(ctx, sqlstr, sql.Named(\"author_id\", authorID), sql.Named(\"total\", sql.Out{Dest: &total, In: true}), sql.Named(\"running\", sql.Out{Dest: &running, In: true})); err != nil {",
+				"return total, running, nil",
+			},
+		},
+		{
+			"oracle out inout",
+			"oracle",
+			xo.Proc{Type: "procedure", Name: "book_counts", Params: []xo.Field{authorID, total, running}, Returns: []xo.Field{total, running}},
+			[]string{
+				"const sqlstr = `BEGIN book_counts(:author_id, :total, :running); END;`",
+				"if _, err := db.ExecContext(ctx, sqlstr, sql.Named(\"author_id\", authorID), sql.Out{Dest: &total}, sql.Out{Dest: &running, In: true}); err != nil {",
+				"return total, running, nil",
+			},
+		},
+		{
+			"mysql result sets",
+			"mysql",
+			xo.Proc{Type: "procedure", Name: "author_books", Params: []xo.Field{authorID}, Void: true, Results: []xo.Result{authors, books}},
+			[]string{
+				"type AuthorBooksResult struct {\n\tAuthors []*AuthorBooksAuthors `json:\"authors\"` // authors\n\tBooks   []*AuthorBooksBooks   `json:\"books\"`   // books\n}",
+				"type AuthorBooksBooks struct {\n\tBookID int    `json:\"book_id\"` // book_id\n\tTitle  string `json:\"title\"`   // title\n}",
+				"func AuthorBooks(ctx context.Context, db DB, authorID int) (*AuthorBooksResult, error) {",
+				"const sqlstr = `CALL booktest.author_books(?)`",
+				"rows, err := db.QueryContext(ctx, sqlstr, authorID)",
+				"res.Authors = append(res.Authors, &aba)",
+				"if !rows.NextResultSet() {",
+				"if err := rows.Scan(&abb.BookID, &abb.Title); err != nil {",
+				"return &res, nil",
+			},
+		},
+		{
+			"mysql result sets and out params",
+			"mysql",
+			xo.Proc{Type: "procedure", Name: "author_books", Params: []xo.Field{authorID, total}, Returns: []xo.Field{total}, Results: []xo.Result{books}},
+			[]string{
+				"func AuthorBooks(ctx context.Context, db DB, authorID int) (*AuthorBooksResult, int, error) {",
+				"const sqlstr = `CALL booktest.author_books(?, @total)`",
+				"rows, err := db.QueryContext(ctx, sqlstr, authorID)",
+				"return nil, 0, logerror(err)",
+				"if err := rows.Close(); err != nil {",
+				"if err := db.QueryRowContext(ctx, outstr).Scan(&total); err != nil {",
+				"return &res, total, nil",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := renderProcs(t, test.driver, test.proc)
+			for _, exp := range test.exp {
+				if !strings.Contains(s, exp) {
+					t.Errorf("expected generated code to contain:\n%s\ngot:\n%s", exp, s)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildQueryFields(t *testing.T) {
+	var fields []xo.Field
+	for _, typ := range []string{"int", "string", "bool", "[]int64", "*string", "time.Time"} {
+		fields = append(fields, xo.Field{Name: "f", Type: xo.Type{Type: typ}})
+	}
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "postgres")
+	v, err := buildQueryFields(ctx, fields, true, true)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var zeroes []string
+	for _, f := range v {
+		zeroes = append(zeroes, f.Zero)
+	}
+	if exp := []string{"0", `""`, "false", "nil", "nil", "time.Time{}"}; !slices.Equal(zeroes, exp) {
+		t.Errorf("expected zero values %q, got: %q", exp, zeroes)
+	}
+}
+
+func TestProcsTypeCheck(t *testing.T) {
+	tests := []struct {
+		driver string
+		typ    string
+	}{
+		{"mysql", "int"},
+		{"sqlserver", "int"},
+		{"oracle", "number"},
+	}
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			authorID := xo.Field{Name: "author_id", Type: xo.Type{Type: test.typ}}
+			total := xo.Field{Name: "total", Type: xo.Type{Type: test.typ}, Direction: "out"}
+			running := xo.Field{Name: "running", Type: xo.Type{Type: test.typ}, Direction: "inout"}
+			procs := []xo.Proc{
+				{Type: "procedure", Name: "book_counts", Params: []xo.Field{authorID, total, running}, Returns: []xo.Field{total, running}},
+				// returns without param directions, as loaded from a snapshot
+				{Type: "procedure", Name: "book_total", Params: []xo.Field{authorID}, Returns: []xo.Field{{Name: "total", Type: xo.Type{Type: test.typ}}}},
+				{Type: "procedure", Name: "book_delete", Params: []xo.Field{authorID}, Void: true},
+			}
+			// result sets are only supported with mysql
+			if test.driver == "mysql" {
+				books := xo.Result{Name: "Books", Fields: []xo.Field{{Name: "BookID", Type: xo.Type{Type: "int"}}, {Name: "Title", Type: xo.Type{Type: "string"}}}, ManualFields: true}
+				authors := xo.Result{Name: "Authors", Fields: []xo.Field{{Name: "Name", Type: xo.Type{Type: "string"}}}, ManualFields: true}
+				procs = append(procs,
+					xo.Proc{Type: "procedure", Name: "author_books", Params: []xo.Field{authorID, total}, Returns: []xo.Field{total}, Results: []xo.Result{books}},
+					xo.Proc{Type: "procedure", Name: "author_running", Params: []xo.Field{authorID, running}, Returns: []xo.Field{running}, Results: []xo.Result{authors, books}},
+					xo.Proc{Type: "procedure", Name: "book_list", Params: []xo.Field{authorID}, Void: true, Results: []xo.Result{books}},
+				)
+			}
+			tpl, ctx := loadTemplate(t, test.driver)
+			buf := bytes.NewBufferString("package booktest\n")
+			if err := tpl.ExecuteTemplate(buf, "db", xo.Template{}); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			for _, proc := range procs {
+				buf.WriteString(executeProcs(t, ctx, tpl, proc))
+			}
+			src, err := imports.Process("booktest.go", buf.Bytes(), nil)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v\n%s", err, buf.String())
+			}
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "booktest.go", src, 0)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			conf := types.Config{Importer: importer.Default()}
+			if _, err := conf.Check("booktest", fset, []*ast.File{file}, nil); err != nil {
+				t.Errorf("expected generated code to type check, got: %v\n%s", err, src)
+			}
+		})
+	}
+}
+
+// renderProcs renders the procs partial for the proc, returning the
+// formatted code.
+func renderProcs(t *testing.T, driver string, proc xo.Proc) string {
+	t.Helper()
+	tpl, ctx := loadTemplate(t, driver)
+	src, err := format.Source([]byte("package booktest\n" + executeProcs(t, ctx, tpl, proc)))
+	if err != nil {
+		t.Fatalf("expected valid code, got: %v", err)
+	}
+	return string(src)
+}
+
+// loadTemplate loads the schema template for the driver, returning the
+// template and the context used to convert the schema.
+func loadTemplate(t *testing.T, driver string) (*template.Template, context.Context) {
+	t.Helper()
+	var typ xo.TemplateType
+	if err := Init(context.Background(), func(v xo.TemplateType) { typ = v }); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), xo.DriverKey, driver)
+	ctx = context.WithValue(ctx, xo.SchemaKey, "booktest")
+	ctx = context.WithValue(ctx, FieldTagKey, `json:"{{ .SQLName }}"`)
+	ctx = context.WithValue(ctx, ContextKey, "only")
+	ctx = context.WithValue(ctx, Int32Key, "int")
+	ctx = context.WithValue(ctx, Uint32Key, "uint")
+	ctx = context.WithValue(ctx, OracleTypeKey, "ora")
+	ctx = typ.NewContext(ctx, "schema")
+	funcs, err := typ.Funcs(ctx, "schema")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tpl, err := template.New("").Funcs(funcs).ParseFiles("schema.dbtpl.go.tpl", "db.dbtpl.go.tpl")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return tpl, ctx
+}
+
+// executeProcs executes the procs partial for the proc.
+func executeProcs(t *testing.T, ctx context.Context, tpl *template.Template, proc xo.Proc) string {
+	t.Helper()
+	overloadMap := make(map[string][]Proc)
+	order, err := convertProc(ctx, "booktest", overloadMap, nil, proc)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "procs", xo.Template{Data: overloadMap[order[0]]}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return buf.String()
+}
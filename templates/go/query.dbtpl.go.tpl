@@ -3,14 +3,29 @@
 {{- if $q.Comment -}}
 // {{ $q.Comment | eval (func_name_context $q) }}
 {{- else -}}
-// {{ func_name_context $q }} runs a custom query{{ if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
+// {{ func_name_context $q }} runs a custom query{{ if $q.ExecRows }}, returning the number of rows affected{{ else if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
 {{- end }}
 {{ func_context $q }} {
 	// query
 	{{ querystr $q }}
 	// run
 	logf({{ names "" "sqlstr" $q }})
-{{ if $q.Exec -}}
+{{ if $q.ExecRows -}}
+	res, err := {{ db "Exec" $q }}
+	if err != nil {
+		return 0, logerror(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, logerror(err)
+	}
+{{- if $q.ExpectRows }}
+	if n != {{ $q.ExpectRows }} {
+		return n, logerror(&ErrUnexpectedRows{Expected: {{ $q.ExpectRows }}, Affected: n})
+	}
+{{- end }}
+	return n, nil
+{{- else if $q.Exec -}}
 	return {{ db "Exec" $q }}
 {{- else if $q.Flat -}}
 {{- range $q.Type.Fields -}}
@@ -20,11 +35,44 @@
 		return {{ zero $q.Type.Fields "logerror(err)" }}
 	}
 	return {{ names "" $q.Type "nil" }}
+{{- else if $q.Results -}}
+	rows, err := {{ db "Query" $q }}
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	var res {{ type $q.Type.GoName }}
+{{- range $i, $r := $q.Results }}
+{{- if $i }}
+	if !rows.NextResultSet() {
+		if err := rows.Err(); err != nil {
+			return nil, logerror(err)
+		}
+		return nil, logerror(ErrMissingResultSet)
+	}
+{{- end }}
+	// load {{ (index $q.Type.Fields $i).SQLName }}
+	for rows.Next() {
+		var {{ short $r }} {{ type $r.GoName }}
+		// scan
+		if err := rows.Scan({{ names (print "&" (short $r) ".") $r.Fields }}); err != nil {
+			return nil, logerror(err)
+		}
+		res.{{ (index $q.Type.Fields $i).GoName }} = append(res.{{ (index $q.Type.Fields $i).GoName }}, &{{ short $r }})
+	}
+{{- end }}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return &res, nil
 {{- else if $q.One -}}
 	var {{ short $q.Type }} {{ type $q.Type.GoName }}
 	if err := {{ db "QueryRow" $q }}.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }}); err != nil {
 		return nil, logerror(err)
 	}
+{{ range $q.Exists -}}
+	{{ short $q.Type }}.{{ . }} = true
+{{ end -}}
 	return &{{ short $q.Type }}, nil
 {{- else -}}
 	rows, err := {{ db "Query" $q }}
@@ -40,6 +88,9 @@
 		if err := rows.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }}); err != nil {
 			return nil, logerror(err)
 		}
+{{ range $q.Exists -}}
+		{{ short $q.Type }}.{{ . }} = true
+{{ end -}}
 		res = append(res, &{{ short $q.Type }})
 	}
 	if err := rows.Err(); err != nil {
@@ -53,7 +104,7 @@
 {{- if $q.Comment -}}
 // {{ $q.Comment | eval (func_name $q) }}
 {{- else -}}
-// {{ func_name $q }} runs a custom query{{ if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
+// {{ func_name $q }} runs a custom query{{ if $q.ExecRows }}, returning the number of rows affected{{ else if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
 {{- end }}
 {{ func $q }} {
 	return {{ func_name_context $q }}({{ names_all "" "context.Background()" "db" $q }})
@@ -69,8 +120,12 @@
 // {{ $q.GoName }} represents a row from '{{ schema $q.SQLName }}'.
 {{- end }}
 type {{ $q.GoName }} struct {
+{{ range $q.Embeds -}}
+    {{ . }}
+{{ else -}}
 {{ range $q.Fields -}}
     {{ field . }}
 {{ end -}}
+{{ end -}}
 }
 {{ end }}
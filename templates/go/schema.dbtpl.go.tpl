@@ -1,6 +1,6 @@
 {{ define "enum" }}
 {{- $e := .Data -}}
-// {{ $e.GoName }} is the '{{ $e.SQLName }}' enum type from schema '{{ schema }}'.
+// {{ $e.GoName }} is the '{{ $e.SQLName }}' enum type from schema '{{ qualify $e.Schema }}'.
 type {{ $e.GoName }} uint16
 
 // {{ $e.GoName }} values.
@@ -58,7 +58,7 @@ func ({{ short $e.GoName }} *{{ $e.GoName }}) Scan(v any) error {
 
 {{ $nullName := (printf "%s%s" "Null" $e.GoName) -}}
 {{- $nullShort := (short $nullName) -}}
-// {{ $nullName }} represents a null '{{ $e.SQLName }}' enum for schema '{{ schema }}'.
+// {{ $nullName }} represents a null '{{ $e.SQLName }}' enum for schema '{{ qualify $e.Schema }}'.
 type {{ $nullName }} struct {
 	{{ $e.GoName }} {{ $e.GoName }}
 	// Valid is true if [{{ $e.GoName }}] is not null.
@@ -122,7 +122,7 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 
 {{ define "index" }}
 {{- $i := .Data -}}
-// {{ func_name_context $i }} retrieves a row from '{{ schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}].
+// {{ func_name_context $i }} retrieves a row from '{{ qualify $i.Table.Schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}].
 //
 // Generated from index '{{ $i.SQLName }}'.
 {{ func_context $i }} {
@@ -168,7 +168,7 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 }
 
 {{ if context_both -}}
-// {{ func_name $i }} retrieves a row from '{{ schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}].
+// {{ func_name $i }} retrieves a row from '{{ qualify $i.Table.Schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}].
 //
 // Generated from index '{{ $i.SQLName }}'.
 {{ func $i }} {
@@ -181,20 +181,147 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 {{ define "procs" }}
 {{- $ps := .Data -}}
 {{- range $p := $ps -}}
+{{- if $p.Set -}}
+// {{ $p.Row }} represents a row returned by the stored {{ $p.Type }} '{{ $p.Signature }}'.
+type {{ $p.Row }} struct {
+{{ range $p.Returns -}}
+	{{ field . }}
+{{ end -}}
+}
+
+{{ end -}}
+{{- if $p.Results -}}
+// {{ $p.Result.GoName }} represents the result sets returned by the stored {{ $p.Type }} '{{ $p.Signature }}'.
+type {{ $p.Result.GoName }} struct {
+{{ range $p.Result.Fields -}}
+	{{ field . }}
+{{ end -}}
+}
+{{ range $i, $r := $p.Results }}
+// {{ $r.GoName }} represents a row of the '{{ (index $p.Result.Fields $i).SQLName }}' result set returned by the stored {{ $p.Type }} '{{ $p.Signature }}'.
+type {{ $r.GoName }} struct {
+{{ range $r.Fields -}}
+	{{ field . }}
+{{ end -}}
+}
+{{ end }}
+{{ end -}}
 // {{ func_name_context $p }} calls the stored {{ $p.Type }} '{{ $p.Signature }}' on db.
 {{ func_context $p }} {
-{{- if and (driver "mysql") (eq $p.Type "procedure") (not $p.Void) }}
-	// At the moment, the Go MySQL driver does not support stored procedures
-	// with out parameters
-	return {{ zero $p.Returns }}, fmt.Errorf("unsupported")
+{{- if and (driver "mysql") (eq $p.Type "procedure") (or $p.Results (not $p.Void)) }}
+{{- $zero := zero $p.Returns }}
+{{- if $p.Results }}{{ $zero = zero "nil" $p.Returns }}{{ end }}
+{{- if not $p.Void }}
+	// out params are returned in session variables, which must be set and
+	// read on the same connection
+	var tx *sql.Tx
+	if b, ok := db.(interface {
+		{{ if context }}BeginTx(context.Context, *sql.TxOptions){{ else }}Begin(){{ end }} (*sql.Tx, error)
+	}); ok {
+		var err error
+		if tx, err = b.{{ if context }}BeginTx(ctx, nil){{ else }}Begin(){{ end }}; err != nil {
+			return {{ $zero }}, logerror(err)
+		}
+		defer tx.Rollback()
+		db = tx
+	}
+{{- end }}
+	// call {{ qualify $p.Schema $p.SQLName }}
+	{{ sqlstr "proc" $p }}
+	// run
+{{- range $p.Returns }}{{ if ne .Direction "inout" }}
+	var {{ check_name .GoName }} {{ type .Type }}
+{{- end }}{{ end }}
+	logf(sqlstr, {{ names "" (proc_args $p "") }})
+{{- range proc_args $p "inout" }}
+	if _, err := {{ db_query "Exec" (print "`SET @" .SQLName " = ?`") (check_name .GoName) }}; err != nil {
+		return {{ $zero }}, logerror(err)
+	}
+{{- end }}
+{{- if $p.Results }}
+	rows, err := {{ db_query "Query" "sqlstr" (proc_args $p "") }}
+	if err != nil {
+		return {{ $zero }}, logerror(err)
+	}
+	defer rows.Close()
+	var res {{ $p.Result.GoName }}
+{{- range $i, $r := $p.Results }}
+{{- if $i }}
+	if !rows.NextResultSet() {
+		if err := rows.Err(); err != nil {
+			return {{ $zero }}, logerror(err)
+		}
+		return {{ $zero }}, logerror(ErrMissingResultSet)
+	}
+{{- end }}
+	// load {{ (index $p.Result.Fields $i).SQLName }}
+	for rows.Next() {
+		var {{ short $r }} {{ $r.GoName }}
+		// scan
+		if err := rows.Scan({{ names (print "&" (short $r) ".") $r.Fields }}); err != nil {
+			return {{ $zero }}, logerror(err)
+		}
+		res.{{ (index $p.Result.Fields $i).GoName }} = append(res.{{ (index $p.Result.Fields $i).GoName }}, &{{ short $r }})
+	}
+{{- end }}
+	if err := rows.Err(); err != nil {
+		return {{ $zero }}, logerror(err)
+	}
+{{- if not $p.Void }}
+	// the remaining results must be read before the session variables
+	if err := rows.Close(); err != nil {
+		return {{ $zero }}, logerror(err)
+	}
+{{- end }}
 {{- else }}
-	// call {{ schema $p.SQLName }}
+	if _, err := {{ db_query "Exec" "sqlstr" (proc_args $p "") }}; err != nil {
+		return {{ $zero }}, logerror(err)
+	}
+{{- end }}
+{{- if not $p.Void }}
+	const outstr = `SELECT {{ range $i, $r := $p.Returns }}{{ if $i }}, {{ end }}@{{ $r.SQLName }}{{ end }}`
+	if err := {{ db_query "QueryRow" "outstr" }}.Scan({{ names "&" $p.Returns }}); err != nil {
+		return {{ $zero }}, logerror(err)
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return {{ $zero }}, logerror(err)
+		}
+	}
+{{- end }}
+	return {{ if $p.Results }}&res, {{ end }}{{ range $p.Returns }}{{ check_name .GoName }}, {{ end }}nil
+{{- else if $p.Set }}
+	// call {{ qualify $p.Schema $p.SQLName }}
+	{{ sqlstr "proc" $p }}
+	// run
+	logf(sqlstr, {{ params $p.Params false }})
+	rows, err := {{ db "Query" $p }}
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// load results
+	var res []*{{ $p.Row }}
+	for rows.Next() {
+		var {{ short $p.Row }} {{ $p.Row }}
+		// scan
+		if err := rows.Scan({{ names (print "&" (short $p.Row) ".") $p.Returns }}); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &{{ short $p.Row }})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+{{- else }}
+	// call {{ qualify $p.Schema $p.SQLName }}
 	{{ sqlstr "proc" $p }}
 	// run
 {{- if not $p.Void }}
-{{- range $p.Returns }}
+{{- range $p.Returns }}{{ if ne .Direction "inout" }}
 	var {{ check_name .GoName }} {{ type .Type }}
-{{- end }}
+{{- end }}{{ end }}
 	logf(sqlstr, {{ params $p.Params false }})
 {{- if and (driver "sqlserver" "oracle") (eq $p.Type "procedure")}}
 	if _, err := {{ db_named "Exec" $p }}; err != nil {
@@ -232,11 +359,15 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 {{- if $t.Comment -}}
 // {{ $t.Comment | eval $t.GoName }}
 {{- else -}}
-// {{ $t.GoName }} represents a row from '{{ schema $t.SQLName }}'.
+// {{ $t.GoName }} represents a row from '{{ qualify $t.Schema $t.SQLName }}'.
 {{- end }}
 type {{ $t.GoName }} struct {
+{{ range $t.Embeds -}}
+	{{ . }}
+{{ else -}}
 {{ range $t.Fields -}}
 	{{ field . }}
+{{ end -}}
 {{ end }}
 {{- if $t.PrimaryKeys -}}
 	// xo fields
@@ -244,6 +375,20 @@ type {{ $t.GoName }} struct {
 {{ end -}}
 }
 
+{{ if $t.Checks -}}
+// Validate validates the [{{ $t.GoName }}] against the check constraints of
+// '{{ qualify $t.Schema $t.SQLName }}'.
+func ({{ short $t }} *{{ $t.GoName }}) Validate() error {
+{{- range $t.Checks }}
+	// {{ .Expr }}
+	if {{ check $t . }} {
+		return &ErrCheckFailed{{ "{" }}{{ printf "%q" .SQLName }}{{ "}" }}
+	}
+{{- end }}
+	return nil
+}
+
+{{ end -}}
 {{ if $t.PrimaryKeys -}}
 // Exists returns true when the [{{ $t.GoName }}] exists in the database.
 func ({{ short $t }} *{{ $t.GoName }}) Exists() bool {
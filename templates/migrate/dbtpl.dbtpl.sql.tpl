@@ -0,0 +1,89 @@
+{{ define "header" -}}
+-- Generated by dbtpl for the {{ .Data.Name }} schema.
+{{ end }}
+
+{{ define "migrate" -}}
+{{- $m := .Data -}}
+{{- range $v := $m.DropViews }}
+-- drop view {{ $v.Name }}
+{{ dropView $v }}
+{{ end -}}
+{{- range $fk := $m.DropForeignKeys }}
+-- drop foreign key {{ $fk.Table.Name }}.{{ $fk.ForeignKey.Name }}
+{{ dropForeignKey $fk }}
+{{ end -}}
+{{- range $idx := $m.DropIndexes }}
+-- drop index {{ $idx.Table.Name }}.{{ $idx.Index.Name }}
+{{ dropIndex $idx }}
+{{ end -}}
+{{- range $t := $m.DropTables }}
+-- drop table {{ $t.Name }}
+{{ dropTable $t }}
+{{ end -}}
+{{- if driver "postgres" -}}
+{{- range $e := $m.CreateEnums }}
+-- enum {{ $e.Name }}
+CREATE TYPE {{ esc $e.Name }} AS ENUM (
+{{- range $i, $v := $e.Values }}
+  {{ literal $v.Name }}{{ comma $i $e.Values }}
+{{- end }}
+);
+{{ end -}}
+{{- range $v := $m.AddEnumValues }}
+-- enum value {{ $v.Enum.Name }}.{{ $v.Value }}
+{{ addEnumValue $v }}
+{{ end -}}
+{{- end -}}
+{{- range $t := $m.CreateTables }}
+-- table {{ $t.Name }}
+CREATE TABLE {{ esc $t.Name }} (
+{{- range $i, $c := $t.Columns }}
+  {{ coldef $t $c }}{{ comma $i $t.Columns }}
+{{- end -}}
+{{- range $idx := $t.Indexes -}}{{- if isEndConstraint $idx }},
+  {{ constraint $idx.Name -}} {{ if $idx.IsPrimary }}PRIMARY KEY{{ else }}UNIQUE{{ end }} ({{ fields $idx.Fields }})
+{{- end -}}{{- end -}}
+{{- range $fk := $t.ForeignKeys -}}{{- if gt (len $fk.Fields) 1 }},
+  {{ constraint $fk.Name -}} FOREIGN KEY ({{ fields $fk.Fields }}) REFERENCES {{ esc $fk.RefTable }} ({{ fields $fk.RefFields }})
+{{- end -}}{{- end -}}
+{{- range $c := $t.Checks }},
+  {{ checkdef $c }}
+{{- end }}
+){{ engine }};
+{{- if $t.Indexes }}
+{{ range $idx := $t.Indexes }}{{ if not (or $idx.IsPrimary $idx.IsUnique) }}
+-- index {{ $idx.Name }}
+CREATE INDEX {{ esc $idx.Name }} ON {{ esc $t.Name }} ({{ fields $idx.Fields }});
+{{ end -}}{{- end -}}{{- end }}
+{{ end -}}
+{{- range $c := $m.AddColumns }}
+-- add column {{ $c.Table.Name }}.{{ $c.Field.Name }}
+{{ addColumn $c }}
+{{ end -}}
+{{- range $c := $m.AlterColumns }}
+-- alter column {{ $c.Table.Name }}.{{ $c.Field.Name }}
+{{ alterColumn $c }}
+{{ end -}}
+{{- range $c := $m.DropColumns }}
+-- drop column {{ $c.Table.Name }}.{{ $c.Field.Name }}
+{{ dropColumn $c }}
+{{ end -}}
+{{- range $idx := $m.CreateIndexes }}
+-- index {{ $idx.Table.Name }}.{{ $idx.Index.Name }}
+{{ createIndex $idx }}
+{{ end -}}
+{{- range $fk := $m.AddForeignKeys }}
+-- foreign key {{ $fk.Table.Name }}.{{ $fk.ForeignKey.Name }}
+{{ addForeignKey $fk }}
+{{ end -}}
+{{- if driver "postgres" -}}
+{{- range $e := $m.DropEnums }}
+-- drop enum {{ $e.Name }}
+{{ dropEnum $e }}
+{{ end -}}
+{{- end -}}
+{{- range $v := $m.CreateViews }}
+-- view {{ $v.Name }}
+{{ viewdef $v }};
+{{ end -}}
+{{ end -}}
@@ -0,0 +1,596 @@
+//go:build dbtpl
+
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/xo/dbtpl/ddl"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// Init registers the template.
+func Init(ctx context.Context, f func(xo.TemplateType)) error {
+	f(xo.TemplateType{
+		Modes: []string{"schema"},
+		Flags: []xo.Flag{
+			{
+				ContextKey: BeforeKey,
+				Type:       "string",
+				Desc:       "before schema snapshot (json or yaml)",
+			},
+			{
+				ContextKey: ddl.ConstraintKey,
+				Type:       "bool",
+				Desc:       "enable constraint name in output",
+			},
+			{
+				ContextKey: ddl.EscKey,
+				Type:       "string",
+				Desc:       "escape mode",
+				Enums:      []string{"none", "types", "all"},
+			},
+			{
+				ContextKey: ddl.EngineKey,
+				Type:       "string",
+				Desc:       "mysql table engine",
+				Default:    "InnoDB",
+			},
+		},
+		Funcs: NewFuncs,
+		Order: func(ctx context.Context, mode string) []string {
+			return []string{"header", "migrate"}
+		},
+		Process: func(ctx context.Context, _ string, set *xo.Set, emit func(xo.Template)) error {
+			if len(set.Schemas) == 0 {
+				return errors.New("migrate template must be passed at least one schema")
+			}
+			before, err := readSnapshot(Before(ctx))
+			if err != nil {
+				return err
+			}
+			driver, _, _ := xo.DriverDbSchema(ctx)
+			for _, schema := range set.Schemas {
+				b, err := findSchema(before, schema.Name, driver)
+				if err != nil {
+					return err
+				}
+				emit(xo.Template{
+					Partial:  "header",
+					Dest:     "dbtpl.dbtpl.sql",
+					SortName: schema.Name,
+					Data:     schema,
+				})
+				emit(xo.Template{
+					Partial:  "migrate",
+					Dest:     "dbtpl.dbtpl.sql",
+					SortName: schema.Name,
+					Data:     NewMigration(driver, b, schema),
+				})
+			}
+			return nil
+		},
+		Post: func(ctx context.Context, mode string, files map[string][]byte, emit func(string, []byte)) error {
+			for file, content := range files {
+				emit(file, ddl.Clean(content))
+			}
+			return nil
+		},
+	})
+	return nil
+}
+
+// readSnapshot reads the before schema snapshot.
+func readSnapshot(name string) (*xo.Set, error) {
+	if name == "" {
+		return nil, errors.New("migrate template requires a before schema snapshot (--migrate-before)")
+	}
+	set, err := xo.ReadSnapshot(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read before schema snapshot: %w", err)
+	}
+	return set, nil
+}
+
+// findSchema finds the named schema in the snapshot. A schema not in the
+// snapshot is new, and its before schema is empty.
+func findSchema(set *xo.Set, name, driver string) (xo.Schema, error) {
+	schema := xo.Schema{
+		Driver: driver,
+		Name:   name,
+	}
+	for _, s := range set.Schemas {
+		if s.Driver != "" && s.Driver != driver {
+			return xo.Schema{}, fmt.Errorf("before schema snapshot is for %s, not %s", s.Driver, driver)
+		}
+		if s.Name == name {
+			schema = s
+		}
+	}
+	// mysql enum types are not included in snapshots
+	if driver == "mysql" {
+		for i := range schema.Tables {
+			for j := range schema.Tables[i].Columns {
+				if e := schema.EnumByName(schema.Tables[i].Columns[j].Type.Type); e != nil {
+					schema.Tables[i].Columns[j].Type.Enum = e
+				}
+			}
+		}
+	}
+	return schema, nil
+}
+
+// Migration is the set of changes migrating a schema from its before to its
+// after state, in the order they are to be applied.
+type Migration struct {
+	Name            string
+	DropViews       []xo.Table
+	DropForeignKeys []TableForeignKey
+	DropIndexes     []TableIndex
+	DropTables      []xo.Table
+	CreateEnums     []xo.Enum
+	AddEnumValues   []EnumValue
+	CreateTables    []xo.Table
+	AddColumns      []TableColumn
+	AlterColumns    []TableColumn
+	DropColumns     []TableColumn
+	CreateIndexes   []TableIndex
+	AddForeignKeys  []TableForeignKey
+	DropEnums       []xo.Enum
+	CreateViews     []xo.Table
+}
+
+// TableColumn is a table column.
+type TableColumn struct {
+	Table  xo.Table
+	Field  xo.Field
+	Before xo.Field
+}
+
+// TableIndex is a table index.
+type TableIndex struct {
+	Table xo.Table
+	Index xo.Index
+}
+
+// TableForeignKey is a table foreign key.
+type TableForeignKey struct {
+	Table      xo.Table
+	ForeignKey xo.ForeignKey
+}
+
+// EnumValue is an enum value.
+type EnumValue struct {
+	Enum xo.Enum
+	// Value is the added value, and Prev is the value preceding it (if any).
+	Value string
+	Prev  string
+}
+
+// NewMigration builds the migration from the before to after schema.
+func NewMigration(driver string, before, after xo.Schema) Migration {
+	f := &Funcs{d: &ddl.Funcs{Driver: driver}}
+	m := Migration{
+		Name: after.Name,
+	}
+	// enums
+	for _, e := range after.Enums {
+		b := before.EnumByName(e.Name)
+		if b == nil {
+			m.CreateEnums = append(m.CreateEnums, e)
+			continue
+		}
+		for i, v := range e.Values {
+			if !hasField(b.Values, v.Name) {
+				ev := EnumValue{Enum: e, Value: v.Name}
+				if i != 0 {
+					ev.Prev = e.Values[i-1].Name
+				}
+				m.AddEnumValues = append(m.AddEnumValues, ev)
+			}
+		}
+	}
+	for _, e := range before.Enums {
+		if after.EnumByName(e.Name) == nil {
+			m.DropEnums = append(m.DropEnums, e)
+		}
+	}
+	// tables
+	for _, t := range sortTables(before.Tables) {
+		if _, ok := findTable(after.Tables, t.Name); !ok {
+			m.DropTables = append([]xo.Table{t}, m.DropTables...)
+		}
+	}
+	for _, t := range sortTables(after.Tables) {
+		b, ok := findTable(before.Tables, t.Name)
+		if !ok {
+			m.CreateTables = append(m.CreateTables, t)
+			continue
+		}
+		// columns
+		for _, c := range t.Columns {
+			bc, ok := findField(b.Columns, c.Name)
+			switch {
+			case !ok:
+				m.AddColumns = append(m.AddColumns, TableColumn{Table: t, Field: c})
+			case f.columnChanged(bc, c):
+				m.AlterColumns = append(m.AlterColumns, TableColumn{Table: t, Field: c, Before: bc})
+			}
+		}
+		for _, c := range b.Columns {
+			if !hasField(t.Columns, c.Name) {
+				m.DropColumns = append(m.DropColumns, TableColumn{Table: b, Field: c})
+			}
+		}
+		// indexes
+		for _, index := range b.Indexes {
+			if i, ok := findIndex(t.Indexes, index.Name); !ok || indexChanged(index, i) {
+				m.DropIndexes = append(m.DropIndexes, TableIndex{Table: b, Index: index})
+			}
+		}
+		for _, index := range t.Indexes {
+			if i, ok := findIndex(b.Indexes, index.Name); !ok || indexChanged(i, index) {
+				m.CreateIndexes = append(m.CreateIndexes, TableIndex{Table: t, Index: index})
+			}
+		}
+		// foreign keys
+		for _, fk := range b.ForeignKeys {
+			if k, ok := findForeignKey(t.ForeignKeys, fk.Name); !ok || foreignKeyChanged(fk, k) {
+				m.DropForeignKeys = append(m.DropForeignKeys, TableForeignKey{Table: b, ForeignKey: fk})
+			}
+		}
+		for _, fk := range t.ForeignKeys {
+			if k, ok := findForeignKey(b.ForeignKeys, fk.Name); !ok || foreignKeyChanged(k, fk) {
+				m.AddForeignKeys = append(m.AddForeignKeys, TableForeignKey{Table: t, ForeignKey: fk})
+			}
+		}
+	}
+	// views
+	for _, v := range before.Views {
+		if a, ok := findTable(after.Views, v.Name); !ok || a.Definition != v.Definition {
+			m.DropViews = append(m.DropViews, v)
+		}
+	}
+	for _, v := range after.Views {
+		if b, ok := findTable(before.Views, v.Name); !ok || b.Definition != v.Definition {
+			m.CreateViews = append(m.CreateViews, v)
+		}
+	}
+	return m
+}
+
+// columnChanged determines if the column's type, nullability, or default
+// changed.
+func (f *Funcs) columnChanged(before, after xo.Field) bool {
+	return f.d.Normalize(before.Type) != f.d.Normalize(after.Type) ||
+		before.Type.Nullable != after.Type.Nullable ||
+		!after.IsSequence && f.d.AlterDefault(before.Default) != f.d.AlterDefault(after.Default)
+}
+
+// indexChanged determines if the index's fields or uniqueness changed.
+func indexChanged(before, after xo.Index) bool {
+	return before.IsUnique != after.IsUnique ||
+		before.IsPrimary != after.IsPrimary ||
+		fieldNames(before.Fields) != fieldNames(after.Fields)
+}
+
+// foreignKeyChanged determines if the foreign key's fields or references
+// changed.
+func foreignKeyChanged(before, after xo.ForeignKey) bool {
+	return fieldNames(before.Fields) != fieldNames(after.Fields) ||
+		before.RefTable != after.RefTable ||
+		fieldNames(before.RefFields) != fieldNames(after.RefFields)
+}
+
+// findTable finds the named table.
+func findTable(tables []xo.Table, name string) (xo.Table, bool) {
+	for _, t := range tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return xo.Table{}, false
+}
+
+// findField finds the named field.
+func findField(fields []xo.Field, name string) (xo.Field, bool) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return xo.Field{}, false
+}
+
+// hasField determines if the named field is in fields.
+func hasField(fields []xo.Field, name string) bool {
+	_, ok := findField(fields, name)
+	return ok
+}
+
+// findIndex finds the named index.
+func findIndex(indexes []xo.Index, name string) (xo.Index, bool) {
+	for _, index := range indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return xo.Index{}, false
+}
+
+// findForeignKey finds the named foreign key.
+func findForeignKey(fkeys []xo.ForeignKey, name string) (xo.ForeignKey, bool) {
+	for _, fk := range fkeys {
+		if fk.Name == name {
+			return fk, true
+		}
+	}
+	return xo.ForeignKey{}, false
+}
+
+// fieldNames returns the comma separated field names.
+func fieldNames(fields []xo.Field) string {
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// sortTables sorts tables by their foreign key dependencies. Unlike the
+// createdb template, references to tables not in tables are ignored.
+func sortTables(tables []xo.Table) []xo.Table {
+	m := make(map[string]xo.Table)
+	for _, table := range tables {
+		m[table.Name] = table
+	}
+	seen := make(map[string]bool)
+	var sorted []xo.Table
+	for _, table := range tables {
+		sorted = sortAppendTable(m, seen, sorted, table)
+	}
+	return sorted
+}
+
+// sortAppendTable appends and returns the list of foreign key dependencies for
+// the table if not already in seen.
+func sortAppendTable(m map[string]xo.Table, seen map[string]bool, sorted []xo.Table, table xo.Table) []xo.Table {
+	if seen[table.Name] {
+		return sorted
+	}
+	seen[table.Name] = true
+	for _, fk := range table.ForeignKeys {
+		if t, ok := m[fk.RefTable]; ok && table.Name != t.Name {
+			sorted = sortAppendTable(m, seen, sorted, t)
+		}
+	}
+	return append(sorted, table)
+}
+
+// Funcs is a set of template funcs.
+type Funcs struct {
+	d *ddl.Funcs
+}
+
+// NewFuncs creates custom template funcs for the context.
+func NewFuncs(ctx context.Context, _ string) (template.FuncMap, error) {
+	d := ddl.NewFuncs(ctx)
+	funcs := &Funcs{
+		d: d,
+	}
+	return template.FuncMap{
+		"coldef":          d.Coldef,
+		"viewdef":         d.Viewdef,
+		"checkdef":        d.Checkdef,
+		"driver":          d.IsDriver,
+		"constraint":      d.ConstraintName,
+		"esc":             d.EscType,
+		"fields":          d.Fields,
+		"engine":          d.EngineDef,
+		"literal":         d.Literal,
+		"isEndConstraint": d.IsEndConstraint,
+		"comma":           ddl.Comma,
+		"addEnumValue":    funcs.addEnumValue,
+		"dropEnum":        funcs.dropEnum,
+		"dropTable":       funcs.dropTable,
+		"dropView":        funcs.dropView,
+		"addColumn":       funcs.addColumn,
+		"alterColumn":     funcs.alterColumn,
+		"dropColumn":      funcs.dropColumn,
+		"createIndex":     funcs.createIndex,
+		"dropIndex":       funcs.dropIndex,
+		"addForeignKey":   funcs.addForeignKey,
+		"dropForeignKey":  funcs.dropForeignKey,
+	}, nil
+}
+
+// unsupported returns a comment for a change not supported by the driver.
+func (f *Funcs) unsupported(format string, v ...any) string {
+	return fmt.Sprintf("-- unsupported: "+format+" (%s)", append(v, f.d.Driver)...)
+}
+
+// addEnumValue generates an enum value addition.
+func (f *Funcs) addEnumValue(v EnumValue) string {
+	if f.d.Driver != "postgres" {
+		return ""
+	}
+	s := fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", f.d.EscType(v.Enum.Name), f.d.Literal(v.Value))
+	if v.Prev != "" {
+		s += " AFTER " + f.d.Literal(v.Prev)
+	}
+	return s + ";"
+}
+
+// dropEnum generates an enum drop.
+func (f *Funcs) dropEnum(e xo.Enum) string {
+	if f.d.Driver != "postgres" {
+		return ""
+	}
+	return fmt.Sprintf("DROP TYPE %s;", f.d.EscType(e.Name))
+}
+
+// dropTable generates a table drop.
+func (f *Funcs) dropTable(table xo.Table) string {
+	return fmt.Sprintf("DROP TABLE %s;", f.d.EscType(table.Name))
+}
+
+// dropView generates a view drop.
+func (f *Funcs) dropView(view xo.Table) string {
+	return fmt.Sprintf("DROP VIEW %s;", f.d.EscType(view.Name))
+}
+
+// addColumn generates a column addition. Foreign keys are added separately.
+func (f *Funcs) addColumn(c TableColumn) string {
+	def := f.d.Coldef(xo.Table{Name: c.Table.Name}, c.Field)
+	switch f.d.Driver {
+	case "sqlserver":
+		return fmt.Sprintf("ALTER TABLE %s ADD %s;", f.d.EscType(c.Table.Name), def)
+	case "oracle":
+		return fmt.Sprintf("ALTER TABLE %s ADD (%s);", f.d.EscType(c.Table.Name), def)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", f.d.EscType(c.Table.Name), def)
+}
+
+// alterColumn generates a column alteration.
+func (f *Funcs) alterColumn(c TableColumn) string {
+	table, name := f.d.EscType(c.Table.Name), f.d.EscCol(c.Field.Name)
+	typ := f.d.Normalize(c.Field.Type)
+	switch f.d.Driver {
+	case "postgres":
+		var actions []string
+		if f.d.Normalize(c.Before.Type) != typ {
+			actions = append(actions, fmt.Sprintf("ALTER COLUMN %s TYPE %s", name, typ))
+		}
+		if def := f.d.AlterDefault(c.Field.Default); !c.Field.IsSequence && def != f.d.AlterDefault(c.Before.Default) {
+			if def == "" {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", name))
+			} else {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", name, def))
+			}
+		}
+		if c.Field.Type.Nullable != c.Before.Type.Nullable {
+			if c.Field.Type.Nullable {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", name))
+			} else {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", name))
+			}
+		}
+		return fmt.Sprintf("ALTER TABLE %s %s;", table, strings.Join(actions, ", "))
+	case "mysql":
+		return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, f.d.Coldef(xo.Table{Name: c.Table.Name}, c.Field))
+	case "sqlserver":
+		if f.d.AlterDefault(c.Field.Default) != f.d.AlterDefault(c.Before.Default) {
+			return f.unsupported("cannot change default of %s.%s", c.Table.Name, c.Field.Name)
+		}
+		null := "NULL"
+		if !c.Field.Type.Nullable {
+			null = "NOT NULL"
+		}
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s;", table, name, typ, null)
+	case "oracle":
+		def := []string{name, typ}
+		if d := f.d.AlterDefault(c.Field.Default); d != "" && !c.Field.IsSequence {
+			def = append(def, "DEFAULT", d)
+		}
+		if c.Field.Type.Nullable != c.Before.Type.Nullable {
+			if c.Field.Type.Nullable {
+				def = append(def, "NULL")
+			} else {
+				def = append(def, "NOT NULL")
+			}
+		}
+		return fmt.Sprintf("ALTER TABLE %s MODIFY (%s);", table, strings.Join(def, " "))
+	}
+	return f.unsupported("cannot alter column %s.%s", c.Table.Name, c.Field.Name)
+}
+
+// dropColumn generates a column drop.
+func (f *Funcs) dropColumn(c TableColumn) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", f.d.EscType(c.Table.Name), f.d.EscCol(c.Field.Name))
+}
+
+// createIndex generates an index creation.
+func (f *Funcs) createIndex(i TableIndex) string {
+	table := f.d.EscType(i.Table.Name)
+	switch {
+	case i.Index.IsPrimary && f.d.Driver == "sqlite3":
+		return f.unsupported("cannot add primary key to %s", i.Table.Name)
+	case i.Index.IsPrimary:
+		return fmt.Sprintf("ALTER TABLE %s ADD %sPRIMARY KEY (%s);", table, f.d.ConstraintName(i.Index.Name), f.d.Fields(i.Index.Fields))
+	case strings.HasPrefix(i.Index.Name, "sqlite_autoindex_"):
+		return f.unsupported("cannot add unique constraint to %s", i.Table.Name)
+	}
+	unique := ""
+	if i.Index.IsUnique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique, f.d.EscType(i.Index.Name), table, f.d.Fields(i.Index.Fields))
+}
+
+// dropIndex generates an index drop.
+//
+// Postgres primary keys, and unique indexes having the default unique
+// constraint suffix (_key), are dropped as constraints.
+func (f *Funcs) dropIndex(i TableIndex) string {
+	table, name := f.d.EscType(i.Table.Name), f.d.EscType(i.Index.Name)
+	switch f.d.Driver {
+	case "postgres":
+		if i.Index.IsPrimary || i.Index.IsUnique && strings.HasSuffix(i.Index.Name, "_key") {
+			return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", table, name)
+		}
+	case "mysql":
+		if i.Index.IsPrimary {
+			return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", table)
+		}
+		return fmt.Sprintf("DROP INDEX %s ON %s;", name, table)
+	case "sqlite3":
+		if i.Index.IsPrimary || strings.HasPrefix(i.Index.Name, "sqlite_autoindex_") {
+			return f.unsupported("cannot drop index %s on %s", i.Index.Name, i.Table.Name)
+		}
+	case "sqlserver":
+		if i.Index.IsPrimary {
+			return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", table, name)
+		}
+		return fmt.Sprintf("DROP INDEX %s ON %s;", name, table)
+	}
+	return fmt.Sprintf("DROP INDEX %s;", name)
+}
+
+// addForeignKey generates a foreign key addition.
+func (f *Funcs) addForeignKey(fk TableForeignKey) string {
+	if f.d.Driver == "sqlite3" {
+		return f.unsupported("cannot add foreign key %s to %s", fk.ForeignKey.Name, fk.Table.Name)
+	}
+	return fmt.Sprintf(
+		"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s);",
+		f.d.EscType(fk.Table.Name), f.d.EscType(fk.ForeignKey.Name), f.d.Fields(fk.ForeignKey.Fields),
+		f.d.EscType(fk.ForeignKey.RefTable), f.d.Fields(fk.ForeignKey.RefFields),
+	)
+}
+
+// dropForeignKey generates a foreign key drop.
+func (f *Funcs) dropForeignKey(fk TableForeignKey) string {
+	table, name := f.d.EscType(fk.Table.Name), f.d.EscType(fk.ForeignKey.Name)
+	switch f.d.Driver {
+	case "sqlite3":
+		return f.unsupported("cannot drop foreign key %s on %s", fk.ForeignKey.Name, fk.Table.Name)
+	case "mysql":
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", table, name)
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", table, name)
+}
+
+// Context keys.
+var (
+	BeforeKey xo.ContextKey = "before"
+)
+
+// Before returns before from the context.
+func Before(ctx context.Context) string {
+	s, _ := ctx.Value(BeforeKey).(string)
+	return s
+}
@@ -0,0 +1,243 @@
+//go:build dbtpl
+
+package migrate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestNewMigration(t *testing.T) {
+	authorID := xo.Field{Name: "author_id", Type: xo.Type{Type: "integer"}, IsPrimary: true}
+	name := xo.Field{Name: "name", Type: xo.Type{Type: "text"}}
+	bookID := xo.Field{Name: "book_id", Type: xo.Type{Type: "integer"}, IsPrimary: true}
+	bookAuthorID := xo.Field{Name: "author_id", Type: xo.Type{Type: "integer"}}
+	title := xo.Field{Name: "title", Type: xo.Type{Type: "character varying", Prec: 100}}
+	authors := xo.Table{
+		Type:        "table",
+		Name:        "authors",
+		Columns:     []xo.Field{authorID, name},
+		PrimaryKeys: []xo.Field{authorID},
+		Indexes:     []xo.Index{{Name: "authors_pkey", Fields: []xo.Field{authorID}, IsPrimary: true}},
+	}
+	books := xo.Table{
+		Type:        "table",
+		Name:        "books",
+		Columns:     []xo.Field{bookID, bookAuthorID, title},
+		PrimaryKeys: []xo.Field{bookID},
+		Indexes:     []xo.Index{{Name: "books_pkey", Fields: []xo.Field{bookID}, IsPrimary: true}},
+		ForeignKeys: []xo.ForeignKey{{Name: "books_author_id_fkey", Fields: []xo.Field{bookAuthorID}, RefTable: "authors", RefFields: []xo.Field{authorID}}},
+	}
+	view := xo.Table{Type: "view", Name: "book_titles", Definition: "SELECT title FROM books"}
+	tests := []struct {
+		name   string
+		before xo.Schema
+		after  xo.Schema
+		exp    []string
+	}{
+		{
+			"unchanged",
+			xo.Schema{Tables: []xo.Table{authors, books}, Views: []xo.Table{view}},
+			xo.Schema{Tables: []xo.Table{authors, books}, Views: []xo.Table{view}},
+			nil,
+		},
+		{
+			"create tables",
+			xo.Schema{},
+			xo.Schema{Tables: []xo.Table{books, authors}},
+			[]string{"create table authors", "create table books"},
+		},
+		{
+			"drop tables",
+			xo.Schema{Tables: []xo.Table{authors, books}},
+			xo.Schema{},
+			[]string{"drop table books", "drop table authors"},
+		},
+		{
+			"columns",
+			xo.Schema{Tables: []xo.Table{books}},
+			xo.Schema{Tables: []xo.Table{withColumns(books,
+				bookID,
+				xo.Field{Name: "author_id", Type: xo.Type{Type: "bigint"}},
+				xo.Field{Name: "title", Type: xo.Type{Type: "character varying", Prec: 100}, Default: "''::character varying"},
+				xo.Field{Name: "pages", Type: xo.Type{Type: "integer", Nullable: true}},
+			)}},
+			[]string{"add column books.pages", "alter column books.author_id", "alter column books.title"},
+		},
+		{
+			"drop column",
+			xo.Schema{Tables: []xo.Table{withColumns(authors, authorID, name)}},
+			xo.Schema{Tables: []xo.Table{withColumns(authors, authorID)}},
+			[]string{"drop column authors.name"},
+		},
+		{
+			"unchanged default cast",
+			xo.Schema{Tables: []xo.Table{withColumns(authors, authorID, xo.Field{Name: "name", Type: xo.Type{Type: "text"}, Default: "'x'::text"})}},
+			xo.Schema{Tables: []xo.Table{withColumns(authors, authorID, xo.Field{Name: "name", Type: xo.Type{Type: "text"}, Default: "'x'"})}},
+			nil,
+		},
+		{
+			"foreign keys",
+			xo.Schema{Tables: []xo.Table{authors, books}},
+			xo.Schema{Tables: []xo.Table{authors, withForeignKeys(books,
+				xo.ForeignKey{Name: "books_author_id_fkey", Fields: []xo.Field{bookAuthorID}, RefTable: "authors", RefFields: []xo.Field{name}},
+				xo.ForeignKey{Name: "books_book_id_fkey", Fields: []xo.Field{bookID}, RefTable: "authors", RefFields: []xo.Field{authorID}},
+			)}},
+			[]string{"drop foreign key books.books_author_id_fkey", "add foreign key books.books_author_id_fkey", "add foreign key books.books_book_id_fkey"},
+		},
+		{
+			"drop foreign key",
+			xo.Schema{Tables: []xo.Table{authors, books}},
+			xo.Schema{Tables: []xo.Table{authors, withForeignKeys(books)}},
+			[]string{"drop foreign key books.books_author_id_fkey"},
+		},
+		{
+			"indexes",
+			xo.Schema{Tables: []xo.Table{withIndexes(books,
+				xo.Index{Name: "books_pkey", Fields: []xo.Field{bookID}, IsPrimary: true},
+				xo.Index{Name: "books_title_idx", Fields: []xo.Field{title}},
+				xo.Index{Name: "books_author_id_idx", Fields: []xo.Field{bookAuthorID}},
+			)}},
+			xo.Schema{Tables: []xo.Table{withIndexes(books,
+				xo.Index{Name: "books_pkey", Fields: []xo.Field{bookID}, IsPrimary: true},
+				xo.Index{Name: "books_title_idx", Fields: []xo.Field{title}, IsUnique: true},
+				xo.Index{Name: "books_author_id_title_idx", Fields: []xo.Field{bookAuthorID, title}},
+			)}},
+			[]string{"drop index books.books_title_idx", "drop index books.books_author_id_idx", "create index books.books_title_idx", "create index books.books_author_id_title_idx"},
+		},
+		{
+			"views",
+			xo.Schema{Views: []xo.Table{view, {Type: "view", Name: "old", Definition: "SELECT 1"}}},
+			xo.Schema{Views: []xo.Table{
+				{Type: "view", Name: "book_titles", Definition: "SELECT title, book_id FROM books"},
+				{Type: "view", Name: "new", Definition: "SELECT 2"},
+			}},
+			[]string{"drop view book_titles", "drop view old", "create view book_titles", "create view new"},
+		},
+		{
+			"enums",
+			xo.Schema{Enums: []xo.Enum{
+				{Name: "book_type", Values: []xo.Field{{Name: "FICTION"}, {Name: "NONFICTION"}}},
+				{Name: "old_type", Values: []xo.Field{{Name: "A"}}},
+			}},
+			xo.Schema{Enums: []xo.Enum{
+				{Name: "book_type", Values: []xo.Field{{Name: "FICTION"}, {Name: "POETRY"}, {Name: "NONFICTION"}}},
+				{Name: "new_type", Values: []xo.Field{{Name: "B"}}},
+			}},
+			[]string{"create enum new_type", "add enum value book_type.POETRY after FICTION", "drop enum old_type"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if changes := migrationChanges(NewMigration("postgres", test.before, test.after)); !reflect.DeepEqual(changes, test.exp) {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.exp, changes)
+			}
+		})
+	}
+}
+
+func TestFindSchema(t *testing.T) {
+	set := &xo.Set{Schemas: []xo.Schema{
+		{Driver: "postgres", Name: "public", Tables: []xo.Table{{Name: "authors"}}},
+		{Driver: "postgres", Name: "auth", Tables: []xo.Table{{Name: "users"}}},
+	}}
+	tests := []struct {
+		name   string
+		driver string
+		exp    []string
+		err    bool
+	}{
+		{"public", "postgres", []string{"authors"}, false},
+		{"auth", "postgres", []string{"users"}, false},
+		{"billing", "postgres", nil, false},
+		{"public", "mysql", nil, true},
+	}
+	for i, test := range tests {
+		schema, err := findSchema(set, test.name, test.driver)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case !test.err && schema.Name != test.name:
+			t.Errorf("test %d expected schema %s, got: %s", i, test.name, schema.Name)
+		}
+		var tables []string
+		for _, table := range schema.Tables {
+			tables = append(tables, table.Name)
+		}
+		if !reflect.DeepEqual(tables, test.exp) {
+			t.Errorf("test %d expected tables %v, got: %v", i, test.exp, tables)
+		}
+	}
+}
+
+// migrationChanges returns a description of each of the migration's changes,
+// in the order they are applied.
+func migrationChanges(m Migration) []string {
+	var changes []string
+	add := func(format string, v ...any) {
+		changes = append(changes, fmt.Sprintf(format, v...))
+	}
+	for _, v := range m.DropViews {
+		add("drop view %s", v.Name)
+	}
+	for _, fk := range m.DropForeignKeys {
+		add("drop foreign key %s.%s", fk.Table.Name, fk.ForeignKey.Name)
+	}
+	for _, i := range m.DropIndexes {
+		add("drop index %s.%s", i.Table.Name, i.Index.Name)
+	}
+	for _, t := range m.DropTables {
+		add("drop table %s", t.Name)
+	}
+	for _, e := range m.CreateEnums {
+		add("create enum %s", e.Name)
+	}
+	for _, v := range m.AddEnumValues {
+		add("add enum value %s.%s after %s", v.Enum.Name, v.Value, v.Prev)
+	}
+	for _, t := range m.CreateTables {
+		add("create table %s", t.Name)
+	}
+	for _, c := range m.AddColumns {
+		add("add column %s.%s", c.Table.Name, c.Field.Name)
+	}
+	for _, c := range m.AlterColumns {
+		add("alter column %s.%s", c.Table.Name, c.Field.Name)
+	}
+	for _, c := range m.DropColumns {
+		add("drop column %s.%s", c.Table.Name, c.Field.Name)
+	}
+	for _, i := range m.CreateIndexes {
+		add("create index %s.%s", i.Table.Name, i.Index.Name)
+	}
+	for _, fk := range m.AddForeignKeys {
+		add("add foreign key %s.%s", fk.Table.Name, fk.ForeignKey.Name)
+	}
+	for _, e := range m.DropEnums {
+		add("drop enum %s", e.Name)
+	}
+	for _, v := range m.CreateViews {
+		add("create view %s", v.Name)
+	}
+	return changes
+}
+
+func withColumns(table xo.Table, columns ...xo.Field) xo.Table {
+	table.Columns = columns
+	return table
+}
+
+func withIndexes(table xo.Table, indexes ...xo.Index) xo.Table {
+	table.Indexes = indexes
+	return table
+}
+
+func withForeignKeys(table xo.Table, fkeys ...xo.ForeignKey) xo.Table {
+	table.ForeignKeys = fkeys
+	return table
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"maps"
@@ -346,6 +347,126 @@ func (ts *Templates) Dump(out string) {
 	}
 }
 
+// Record records the generated files written without error in the out path's
+// manifest for the key, replacing the files previously recorded for the key.
+// Files recorded for other keys are not changed.
+func (ts *Templates) Record(key, out string) {
+	manifest, err := readManifest(out)
+	if err != nil {
+		ts.err = err
+		return
+	}
+	var files []string
+	for _, file := range slices.Sorted(maps.Keys(ts.files)) {
+		if len(ts.files[file].Err) == 0 {
+			files = append(files, file)
+		}
+	}
+	manifest[key] = files
+	if err := writeManifest(out, manifest); err != nil {
+		ts.err = err
+	}
+}
+
+// Stale returns the files recorded in the out path's manifest for the key
+// that were not generated. Files also recorded for another key are not stale.
+func (ts *Templates) Stale(key, out string) ([]string, error) {
+	manifest, err := readManifest(out)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, file := range manifest[key] {
+		if _, ok := ts.files[file]; ok {
+			continue
+		}
+		other := false
+		for k, files := range manifest {
+			if k != key && slices.Contains(files, file) {
+				other = true
+			}
+		}
+		if !other {
+			stale = append(stale, file)
+		}
+	}
+	return stale, nil
+}
+
+// Prune removes the files recorded in the out path's manifest for the key that
+// were not generated. Files not recorded in the manifest for the key are never
+// removed.
+func (ts *Templates) Prune(key, out string) {
+	stale, err := ts.Stale(key, out)
+	if err != nil {
+		ts.err = err
+		return
+	}
+	for _, file := range stale {
+		if err := os.Remove(filepath.Join(out, file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			ts.err = err
+			return
+		}
+	}
+}
+
+// ManifestFile is the name of the manifest file written to the out path when
+// pruning, recording the files generated by dbtpl for each key.
+const ManifestFile = ".dbtpl-manifest"
+
+// readManifest reads the manifest in the out path, returning the recorded file
+// names by key. Each key's files follow a [key] line. Names that are not local
+// paths are ignored.
+func readManifest(out string) (map[string][]string, error) {
+	manifest := make(map[string][]string)
+	buf, err := os.ReadFile(filepath.Join(out, ManifestFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return manifest, nil
+	case err != nil:
+		return nil, err
+	}
+	var key string
+	for line := range strings.Lines(string(buf)) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			key = line[1 : len(line)-1]
+		case line == "", strings.HasPrefix(line, "#"), line == ManifestFile, !filepath.IsLocal(line):
+		default:
+			manifest[key] = append(manifest[key], line)
+		}
+	}
+	return manifest, nil
+}
+
+// writeManifest writes the manifest to the out path.
+func writeManifest(out string, manifest map[string][]string) error {
+	buf := new(bytes.Buffer)
+	buf.WriteString("# Generated by dbtpl. DO NOT EDIT.\n")
+	for _, key := range slices.Sorted(maps.Keys(manifest)) {
+		if len(manifest[key]) == 0 {
+			continue
+		}
+		if key != "" {
+			buf.WriteString("[" + key + "]\n")
+		}
+		for _, file := range manifest[key] {
+			buf.WriteString(file + "\n")
+		}
+	}
+	return os.WriteFile(filepath.Join(out, ManifestFile), buf.Bytes(), 0o644)
+}
+
+// Files returns the generated file contents, keyed by file name.
+func (ts *Templates) Files() map[string][]byte {
+	files := make(map[string][]byte, len(ts.files))
+	for file, emitted := range ts.files {
+		files[file] = emitted.Buf.Bytes()
+	}
+	return files
+}
+
 // Errors returns any collected errors.
 func (set *Templates) Errors() []error {
 	var errors []error
@@ -582,5 +703,6 @@ func (src sourceFS) Open(name string) (fs.File, error) {
 //go:embed dot
 //go:embed go
 //go:embed json
+//go:embed migrate
 //go:embed yaml
 var files embed.FS
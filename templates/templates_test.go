@@ -0,0 +1,145 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	out := t.TempDir()
+	// file in place of a directory, causing a write error
+	if err := os.WriteFile(filepath.Join(out, "dir"), nil, 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	ts := newTestTemplates("a.go", "b.go", "dir/c.go")
+	ts.Dump(out)
+	if ts.err != nil {
+		t.Fatalf("expected no error, got: %v", ts.err)
+	}
+	if len(ts.files["dir/c.go"].Err) == 0 {
+		t.Errorf("expected write error for dir/c.go")
+	}
+	for _, file := range []string{"a.go", "b.go"} {
+		if buf, err := os.ReadFile(filepath.Join(out, file)); err != nil || string(buf) != file {
+			t.Errorf("expected %s to be written, got: %q %v", file, buf, err)
+		}
+	}
+	// no manifest without recording
+	if exp := []string{"a.go", "b.go", "dir"}; !reflect.DeepEqual(dirFiles(t, out), exp) {
+		t.Errorf("expected files %v, got: %v", exp, dirFiles(t, out))
+	}
+	ts.Record("schema", out)
+	if ts.err != nil {
+		t.Fatalf("expected no error, got: %v", ts.err)
+	}
+	manifest, err := readManifest(out)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := map[string][]string{"schema": {"a.go", "b.go"}}
+	if !reflect.DeepEqual(manifest, exp) {
+		t.Errorf("expected manifest %v, got: %v", exp, manifest)
+	}
+}
+
+func TestStale(t *testing.T) {
+	out := t.TempDir()
+	dumpTestTemplates(t, "schema", out, "a.go", "b.go")
+	ts := newTestTemplates("a.go")
+	stale, err := ts.Stale("schema", out)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := []string{"b.go"}; !reflect.DeepEqual(stale, exp) {
+		t.Errorf("expected stale %v, got: %v", exp, stale)
+	}
+	// files for other keys are not stale
+	if stale, err = ts.Stale("query:queries.dbtpl.go", out); err != nil || stale != nil {
+		t.Errorf("expected no stale files for other key, got: %v %v", stale, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	out := t.TempDir()
+	dumpTestTemplates(t, "schema", out, "a.go", "b.go")
+	if err := os.WriteFile(filepath.Join(out, "user.go"), nil, 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	dumpTestTemplates(t, "schema", out, "a.go")
+	if exp := []string{".dbtpl-manifest", "a.go", "user.go"}; !reflect.DeepEqual(dirFiles(t, out), exp) {
+		t.Errorf("expected files %v, got: %v", exp, dirFiles(t, out))
+	}
+}
+
+func TestPruneKeys(t *testing.T) {
+	out := t.TempDir()
+	dumpTestTemplates(t, "schema", out, "db.go", "author.go", "book.go")
+	dumpTestTemplates(t, "query:authors.sql", out, "db.go", "authors.go", "old.go")
+	dumpTestTemplates(t, "query:books.sql", out, "db.go", "books.go")
+	// prune a query run, without removing the files of the other runs
+	dumpTestTemplates(t, "query:authors.sql", out, "authors.go")
+	if exp := []string{".dbtpl-manifest", "author.go", "authors.go", "book.go", "books.go", "db.go"}; !reflect.DeepEqual(dirFiles(t, out), exp) {
+		t.Errorf("expected files %v, got: %v", exp, dirFiles(t, out))
+	}
+	// prune schema mode, without removing query files
+	dumpTestTemplates(t, "schema", out, "db.go", "author.go")
+	if exp := []string{".dbtpl-manifest", "author.go", "authors.go", "books.go", "db.go"}; !reflect.DeepEqual(dirFiles(t, out), exp) {
+		t.Errorf("expected files %v, got: %v", exp, dirFiles(t, out))
+	}
+	manifest, err := readManifest(out)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := map[string][]string{
+		"query:authors.sql": {"authors.go"},
+		"query:books.sql":   {"books.go", "db.go"},
+		"schema":            {"author.go", "db.go"},
+	}
+	if !reflect.DeepEqual(manifest, exp) {
+		t.Errorf("expected manifest %v, got: %v", exp, manifest)
+	}
+}
+
+// dumpTestTemplates prunes, dumps, and records the generated files for the
+// key, as with --prune.
+func dumpTestTemplates(t *testing.T, key, out string, files ...string) {
+	t.Helper()
+	ts := newTestTemplates(files...)
+	ts.Prune(key, out)
+	ts.Dump(out)
+	ts.Record(key, out)
+	if ts.err != nil {
+		t.Fatalf("expected no error, got: %v", ts.err)
+	}
+}
+
+// newTestTemplates creates a template set with the generated files, with
+// each file's content being its name.
+func newTestTemplates(files ...string) *Templates {
+	ts := New(nil, "")
+	for _, file := range files {
+		ts.files[file] = &EmittedTemplate{}
+		ts.files[file].Buf.WriteString(file)
+	}
+	return ts
+}
+
+// dirFiles returns the sorted names of the files in the directory.
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+	}
+	slices.Sort(files)
+	return files
+}
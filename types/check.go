@@ -0,0 +1,375 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/xo/dbtpl/sqllex"
+)
+
+// Rule is a simple rule of a check constraint, comparing a column's value, or
+// its length, with literal values.
+type Rule struct {
+	Column string
+	Length string   // lower cased length func, when comparing the column's length
+	Op     string   // =, <>, <, <=, >, >=, in, or not in
+	Values []string // unquoted literal values
+}
+
+// ParseCheck parses a check constraint expression for the driver into simple
+// rules, that must all be satisfied.
+//
+// The expression must be a conjunction of comparisons of a column, or a
+// column's length, with literals, including IN, NOT IN, BETWEEN, and = ANY
+// (ARRAY[...]) lists. A disjunction of equality comparisons on the same
+// column is parsed as an IN list. Casts, charset introducers, and redundant
+// parentheses, as found in the expressions returned by databases, are
+// ignored. Returns false when the expression is not made of simple rules.
+func ParseCheck(driver, expr string) ([]Rule, bool) {
+	toks, err := sqllex.Lex(driver, expr)
+	if err != nil {
+		return nil, false
+	}
+	p := &checkParser{
+		toks: stripCasts(stripIntroducers(toks)),
+	}
+	rules, ok := p.or()
+	if !ok || p.i != len(p.toks) {
+		return nil, false
+	}
+	return rules, true
+}
+
+// stripIntroducers removes the national string prefixes and charset
+// introducers (ie, N'a', _utf8mb4'a') from the tokens.
+func stripIntroducers(toks []sqllex.Token) []sqllex.Token {
+	var res []sqllex.Token
+	for i, tok := range toks {
+		if i+1 < len(toks) && toks[i+1].Type == sqllex.String && toks[i+1].Pos == tok.End &&
+			tok.Type == sqllex.Word && (strings.EqualFold(tok.Text, "n") || strings.HasPrefix(tok.Text, "_")) {
+			continue
+		}
+		res = append(res, tok)
+	}
+	return res
+}
+
+// stripCasts removes the postgres casts (ie, ::text, ::character
+// varying(255), ::text[]) from the tokens.
+func stripCasts(toks []sqllex.Token) []sqllex.Token {
+	var res []sqllex.Token
+	for i := 0; i < len(toks); i++ {
+		if !toks[i].IsPunct("::") {
+			res = append(res, toks[i])
+			continue
+		}
+		// type name
+		for i+1 < len(toks) && (toks[i+1].Type == sqllex.Ident || toks[i+1].Type == sqllex.Word && !isCheckKeyword(toks[i+1].Text) ||
+			toks[i+1].IsPunct(".")) {
+			i++
+		}
+		// type modifiers
+		if i+1 < len(toks) && toks[i+1].IsPunct("(") {
+			for i++; i < len(toks) && !toks[i].IsPunct(")"); i++ {
+			}
+		}
+		// array
+		for i+2 < len(toks) && toks[i+1].Text == "[" && toks[i+2].Text == "]" {
+			i += 2
+		}
+	}
+	return res
+}
+
+// isCheckKeyword returns true when s is a keyword that cannot be a column name
+// or part of a type name.
+func isCheckKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not", "in", "between", "is", "like", "any", "all", "some",
+		"array", "null", "true", "false", "case", "when", "exists", "select":
+		return true
+	}
+	return false
+}
+
+// isLengthFunc returns true when s is a character length func.
+func isLengthFunc(s string) bool {
+	switch strings.ToLower(s) {
+	case "length", "char_length", "character_length", "len":
+		return true
+	}
+	return false
+}
+
+// checkParser is a check expression parser.
+type checkParser struct {
+	toks []sqllex.Token
+	i    int
+}
+
+// peek returns the current token.
+func (p *checkParser) peek() sqllex.Token {
+	if p.i < len(p.toks) {
+		return p.toks[p.i]
+	}
+	return sqllex.Token{}
+}
+
+// is returns true when the current token is the operator or keyword s.
+func (p *checkParser) is(s string) bool {
+	tok := p.peek()
+	return tok.IsPunct(s) || tok.IsWord(s)
+}
+
+// accept consumes the operators or keywords when they are next.
+func (p *checkParser) accept(words ...string) bool {
+	i := p.i
+	for _, s := range words {
+		if !p.is(s) {
+			p.i = i
+			return false
+		}
+		p.i++
+	}
+	return true
+}
+
+// or parses a disjunction.
+func (p *checkParser) or() ([]Rule, bool) {
+	var terms [][]Rule
+	for {
+		rules, ok := p.and()
+		if !ok {
+			return nil, false
+		}
+		terms = append(terms, rules)
+		if !p.accept("or") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], true
+	}
+	// disjunction of equalities on a column is an in list
+	rule := Rule{
+		Column: terms[0][0].Column,
+		Length: terms[0][0].Length,
+		Op:     "in",
+	}
+	for _, rules := range terms {
+		switch r := rules[0]; {
+		case len(rules) != 1, r.Op != "=" && r.Op != "in",
+			!strings.EqualFold(r.Column, rule.Column), r.Length != rule.Length:
+			return nil, false
+		}
+		rule.Values = append(rule.Values, rules[0].Values...)
+	}
+	return []Rule{rule}, true
+}
+
+// and parses a conjunction.
+func (p *checkParser) and() ([]Rule, bool) {
+	var res []Rule
+	for {
+		rules, ok := p.pred()
+		if !ok {
+			return nil, false
+		}
+		res = append(res, rules...)
+		if !p.accept("and") {
+			return res, true
+		}
+	}
+}
+
+// pred parses a parenthesized expression or a comparison.
+func (p *checkParser) pred() ([]Rule, bool) {
+	if p.is("(") {
+		i := p.i
+		p.i++
+		if rules, ok := p.or(); ok && p.accept(")") {
+			return rules, true
+		}
+		p.i = i
+	}
+	// literal compared with column (ie, 0 < price)
+	if v, ok := p.value(); ok {
+		op, ok := p.op()
+		if !ok {
+			return nil, false
+		}
+		column, length, ok := p.operand()
+		if !ok {
+			return nil, false
+		}
+		switch op {
+		case "<":
+			op = ">"
+		case "<=":
+			op = ">="
+		case ">":
+			op = "<"
+		case ">=":
+			op = "<="
+		}
+		return []Rule{{Column: column, Length: length, Op: op, Values: []string{v}}}, true
+	}
+	column, length, ok := p.operand()
+	if !ok {
+		return nil, false
+	}
+	rule := Rule{
+		Column: column,
+		Length: length,
+	}
+	not := p.accept("not")
+	switch {
+	case p.accept("in"):
+		rule.Op = "in"
+		if not {
+			rule.Op = "not in"
+		}
+		if rule.Values, ok = p.list(); !ok {
+			return nil, false
+		}
+		return []Rule{rule}, true
+	case !not && p.accept("between"):
+		lower, ok := p.value()
+		if !ok || !p.accept("and") {
+			return nil, false
+		}
+		upper, ok := p.value()
+		if !ok {
+			return nil, false
+		}
+		return []Rule{
+			{Column: column, Length: length, Op: ">=", Values: []string{lower}},
+			{Column: column, Length: length, Op: "<=", Values: []string{upper}},
+		}, true
+	case not:
+		return nil, false
+	}
+	if rule.Op, ok = p.op(); !ok {
+		return nil, false
+	}
+	switch {
+	case rule.Op == "=" && p.accept("any"):
+		rule.Op = "in"
+		rule.Values, ok = p.list()
+	case rule.Op == "<>" && p.accept("all"):
+		rule.Op = "not in"
+		rule.Values, ok = p.list()
+	default:
+		var v string
+		v, ok = p.value()
+		rule.Values = []string{v}
+	}
+	if !ok {
+		return nil, false
+	}
+	return []Rule{rule}, true
+}
+
+// op consumes a comparison operator.
+func (p *checkParser) op() (string, bool) {
+	tok := p.peek()
+	if tok.Type != sqllex.Punct {
+		return "", false
+	}
+	switch tok.Text {
+	case "=", "<>", "<", "<=", ">", ">=":
+		p.i++
+		return tok.Text, true
+	case "!=":
+		p.i++
+		return "<>", true
+	}
+	return "", false
+}
+
+// operand consumes a column, or a character length func of a column,
+// returning the column name and the lower cased length func name.
+func (p *checkParser) operand() (string, string, bool) {
+	i := p.i
+	tok := p.peek()
+	switch {
+	case p.accept("("):
+		if column, length, ok := p.operand(); ok && p.accept(")") {
+			return column, length, true
+		}
+	case tok.Type == sqllex.Word && isLengthFunc(tok.Text) && p.i+1 < len(p.toks) && p.toks[p.i+1].Text == "(":
+		p.i += 2
+		if column, length, ok := p.operand(); ok && length == "" && p.accept(")") {
+			return column, strings.ToLower(tok.Text), true
+		}
+	case tok.Type == sqllex.Ident, tok.Type == sqllex.Word && !isCheckKeyword(tok.Text):
+		p.i++
+		// qualified name
+		for p.is(".") && p.i+1 < len(p.toks) && p.toks[p.i+1].IsName() {
+			tok = p.toks[p.i+1]
+			p.i += 2
+		}
+		if !p.is("(") {
+			return tok.Text, "", true
+		}
+	}
+	p.i = i
+	return "", "", false
+}
+
+// value consumes a literal value.
+func (p *checkParser) value() (string, bool) {
+	i := p.i
+	tok := p.peek()
+	switch {
+	case p.accept("("):
+		if v, ok := p.value(); ok && p.accept(")") {
+			return v, true
+		}
+	case tok.Type == sqllex.String, tok.Type == sqllex.Number:
+		p.i++
+		return tok.Text, true
+	case p.is("-"), p.is("+"):
+		p.i++
+		if next := p.peek(); next.Type == sqllex.Number {
+			p.i++
+			return strings.TrimPrefix(tok.Text, "+") + next.Text, true
+		}
+	}
+	p.i = i
+	return "", false
+}
+
+// list consumes a parenthesized list of values, or an array of values (ie,
+// ARRAY[...] or (ARRAY[...])).
+func (p *checkParser) list() ([]string, bool) {
+	switch {
+	case p.accept("array", "["):
+		return p.values("]")
+	case p.accept("("):
+		if !p.is("(") && !p.is("array") {
+			return p.values(")")
+		}
+		if values, ok := p.list(); ok && p.accept(")") {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+// values consumes a comma separated list of values up to end.
+func (p *checkParser) values(end string) ([]string, bool) {
+	var values []string
+	for {
+		v, ok := p.value()
+		if !ok {
+			return nil, false
+		}
+		values = append(values, v)
+		switch {
+		case p.accept(end):
+			return values, true
+		case !p.accept(","):
+			return nil, false
+		}
+	}
+}
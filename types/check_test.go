@@ -0,0 +1,41 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCheck(t *testing.T) {
+	tests := []struct {
+		driver string
+		expr   string
+		exp    []Rule
+	}{
+		{"postgres", "((kind = ANY (ARRAY['a'::text, 'b'::text])))", []Rule{{Column: "kind", Op: "in", Values: []string{"a", "b"}}}},
+		{"postgres", "((char_length((code)::text) >= 2))", []Rule{{Column: "code", Length: "char_length", Op: ">=", Values: []string{"2"}}}},
+		{"sqlserver", "(LEN([code])>(2))", []Rule{{Column: "code", Length: "len", Op: ">", Values: []string{"2"}}}},
+		{"mysql", "(`status` in (_utf8mb4'draft',_utf8mb4'published'))", []Rule{{Column: "status", Op: "in", Values: []string{"draft", "published"}}}},
+		{"sqlserver", "([status]=N'b' OR [status]=N'a')", []Rule{{Column: "status", Op: "in", Values: []string{"b", "a"}}}},
+		{"sqlserver", "([qty]>=(0) AND [qty]<=(100))", []Rule{{Column: "qty", Op: ">=", Values: []string{"0"}}, {Column: "qty", Op: "<=", Values: []string{"100"}}}},
+		{"sqlite3", "rating BETWEEN 1 AND 5", []Rule{{Column: "rating", Op: ">=", Values: []string{"1"}}, {Column: "rating", Op: "<=", Values: []string{"5"}}}},
+		{"postgres", "0 < price", []Rule{{Column: "price", Op: ">", Values: []string{"0"}}}},
+		{"oracle", `"KIND" NOT IN ('x')`, []Rule{{Column: "KIND", Op: "not in", Values: []string{"x"}}}},
+		{"postgres", "((kind)::text = ANY ((ARRAY['a'::character varying, 'b'::character varying])::text[]))", []Rule{{Column: "kind", Op: "in", Values: []string{"a", "b"}}}},
+		{"mysql", `(name <> 'it\'s')`, []Rule{{Column: "name", Op: "<>", Values: []string{"it's"}}}},
+		{"postgres", "(name <> 'it''s')", []Rule{{Column: "name", Op: "<>", Values: []string{"it's"}}}},
+		{"postgres", "name <> 'a", nil},
+		{"postgres", "upper(title) <> title", nil},
+		{"postgres", "a = 1 OR b = 2", nil},
+		{"postgres", "a > b", nil},
+		{"postgres", "code IS NOT NULL", nil},
+	}
+	for i, test := range tests {
+		rules, ok := ParseCheck(test.driver, test.expr)
+		switch {
+		case ok != (test.exp != nil):
+			t.Errorf("test %d expected ok %t for %q, got: %t", i, test.exp != nil, test.expr, ok)
+		case !reflect.DeepEqual(rules, test.exp):
+			t.Errorf("test %d expected rules %v for %q, got: %v", i, test.exp, test.expr, rules)
+		}
+	}
+}
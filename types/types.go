@@ -4,13 +4,18 @@ package types
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
+
+	"github.com/goccy/go-yaml"
 )
 
 // Set is a set of queries and schemas.
@@ -19,12 +24,34 @@ type Set struct {
 	Schemas []Schema `json:"schemas,omitempty"`
 }
 
+// ReadSnapshot reads a set from a json or yaml snapshot, as generated by the
+// json and yaml templates. The format is determined by the file extension.
+func ReadSnapshot(name string) (*Set, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	set := new(Set)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buf, set)
+	default:
+		err = json.Unmarshal(buf, set)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return set, nil
+}
+
 // Query is a query.
 type Query struct {
 	Driver       string   `json:"driver,omitempty"`
 	Name         string   `json:"name,omitempty"`
 	Comment      string   `json:"comment,omitempty"`
 	Exec         bool     `json:"exec,omitempty"`
+	ExecRows     bool     `json:"exec_rows,omitempty"`
+	ExpectRows   int      `json:"expect_rows,omitempty"`
 	Flat         bool     `json:"flat,omitempty"`
 	One          bool     `json:"one,omitempty"`
 	Interpolate  bool     `json:"interpolate,omitempty"`
@@ -35,6 +62,15 @@ type Query struct {
 	Params       []Field  `json:"params,omitempty"`
 	Query        []string `json:"query,omitempty"`
 	Comments     []string `json:"comments,omitempty"`
+	Tables       []Table  `json:"tables,omitempty"`  // tables whose types are reused for the results
+	Results      []Result `json:"results,omitempty"` // result sets, when returning multiple result sets
+}
+
+// Result is a result set of a query returning multiple result sets.
+type Result struct {
+	Name         string  `json:"name,omitempty"`
+	Fields       []Field `json:"fields,omitempty"`
+	ManualFields bool    `json:"manual_fields,omitempty"` // fields provided by user
 }
 
 // MarshalYAML satisfies the yaml.Marshaler interface.
@@ -72,13 +108,15 @@ type Enum struct {
 
 // Proc is a stored procedure.
 type Proc struct {
-	ID         string  `json:"-"`
-	Type       string  `json:"type,omitempty"` // 'procedure' or 'function'
-	Name       string  `json:"name,omitempty"`
-	Params     []Field `json:"params,omitempty"`
-	Returns    []Field `json:"return,omitempty"`
-	Void       bool    `json:"void,omitempty"`
-	Definition string  `json:"definition,omitempty"`
+	ID         string   `json:"-"`
+	Type       string   `json:"type,omitempty"` // 'procedure' or 'function'
+	Name       string   `json:"name,omitempty"`
+	Params     []Field  `json:"params,omitempty"`
+	Returns    []Field  `json:"return,omitempty"`
+	Void       bool     `json:"void,omitempty"`
+	Set        bool     `json:"set,omitempty"`     // returns rows
+	Results    []Result `json:"results,omitempty"` // result sets returned by a procedure
+	Definition string   `json:"definition,omitempty"`
 }
 
 // MarshalYAML satisfies the yaml.Marshaler interface.
@@ -96,6 +134,7 @@ type Table struct {
 	PrimaryKeys []Field      `json:"primary_keys,omitempty"`
 	Indexes     []Index      `json:"indexes,omitempty"`
 	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+	Checks      []Check      `json:"checks,omitempty"`
 	Manual      bool         `json:"manual,omitempty"`
 	Definition  string       `json:"definition,omitempty"` // empty for tables
 }
@@ -120,12 +159,20 @@ type Index struct {
 type ForeignKey struct {
 	Name      string  `json:"name,omitempty"`       // constraint name
 	Fields    []Field `json:"column,omitempty"`     // column that has the key on it
+	RefSchema string  `json:"ref_schema,omitempty"` // schema of the ref table, when not the table's schema
 	RefTable  string  `json:"ref_table,omitempty"`  // table the foreign key refers to
 	RefFields []Field `json:"ref_column,omitempty"` // column in ref table the index refers to
 	Func      string  `json:"-"`                    // foreign key func name (based on fkey mode)
 	RefFunc   string  `json:"-"`                    // func name from ref index
 }
 
+// Check is a check constraint.
+type Check struct {
+	Name   string  `json:"name,omitempty"`
+	Expr   string  `json:"expr,omitempty"`   // check expression
+	Fields []Field `json:"fields,omitempty"` // columns used in the expression
+}
+
 // Field is a column, index, enum value, or stored procedure parameter.
 type Field struct {
 	Name        string `json:"name,omitempty"`
@@ -136,6 +183,9 @@ type Field struct {
 	ConstValue  *int   `json:"const_value,omitempty"`
 	Interpolate bool   `json:"interpolate,omitempty"`
 	Join        bool   `json:"join,omitempty"`
+	Expand      bool   `json:"expand,omitempty"`
+	Inferred    bool   `json:"inferred,omitempty"`
+	Direction   string `json:"direction,omitempty"` // proc param direction ('out' or 'inout'; empty for 'in')
 	Comment     string `json:"comment,omitempty"`
 }
 